@@ -0,0 +1,85 @@
+package kvstore
+
+import (
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TrackAccess configures the store to record the time each key was last read by Get, GetWithSource, GetMany,
+// and GetJSON. This turns reads into writes, so it is disabled by default. Access times are recorded on a
+// best-effort basis and failures to record them do not make reads fail.
+func TrackAccess() Option {
+	return func(o *options) {
+		o.trackAccess = true
+	}
+}
+
+// initAccess creates the table holding access times, which is kept separate from kv so that recording
+// an access does not rewrite values or mark them for reindexing.
+func initAccess(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_access(
+  key TEXT PRIMARY KEY NOT NULL,
+  last_accessed INTEGER NOT NULL,
+  access_count INTEGER NOT NULL DEFAULT 0
+);
+CREATE TRIGGER IF NOT EXISTS kv_access_delete AFTER DELETE ON kv BEGIN
+  DELETE FROM kv_access WHERE key=old.key;
+END;
+CREATE TRIGGER IF NOT EXISTS kv_access_rename AFTER UPDATE OF key ON kv WHEN old.key<>new.key BEGIN
+  UPDATE kv_access SET key=new.key WHERE key=old.key;
+END;
+`)
+	if err != nil {
+		return err
+	}
+	return addColumn(tx, "kv_access", "access_count", "INTEGER NOT NULL DEFAULT 0")
+}
+
+// touch records the current time as the last access time of the given keys if access tracking is enabled.
+func (db *KVStore) touch(keys ...string) {
+	if db.opts.trackAccess {
+		db.recordAccess(keys...)
+	}
+}
+
+// recordAccess records the current time as the last access time of the given keys.
+func (db *KVStore) recordAccess(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+	now := db.now().UnixMilli()
+	for _, key := range keys {
+		_, err := tx.Exec(`INSERT INTO kv_access(key,last_accessed,access_count) SELECT key,?,1 FROM kv WHERE key=?
+ON CONFLICT(key) DO UPDATE SET last_accessed=excluded.last_accessed,access_count=access_count+1;`, now, key)
+		if err != nil {
+			return
+		}
+	}
+	tx.Commit()
+}
+
+// LeastRecentlyUsed returns up to n keys that have been used least recently, starting with the least recently
+// used one. A key counts as used when it was last read, if access tracking is enabled, or last modified,
+// whichever is later. All keys are returned if n is not positive.
+func (db *KVStore) LeastRecentlyUsed(n int) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		n = -1
+	}
+	var keys []string
+	err := db.sqx.Select(&keys, `SELECT kv.key FROM kv LEFT JOIN kv_access a ON a.key=kv.key
+ORDER BY max(coalesce(a.last_accessed,0),coalesce(kv.updated_at,0)),kv.key LIMIT ?;`, n)
+	return keys, err
+}
@@ -0,0 +1,40 @@
+package kvstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeastRecentlyUsed(t *testing.T) {
+	db := New(TrackAccess())
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	for _, key := range []string{"a", "b", "c"} {
+		if err := db.Set(key, key); err != nil {
+			t.Fatalf(`failed to set key: %v`, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := db.Get("a"); err != nil {
+		t.Fatalf(`failed to get key: %v`, err)
+	}
+	keys, err := db.LeastRecentlyUsed(2)
+	if err != nil {
+		t.Fatalf(`failed to get least recently used keys: %v`, err)
+	}
+	if len(keys) != 2 || keys[0] != "b" || keys[1] != "c" {
+		t.Errorf(`expected [b c], got %v`, keys)
+	}
+	if err := db.Delete("b"); err != nil {
+		t.Fatalf(`failed to delete key: %v`, err)
+	}
+	keys, err = db.LeastRecentlyUsed(0)
+	if err != nil {
+		t.Fatalf(`failed to get least recently used keys: %v`, err)
+	}
+	if len(keys) != 2 || keys[0] != "c" || keys[1] != "a" {
+		t.Errorf(`expected [c a], got %v`, keys)
+	}
+}
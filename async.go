@@ -0,0 +1,80 @@
+package kvstore
+
+import "sync"
+
+// asyncWrites holds the writes queued by SetAsync, which are performed in order by a worker goroutine that
+// runs while the queue is not empty.
+type asyncWrites struct {
+	mutex  sync.Mutex
+	queue  []asyncWrite
+	active bool // the worker is running
+}
+
+// asyncWrite is a queued write, or a barrier that is closed when all writes queued before it have completed.
+type asyncWrite struct {
+	key     string
+	value   any
+	done    func(error)
+	barrier chan struct{}
+}
+
+// SetAsync sets the value for the given key like Set without waiting for the write to complete, so that user
+// interface threads do not block on disk I/O. Writes are performed in the order of the calls to SetAsync by a
+// worker goroutine, which then calls done with the error returned by Set unless done is nil. The value must
+// not be modified until done has been called. Flush and Close wait for all writes queued before they were
+// called; done must therefore not call Flush or Close.
+func (db *KVStore) SetAsync(key string, value any, done func(error)) {
+	db.enqueueAsync(asyncWrite{key: key, value: value, done: done})
+}
+
+// enqueueAsync queues a write and starts the worker if it is not running.
+func (db *KVStore) enqueueAsync(w asyncWrite) {
+	a := &db.async
+	a.mutex.Lock()
+	a.queue = append(a.queue, w)
+	start := !a.active
+	a.active = true
+	a.mutex.Unlock()
+	if start {
+		go db.runAsync()
+	}
+}
+
+// runAsync performs queued writes until the queue is empty.
+func (db *KVStore) runAsync() {
+	a := &db.async
+	for {
+		a.mutex.Lock()
+		if len(a.queue) == 0 {
+			a.active = false
+			a.mutex.Unlock()
+			return
+		}
+		w := a.queue[0]
+		a.queue[0] = asyncWrite{}
+		a.queue = a.queue[1:]
+		a.mutex.Unlock()
+		if w.barrier != nil {
+			close(w.barrier)
+			continue
+		}
+		err := db.Set(w.key, w.value)
+		if w.done != nil {
+			w.done(err)
+		}
+	}
+}
+
+// waitAsync waits until all writes queued by SetAsync before the call have completed.
+func (db *KVStore) waitAsync() {
+	a := &db.async
+	a.mutex.Lock()
+	if !a.active {
+		a.mutex.Unlock()
+		return
+	}
+	barrier := make(chan struct{})
+	a.mutex.Unlock()
+	db.enqueueAsync(asyncWrite{barrier: barrier})
+	<-barrier
+}
@@ -0,0 +1,52 @@
+package kvstore
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestSetAsync(t *testing.T) {
+	db := New()
+	if err := db.Open(InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	var mutex sync.Mutex
+	var order []int
+	for i := range 100 {
+		db.SetAsync("counter", i, func(err error) {
+			if err != nil {
+				t.Errorf(`set %v: %v`, i, err)
+			}
+			mutex.Lock()
+			order = append(order, i)
+			mutex.Unlock()
+		})
+	}
+	if err := db.Flush(); err != nil {
+		t.Fatalf(`flush: %v`, err)
+	}
+	mutex.Lock()
+	if len(order) != 100 {
+		t.Errorf(`expected all writes to complete before Flush returns, got %v`, len(order))
+	}
+	for i, n := range order {
+		if n != i {
+			t.Errorf(`expected writes in order, got %v at %v`, n, i)
+			break
+		}
+	}
+	mutex.Unlock()
+	if v, err := db.Get("counter"); err != nil || v != 99 {
+		t.Errorf(`expected last value, got %v, %v`, v, err)
+	}
+	db.SetAsync("closing", true, nil)
+	if err := db.Close(); err != nil {
+		t.Fatalf(`close: %v`, err)
+	}
+	done := make(chan error)
+	db.SetAsync("closed", true, func(err error) { done <- err })
+	if err := <-done; !errors.Is(err, NotOpenErr) {
+		t.Errorf(`expected NotOpenErr, got %v`, err)
+	}
+}
@@ -0,0 +1,101 @@
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var NotStructErr = errors.New(`expected a struct or a pointer to a struct`)
+
+// Save stores all exported fields of the struct src as individual keys in one transaction. The key of a field
+// is prefix followed by the name given in its `kv:"name"` tag, or the field name if there is no tag.
+// Fields tagged with `kv:"-"` are skipped. Field values must be gob serializable.
+func (db *KVStore) Save(prefix string, src any) error {
+	v := reflect.ValueOf(src)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return NotStructErr
+	}
+	pairs := make(map[string]any)
+	for name, i := range boundFields(v.Type()) {
+		pairs[prefix+name] = v.Field(i).Interface()
+	}
+	return db.SetMany(pairs)
+}
+
+// Load reads the keys for all exported fields of the struct pointed to by dest, using the same key names as Save.
+// Fields for which neither a value nor a default is stored are left unchanged. Numeric values are converted
+// to the type of the field if they fit into it without overflow or loss of a fractional part.
+func (db *KVStore) Load(prefix string, dest any) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return NotStructErr
+	}
+	v = v.Elem()
+	fields := boundFields(v.Type())
+	keys := make([]string, 0, len(fields))
+	for name := range fields {
+		keys = append(keys, prefix+name)
+	}
+	values, err := db.GetMany(keys)
+	if err != nil {
+		return err
+	}
+	for name, i := range fields {
+		value, ok := values[prefix+name]
+		if !ok || value == nil {
+			continue
+		}
+		field := v.Field(i)
+		rv := reflect.ValueOf(value)
+		switch {
+		case rv.Type().AssignableTo(field.Type()):
+			field.Set(rv)
+		case isNumeric(rv.Kind()) && isNumeric(field.Kind()):
+			converted, ok := convertNumber(rv, field.Type())
+			if !ok {
+				return fmt.Errorf("%w: value %v for key %q does not fit into field of type %v",
+					TypeMismatchErr, value, prefix+name, field.Type())
+			}
+			field.Set(converted)
+		default:
+			return fmt.Errorf("%w: cannot load value of type %T for key %q into field of type %v",
+				TypeMismatchErr, value, prefix+name, field.Type())
+		}
+	}
+	return nil
+}
+
+// boundFields returns the key names of all exported fields of a struct type mapped to their field index.
+func boundFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Tag.Get("kv")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+// isNumeric returns true if the kind is an integer or floating point kind.
+func isNumeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
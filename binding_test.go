@@ -0,0 +1,48 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+type testSettings struct {
+	Width    int     `kv:"width"`
+	Height   int     `kv:"height"`
+	Scale    float32 `kv:"scale"`
+	Theme    string
+	Volatile string `kv:"-"`
+	hidden   int
+}
+
+func TestSaveLoad(t *testing.T) {
+	db := openTestStore(t)
+	src := testSettings{Width: 800, Height: 600, Scale: 1.5, Theme: "dark", Volatile: "x", hidden: 3}
+	if err := db.Save("window.", src); err != nil {
+		t.Fatalf(`failed to save struct: %v`, err)
+	}
+	if v, _ := db.Get("window.width"); v != 800 {
+		t.Errorf(`expected field to be stored under tag name, got %v`, v)
+	}
+	if ok, _ := db.Has("window.Volatile"); ok {
+		t.Errorf(`skipped field was stored`)
+	}
+	if err := db.Set("window.height", int64(700)); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	dest := testSettings{Volatile: "y"}
+	if err := db.Load("window.", &dest); err != nil {
+		t.Fatalf(`failed to load struct: %v`, err)
+	}
+	if dest.Width != 800 || dest.Height != 700 || dest.Scale != 1.5 || dest.Theme != "dark" || dest.Volatile != "y" {
+		t.Errorf(`wrong struct loaded: %+v`, dest)
+	}
+	if err := db.Load("window.", dest); !errors.Is(err, NotStructErr) {
+		t.Errorf(`expected NotStructErr for non-pointer, got %v`, err)
+	}
+	if err := db.Set("window.width", 1.7); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Load("window.", &dest); !errors.Is(err, TypeMismatchErr) || dest.Width != 800 {
+		t.Errorf(`expected TypeMismatchErr for fractional value, got %v, %v`, dest.Width, err)
+	}
+}
@@ -0,0 +1,127 @@
+package kvstore
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// RevertAll reverts all keys that have a default to their defaults in one transaction.
+func (db *KVStore) RevertAll() error {
+	return db.revertWhere(`original IS NOT NULL`)
+}
+
+// RevertCategory reverts all keys in the given category that have a default to their defaults in one transaction.
+func (db *KVStore) RevertCategory(category string) error {
+	return db.revertWhere(`original IS NOT NULL AND category=?`, category)
+}
+
+// revertWhere reverts all keys matching the given SQL condition in one transaction.
+func (db *KVStore) revertWhere(cond string, args ...any) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	defer db.cache.clear()
+	var changes []change
+	if db.hasListeners() {
+		var keys []string
+		if err := tx.Select(&keys, `SELECT key FROM kv WHERE `+cond+` AND value IS NOT NULL;`, args...); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			changes = append(changes, change{key: k, old: db.current(tx, k)})
+		}
+	}
+	args = append([]any{db.now().UnixMilli()}, args...)
+	if _, err := tx.Exec(`UPDATE kv SET `+revertColumns+` WHERE `+cond+`;`, args...); err != nil {
+		return err
+	}
+	for i := range changes {
+		changes[i].new = db.current(tx, changes[i].key)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := db.notify(changes...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteByCategory removes all keys in the given category in one transaction.
+func (db *KVStore) DeleteByCategory(category string) error {
+	return db.deleteWhere(`category=?`, category)
+}
+
+// DeleteByPrefix removes all keys starting with the given prefix in one transaction. The prefix
+// is matched case-sensitively.
+func (db *KVStore) DeleteByPrefix(prefix string) error {
+	return db.deleteWhere(`key GLOB ?`, globPrefix(prefix))
+}
+
+// deleteWhere removes all keys matching the given SQL condition in one transaction.
+func (db *KVStore) deleteWhere(cond string, args ...any) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	defer db.cache.clear()
+	notify := db.hasListeners()
+	var changes []change
+	if notify || db.hasDeleteHooks() {
+		var keys []string
+		if err := tx.Select(&keys, `SELECT key FROM kv WHERE `+cond+` ORDER BY key;`, args...); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := db.beforeDelete(k); err != nil {
+				return keyError("delete", k, err)
+			}
+			if notify {
+				changes = append(changes, change{key: k, old: db.current(tx, k)})
+			}
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM kv WHERE `+cond+`;`, args...); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := db.notify(changes...); err != nil {
+		return err
+	}
+	db.removeOrphans()
+	return nil
+}
+
+// globPrefix returns a GLOB pattern matching all strings starting with prefix.
+func globPrefix(prefix string) string {
+	var sb strings.Builder
+	for _, r := range prefix {
+		switch r {
+		case '*', '?', '[':
+			sb.WriteRune('[')
+			sb.WriteRune(r)
+			sb.WriteRune(']')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteRune('*')
+	return sb.String()
+}
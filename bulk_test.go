@@ -0,0 +1,72 @@
+package kvstore
+
+import "testing"
+
+func TestRevertAll(t *testing.T) {
+	db := openTestStore(t)
+	for _, category := range []string{"display", "network"} {
+		if err := db.SetDefault(category+".a", "default", KeyInfo{Category: category}); err != nil {
+			t.Fatalf(`failed to set default: %v`, err)
+		}
+		if err := db.Set(category+".a", "changed"); err != nil {
+			t.Fatalf(`failed to set key: %v`, err)
+		}
+	}
+	if err := db.Set("plain", "value"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	notified := 0
+	db.OnChange(func(key string, old, new any) { notified++ })
+	if err := db.RevertCategory("display"); err != nil {
+		t.Fatalf(`failed to revert category: %v`, err)
+	}
+	if v, _ := db.Get("display.a"); v != "default" {
+		t.Errorf(`key in category was not reverted, got %v`, v)
+	}
+	if v, _ := db.Get("network.a"); v != "changed" {
+		t.Errorf(`key in other category was reverted`)
+	}
+	if err := db.RevertAll(); err != nil {
+		t.Fatalf(`failed to revert all: %v`, err)
+	}
+	if v, _ := db.Get("network.a"); v != "default" {
+		t.Errorf(`key was not reverted, got %v`, v)
+	}
+	if v, _ := db.Get("plain"); v != "value" {
+		t.Errorf(`key without default was changed by revert, got %v`, v)
+	}
+	if notified != 2 {
+		t.Errorf(`expected 2 change notifications, got %v`, notified)
+	}
+}
+
+func TestDeleteByCategoryAndPrefix(t *testing.T) {
+	db := openTestStore(t)
+	for _, key := range []string{"plugin.a", "plugin.b", "plugin*x", "pluginc", "other"} {
+		if err := db.Set(key, 1); err != nil {
+			t.Fatalf(`failed to set key: %v`, err)
+		}
+	}
+	if err := db.SetDefault("legacy", 1, KeyInfo{Category: "old"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.DeleteByCategory("old"); err != nil {
+		t.Fatalf(`failed to delete by category: %v`, err)
+	}
+	if ok, _ := db.Has("legacy"); ok {
+		t.Errorf(`key in deleted category is still present`)
+	}
+	if err := db.DeleteByPrefix("plugin."); err != nil {
+		t.Fatalf(`failed to delete by prefix: %v`, err)
+	}
+	if err := db.DeleteByPrefix("plugin*"); err != nil {
+		t.Fatalf(`failed to delete by prefix: %v`, err)
+	}
+	all, err := db.GetAll(0)
+	if err != nil {
+		t.Fatalf(`failed to get all: %v`, err)
+	}
+	if len(all) != 2 || all["pluginc"] != 1 || all["other"] != 1 {
+		t.Errorf(`wrong keys remaining after delete by prefix: %v`, all)
+	}
+}
@@ -0,0 +1,107 @@
+package kvstore
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	busyRetries      = 8                     // maximum number of retries in multi-process mode
+	busyInitialDelay = 10 * time.Millisecond // delay before the first retry, doubled for each further retry
+	busyTimeout      = 5 * time.Second       // sqlite's busy timeout
+)
+
+// RetryPolicy configures how operations are retried when they fail because the database is locked by
+// another connection, see Retry.
+type RetryPolicy struct {
+	MaxRetries   int           // maximum number of retries, none if zero
+	InitialDelay time.Duration // delay before the first retry, doubled for each further retry
+	MaxDelay     time.Duration // maximum delay between retries, unlimited if zero
+	BusyTimeout  time.Duration // time sqlite waits for a lock before failing, 5s if zero, none if negative
+}
+
+// Retry configures how operations are retried when the database is busy. By default, sqlite waits for
+// locks for up to 5 seconds, and operations that still fail are retried 8 times with delays doubling from
+// 10ms in multi-process mode, and not at all otherwise. The policy applies to all operations that write,
+// in both modes.
+func Retry(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = &policy
+	}
+}
+
+// busyTimeout returns the busy timeout of the policy, the default for a nil policy.
+func (p *RetryPolicy) busyTimeout() time.Duration {
+	switch {
+	case p == nil || p.BusyTimeout == 0:
+		return busyTimeout
+	case p.BusyTimeout < 0:
+		return 0
+	}
+	return p.BusyTimeout
+}
+
+// retryPolicy returns the configured retry policy or the default for the mode of the store.
+func (db *KVStore) retryPolicy() RetryPolicy {
+	if db.opts.retry != nil {
+		return *db.opts.retry
+	}
+	if db.opts.multiProcess {
+		return RetryPolicy{MaxRetries: busyRetries, InitialDelay: busyInitialDelay}
+	}
+	return RetryPolicy{}
+}
+
+// retry calls fn and retries it with exponential backoff according to the retry policy as long as it fails
+// because the database is busy.
+func (db *KVStore) retry(fn func() error) error {
+	err := fn()
+	policy := db.retryPolicy()
+	delay := policy.InitialDelay
+	for i := 0; i < policy.MaxRetries && isBusy(err); i++ {
+		time.Sleep(delay)
+		delay *= 2
+		if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+		err = fn()
+	}
+	return err
+}
+
+// begin starts a write transaction, retrying if the database is busy.
+func (db *KVStore) begin() (*sqlx.Tx, error) {
+	var tx *sqlx.Tx
+	err := db.retry(func() error {
+		var err error
+		tx, err = db.writeDB().Beginx()
+		return err
+	})
+	return tx, err
+}
+
+// exec executes a statement outside of a transaction, retrying if the database is busy.
+func (db *KVStore) exec(query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := db.retry(func() error {
+		var err error
+		result, err = db.writeDB().Exec(query, args...)
+		return err
+	})
+	return result, err
+}
+
+// inTx runs fn within a write transaction started with begin, which is committed if fn returns nil.
+func (db *KVStore) inTx(fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,85 @@
+package kvstore
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMultiProcess(t *testing.T) {
+	dir := t.TempDir()
+	stores := []*KVStore{New(MultiProcess()), New(MultiProcess())}
+	for _, db := range stores {
+		if err := db.Open(dir); err != nil {
+			t.Fatalf(`failed to open database: %v`, err)
+		}
+		defer db.Close()
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 400)
+	for i, db := range stores {
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(db *KVStore, prefix string) {
+				defer wg.Done()
+				for n := 0; n < 50; n++ {
+					pairs := map[string]any{fmt.Sprintf("%v.%v", prefix, n): n}
+					if err := db.SetMany(pairs); err != nil {
+						errs <- err
+					}
+				}
+			}(db, fmt.Sprintf("%v.%v", i, g))
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf(`concurrent write failed: %v`, err)
+	}
+	for _, db := range stores {
+		all, err := db.GetAll(0)
+		if err != nil {
+			t.Fatalf(`failed to get all: %v`, err)
+		}
+		if len(all) != 400 {
+			t.Errorf(`expected 400 keys visible in both stores, got %v`, len(all))
+		}
+		var mode string
+		if err := db.sqx.Get(&mode, `PRAGMA journal_mode;`); err != nil || mode != "wal" {
+			t.Errorf(`expected WAL journal mode, got %v, %v`, mode, err)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	dir := t.TempDir()
+	locker := New()
+	if err := locker.Open(dir); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer locker.Close()
+	noRetry := New(Retry(RetryPolicy{BusyTimeout: -1}))
+	withRetry := New(Retry(RetryPolicy{MaxRetries: 50, InitialDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond,
+		BusyTimeout: -1}))
+	for _, db := range []*KVStore{noRetry, withRetry} {
+		if err := db.Open(dir); err != nil {
+			t.Fatalf(`failed to open database: %v`, err)
+		}
+		defer db.Close()
+	}
+	tx, err := locker.begin()
+	if err != nil {
+		t.Fatalf(`failed to begin transaction: %v`, err)
+	}
+	if err := noRetry.Set("a", 1); !isBusy(err) {
+		t.Errorf(`expected busy error without retries, got %v`, err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		tx.Rollback()
+	}()
+	if err := withRetry.Set("a", 1); err != nil {
+		t.Errorf(`expected Set to succeed after retries, got %v`, err)
+	}
+}
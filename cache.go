@@ -0,0 +1,183 @@
+package kvstore
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// Cache configures an in-process read cache for decoded values in front of the database. The cache holds at most
+// maxEntries values and at most maxBytes bytes of encoded values, where a limit of zero or less means that there
+// is no limit of this kind. The cache is disabled if both limits are zero or less. Values returned from
+// the cache are shared between callers of Get and must not be modified.
+func Cache(maxEntries int, maxBytes int64) Option {
+	return func(o *options) {
+		o.cacheEntries = maxEntries
+		o.cacheBytes = maxBytes
+	}
+}
+
+// readCache is a least-recently-used cache of decoded values. A nil cache is valid and caches nothing.
+type readCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	generation uint64 // incremented on every invalidation
+	order      *list.List
+	entries    map[string]*list.Element
+	version    *sql.Conn // connection for detecting changes by other processes, nil if not needed
+	dataVer    int64
+}
+
+type cacheEntry struct {
+	key   string
+	value any
+	size  int64
+}
+
+// newReadCache returns a new cache for the given limits, nil if caching is disabled.
+func newReadCache(maxEntries int, maxBytes int64) *readCache {
+	if maxEntries <= 0 && maxBytes <= 0 {
+		return nil
+	}
+	return &readCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// watch makes the cache check for changes made by other connections before each read, using a dedicated
+// connection of the pool. This is used in multi-process mode.
+func (c *readCache) watch(sq *sql.DB) error {
+	if c == nil {
+		return nil
+	}
+	conn, err := sq.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.version = conn
+	return c.version.QueryRowContext(context.Background(), `PRAGMA data_version;`).Scan(&c.dataVer)
+}
+
+// close releases the connection used for detecting changes and empties the cache.
+func (c *readCache) close() error {
+	if c == nil {
+		return nil
+	}
+	c.clear()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.version == nil {
+		return nil
+	}
+	err := c.version.Close()
+	c.version = nil
+	return err
+}
+
+// get returns the cached value for the key, false if it is not cached.
+func (c *readCache) get(key string) (any, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checkVersion()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).value, true
+}
+
+// checkVersion empties the cache if another connection has changed the database. The caller must hold the mutex.
+func (c *readCache) checkVersion() {
+	if c.version == nil {
+		return
+	}
+	var v int64
+	err := c.version.QueryRowContext(context.Background(), `PRAGMA data_version;`).Scan(&v)
+	if err != nil || v != c.dataVer {
+		c.dataVer = v
+		c.purge()
+	}
+}
+
+// gen returns the current generation. It must be obtained before reading a value from the database,
+// and passed to add so that values are not cached if they have been invalidated in the meantime.
+func (c *readCache) gen() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.generation
+}
+
+// add caches the value for the key unless the cache has been invalidated since generation gen.
+func (c *readCache) add(gen uint64, key string, value any, size int64) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if gen != c.generation || (c.maxBytes > 0 && size > c.maxBytes) {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value, size: size})
+	c.bytes += size
+	for (c.maxEntries > 0 && len(c.entries) > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// remove invalidates the cached values for the given keys.
+func (c *readCache) remove(keys ...string) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+	for _, key := range keys {
+		if elem, ok := c.entries[key]; ok {
+			c.removeElement(elem)
+		}
+	}
+}
+
+// clear invalidates all cached values.
+func (c *readCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.purge()
+}
+
+// purge removes all entries. The caller must hold the mutex.
+func (c *readCache) purge() {
+	c.generation++
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	c.bytes = 0
+}
+
+// removeElement removes an entry. The caller must hold the mutex.
+func (c *readCache) removeElement(elem *list.Element) {
+	entry := c.order.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
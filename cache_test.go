@@ -0,0 +1,77 @@
+package kvstore
+
+import "testing"
+
+func TestCache(t *testing.T) {
+	db := New(Cache(2, 0))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	if err := db.SetMany(map[string]any{"a": 1, "b": 2, "c": 3}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	if v, err := db.Get("a"); v != 1 || err != nil {
+		t.Fatalf(`expected 1, got %v, %v`, v, err)
+	}
+	// change the table behind the cache's back to check that cached values are returned
+	b, _ := MarshalBinary(10)
+	if _, err := db.sqx.Exec(`UPDATE kv SET value=? WHERE key='a';`, b); err != nil {
+		t.Fatalf(`failed to update table: %v`, err)
+	}
+	if v, _ := db.Get("a"); v != 1 {
+		t.Errorf(`expected cached value 1, got %v`, v)
+	}
+	db.Get("b")
+	db.Get("c")
+	if v, _ := db.Get("a"); v != 10 {
+		t.Errorf(`expected least recently used entry to be evicted, got %v`, v)
+	}
+	if err := db.Set("a", 11); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if v, _ := db.Get("a"); v != 11 {
+		t.Errorf(`expected cache to be invalidated by Set, got %v`, v)
+	}
+	if err := db.SetDefault("d", "default", KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	db.Get("d")
+	if err := db.Set("d", "value"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Revert("d"); err != nil {
+		t.Fatalf(`failed to revert key: %v`, err)
+	}
+	if v, _ := db.Get("d"); v != "default" {
+		t.Errorf(`expected cache to be invalidated by Revert, got %v`, v)
+	}
+	if err := db.Delete("d"); err != nil {
+		t.Fatalf(`failed to delete key: %v`, err)
+	}
+	if _, err := db.Get("d"); err == nil {
+		t.Errorf(`expected cache to be invalidated by Delete`)
+	}
+}
+
+func TestCacheMultiProcess(t *testing.T) {
+	dir := t.TempDir()
+	db := New(MultiProcess(), Cache(100, 0))
+	other := New(MultiProcess())
+	for _, store := range []*KVStore{db, other} {
+		if err := store.Open(dir); err != nil {
+			t.Fatalf(`failed to open database: %v`, err)
+		}
+		defer store.Close()
+	}
+	if err := db.Set("shared", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	db.Get("shared")
+	if err := other.Set("shared", 2); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if v, _ := db.Get("shared"); v != 2 {
+		t.Errorf(`expected cache to be invalidated by change of other process, got %v`, v)
+	}
+}
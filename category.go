@@ -0,0 +1,91 @@
+package kvstore
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// GetByCategory returns all key-value pairs whose category is the given category. As with Get, the default
+// is returned for a key if no value has been set.
+func (db *KVStore) GetByCategory(category string) (map[string]any, error) {
+	return db.getByCondition(`kv.category=?`, category)
+}
+
+// getByCondition returns all key-value pairs of rows matching the SQL condition.
+func (db *KVStore) getByCondition(cond string, args ...any) (map[string]any, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	rows, err := db.queryLive(db.sqx, cond, `ORDER BY kv.key ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make(map[string]any)
+	var sliding []string
+	for rows.Next() {
+		key, sv, slides, scanErr := scanLive(rows)
+		if scanErr != nil {
+			return result, scanErr
+		}
+		v, ok, err2 := sv.decode()
+		if err2 != nil {
+			err = errors.Join(err, err2)
+		} else if ok {
+			result[key] = v
+			if slides {
+				sliding = append(sliding, key)
+			}
+		}
+	}
+	err = errors.Join(err, rows.Err())
+	rows.Close()
+	if err == nil {
+		err = db.redact(result)
+	}
+	db.recordAccess(sliding...)
+	return result, err
+}
+
+// KeysByCategory returns the keys in the given category in ascending order.
+func (db *KVStore) KeysByCategory(category string) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	return db.liveKeys(`kv.category=?`, `ORDER BY kv.key ASC`, category)
+}
+
+// CategoryCount is a category together with the number of keys in it.
+type CategoryCount struct {
+	Category string
+	Count    int
+}
+
+// Categories returns all distinct non-empty categories in ascending order together with the number of
+// keys in each of them.
+func (db *KVStore) Categories() ([]CategoryCount, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	categories := make([]CategoryCount, 0)
+	rows, err := db.sqx.Queryx(`SELECT category, COUNT(*) FROM kv WHERE category IS NOT NULL AND category<>''
+GROUP BY category ORDER BY category ASC;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c CategoryCount
+		if err := rows.Scan(&c.Category, &c.Count); err != nil {
+			return categories, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
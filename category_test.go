@@ -0,0 +1,49 @@
+package kvstore
+
+import "testing"
+
+func TestCategories(t *testing.T) {
+	db := openTestStore(t)
+	defaults := []struct {
+		key, category string
+		value         int
+	}{
+		{"window.width", "display", 800},
+		{"window.height", "display", 600},
+		{"proxy.port", "network", 8080},
+	}
+	for _, d := range defaults {
+		if err := db.SetDefault(d.key, d.value, KeyInfo{Category: d.category}); err != nil {
+			t.Fatalf(`failed to set default: %v`, err)
+		}
+	}
+	if err := db.Set("window.width", 1024); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	keys, err := db.KeysByCategory("display")
+	if err != nil {
+		t.Fatalf(`failed to get keys by category: %v`, err)
+	}
+	if len(keys) != 2 || keys[0] != "window.height" || keys[1] != "window.width" {
+		t.Errorf(`wrong keys for category: %v`, keys)
+	}
+	values, err := db.GetByCategory("display")
+	if err != nil {
+		t.Fatalf(`failed to get by category: %v`, err)
+	}
+	if len(values) != 2 || values["window.width"] != 1024 || values["window.height"] != 600 {
+		t.Errorf(`wrong values for category: %v`, values)
+	}
+	values, err = db.GetByCategory("nonexistent")
+	if err != nil || len(values) != 0 {
+		t.Errorf(`expected no values for nonexistent category, got %v, %v`, values, err)
+	}
+	categories, err := db.Categories()
+	if err != nil {
+		t.Fatalf(`failed to get categories: %v`, err)
+	}
+	if len(categories) != 2 || categories[0] != (CategoryCount{"display", 2}) ||
+		categories[1] != (CategoryCount{"network", 1}) {
+		t.Errorf(`wrong categories: %v`, categories)
+	}
+}
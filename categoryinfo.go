@@ -0,0 +1,92 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CategoryInfo describes a category of keys, so that a settings user interface can present categories without
+// further information from the application.
+type CategoryInfo struct {
+	Category    string `json:"category"`
+	Name        string `json:"name,omitempty"` // the display name of the category
+	Description string `json:"description,omitempty"`
+	Order       int    `json:"order,omitempty"` // categories are sorted by ascending order, then by category
+	Icon        string `json:"icon,omitempty"`  // a hint which icon to show for the category, e.g. "network"
+}
+
+// initCategoryInfo creates the table holding category infos.
+func initCategoryInfo(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_category_info(
+  category TEXT PRIMARY KEY NOT NULL,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL,
+  sort_order INTEGER NOT NULL,
+  icon TEXT NOT NULL
+);
+`)
+	return err
+}
+
+// SetCategoryInfo sets the info for a category, which need not contain any keys yet. The Category field of
+// the info is ignored.
+func (db *KVStore) SetCategoryInfo(category string, info CategoryInfo) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	_, err := db.exec(`INSERT INTO kv_category_info(category,name,description,sort_order,icon) VALUES(?,?,?,?,?)
+ON CONFLICT(category) DO UPDATE SET name=excluded.name,description=excluded.description,sort_order=excluded.sort_order,
+icon=excluded.icon;`, category, info.Name, info.Description, info.Order, info.Icon)
+	return err
+}
+
+// DeleteCategoryInfo deletes the info for a category. Keys in the category are not affected.
+func (db *KVStore) DeleteCategoryInfo(category string) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	_, err := db.exec(`DELETE FROM kv_category_info WHERE category=?;`, category)
+	return err
+}
+
+// CategoryInfo returns the info for a category, NotFoundErr if none has been set.
+func (db *KVStore) CategoryInfo(category string) (CategoryInfo, error) {
+	info := CategoryInfo{Category: category}
+	if atomic.LoadUint32(&db.state) < 256 {
+		return info, NotOpenErr
+	}
+	err := db.sqx.QueryRowx(`SELECT name,description,sort_order,icon FROM kv_category_info WHERE category=?;`,
+		category).Scan(&info.Name, &info.Description, &info.Order, &info.Icon)
+	if errors.Is(err, sql.ErrNoRows) {
+		return info, NotFoundErr
+	}
+	return info, err
+}
+
+// CategoryInfos returns the infos of all categories that have an info or contain keys, sorted by their order and
+// then by category. Categories without info only have their Category field set.
+func (db *KVStore) CategoryInfos() ([]CategoryInfo, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	infos := make([]CategoryInfo, 0)
+	rows, err := db.sqx.Queryx(`SELECT c.category,COALESCE(i.name,''),COALESCE(i.description,''),COALESCE(i.sort_order,0),
+COALESCE(i.icon,'') FROM (SELECT category FROM kv_category_info UNION SELECT category FROM kv WHERE category IS NOT NULL
+AND category<>'') c LEFT JOIN kv_category_info i ON i.category=c.category ORDER BY 4 ASC, 1 ASC;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var info CategoryInfo
+		if err := rows.Scan(&info.Category, &info.Name, &info.Description, &info.Order, &info.Icon); err != nil {
+			return infos, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, rows.Err()
+}
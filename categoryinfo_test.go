@@ -0,0 +1,48 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCategoryInfo(t *testing.T) {
+	db := openTestStore(t)
+	if _, err := db.CategoryInfo("audio"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+	if err := db.SetDefault("volume", 50, KeyInfo{Category: "audio"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.SetDefault("theme", "dark", KeyInfo{Category: "appearance"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	info := CategoryInfo{Name: "Audio", Description: "Sound settings", Order: -1, Icon: "speaker"}
+	if err := db.SetCategoryInfo("audio", info); err != nil {
+		t.Fatalf(`failed to set category info: %v`, err)
+	}
+	if err := db.SetCategoryInfo("network", CategoryInfo{Name: "Network", Order: 1}); err != nil {
+		t.Fatalf(`failed to set category info: %v`, err)
+	}
+	info.Category = "audio"
+	if got, err := db.CategoryInfo("audio"); err != nil || got != info {
+		t.Errorf(`expected %+v, got %+v, %v`, info, got, err)
+	}
+	infos, err := db.CategoryInfos()
+	if err != nil {
+		t.Fatalf(`failed to list category infos: %v`, err)
+	}
+	if len(infos) != 3 || infos[0].Category != "audio" || infos[1].Category != "appearance" ||
+		infos[2].Category != "network" {
+		t.Errorf(`wrong category infos: %+v`, infos)
+	}
+	schema, err := db.Schema()
+	if err != nil || len(schema.Categories) != 3 || schema.Categories[0].Icon != "speaker" {
+		t.Errorf(`expected categories in schema, got %+v, %v`, schema.Categories, err)
+	}
+	if err := db.DeleteCategoryInfo("network"); err != nil {
+		t.Fatalf(`failed to delete category info: %v`, err)
+	}
+	if _, err := db.CategoryInfo("network"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr after delete, got %v`, err)
+	}
+}
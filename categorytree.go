@@ -0,0 +1,78 @@
+package kvstore
+
+import (
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// CategorySeparator separates the parts of category paths such as "Network/Proxy". Categories form a tree
+// in which "Network/Proxy" is a subcategory of "Network", which need not contain keys itself.
+const CategorySeparator = "/"
+
+// subtreeCondition returns the SQL condition selecting rows whose category column, which may be qualified with
+// the table, is the given category or one of its subcategories, together with its arguments. The empty category
+// selects all rows.
+func subtreeCondition(column, category string) (string, []any) {
+	category = strings.TrimSuffix(category, CategorySeparator)
+	if category == "" {
+		return `1`, nil
+	}
+	// all paths below category sort between category+"/" and category+"0", since '0' follows '/'
+	return `(` + column + `=? OR (` + column + `>=? AND ` + column + `<?))`,
+		[]any{category, category + CategorySeparator, category + "0"}
+}
+
+// GetByCategoryTree returns all key-value pairs whose category is the given category or one of its
+// subcategories, e.g. both "Network" and "Network/Proxy" for category "Network", or all key-value pairs if
+// the category is empty. As with Get, the default is returned for a key if no value has been set.
+func (db *KVStore) GetByCategoryTree(category string) (map[string]any, error) {
+	cond, args := subtreeCondition(`kv.category`, category)
+	return db.getByCondition(cond, args...)
+}
+
+// KeysByCategoryTree returns the keys whose category is the given category or one of its subcategories in
+// ascending order.
+func (db *KVStore) KeysByCategoryTree(category string) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	cond, args := subtreeCondition(`kv.category`, category)
+	return db.liveKeys(cond, `ORDER BY kv.key ASC`, args...)
+}
+
+// Subcategories returns the paths of the direct subcategories of the given category in ascending order, or
+// the top-level categories if the category is empty. A subcategory is returned if it or one of its own
+// subcategories contains keys or has a category info.
+func (db *KVStore) Subcategories(category string) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	cond, args := subtreeCondition(`category`, category)
+	var categories []string
+	err := db.sqx.Select(&categories, `SELECT category FROM kv WHERE category IS NOT NULL AND `+cond+`
+UNION SELECT category FROM kv_category_info WHERE `+cond+`;`, append(args, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.TrimSuffix(category, CategorySeparator)
+	if prefix != "" {
+		prefix += CategorySeparator
+	}
+	subcategories := make([]string, 0)
+	for _, c := range categories {
+		rest, ok := strings.CutPrefix(c, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(rest, CategorySeparator)
+		if name != "" {
+			subcategories = append(subcategories, prefix+name)
+		}
+	}
+	slices.Sort(subcategories)
+	return slices.Compact(subcategories), nil
+}
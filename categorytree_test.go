@@ -0,0 +1,41 @@
+package kvstore
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCategoryTree(t *testing.T) {
+	db := openTestStore(t)
+	for key, category := range map[string]string{
+		"online":     "Network",
+		"proxy.host": "Network/Proxy",
+		"proxy.user": "Network/Proxy/Auth",
+		"mtu":        "Network/Interface",
+		"other":      "NetworkTools",
+		"volume":     "Audio",
+	} {
+		if err := db.SetDefault(key, key, KeyInfo{Category: category}); err != nil {
+			t.Fatalf(`failed to set default: %v`, err)
+		}
+	}
+	if err := db.SetCategoryInfo("Network/VPN", CategoryInfo{Name: "VPN"}); err != nil {
+		t.Fatalf(`failed to set category info: %v`, err)
+	}
+	keys, err := db.KeysByCategoryTree("Network/Proxy")
+	if err != nil || !slices.Equal(keys, []string{"proxy.host", "proxy.user"}) {
+		t.Errorf(`wrong keys in subtree: %v, %v`, keys, err)
+	}
+	values, err := db.GetByCategoryTree("Network")
+	if err != nil || len(values) != 4 || values["mtu"] != "mtu" {
+		t.Errorf(`wrong values in subtree: %v, %v`, values, err)
+	}
+	subcategories, err := db.Subcategories("Network")
+	if err != nil || !slices.Equal(subcategories, []string{"Network/Interface", "Network/Proxy", "Network/VPN"}) {
+		t.Errorf(`wrong subcategories: %v, %v`, subcategories, err)
+	}
+	top, err := db.Subcategories("")
+	if err != nil || !slices.Equal(top, []string{"Audio", "Network", "NetworkTools"}) {
+		t.Errorf(`wrong top-level categories: %v, %v`, top, err)
+	}
+}
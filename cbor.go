@@ -0,0 +1,31 @@
+package kvstore
+
+import "github.com/fxamacker/cbor/v2"
+
+// CBOR is a codec encoding values as CBOR (RFC 8949), which is compact and, unlike gob, can be read by tools
+// not written in Go. Maps are decoded as map[any]any, structs as maps, and integers as uint64 or int64.
+var CBOR Codec = cborCodec{}
+
+func init() {
+	RegisterCodec(CBOR)
+}
+
+// cborCodec implements the CBOR codec.
+type cborCodec struct{}
+
+// Name returns the name stored with CBOR encoded values.
+func (cborCodec) Name() string {
+	return "cbor"
+}
+
+// Marshal encodes a value as CBOR.
+func (cborCodec) Marshal(v any) ([]byte, error) {
+	return cbor.Marshal(v)
+}
+
+// Unmarshal decodes a CBOR encoded value.
+func (cborCodec) Unmarshal(b []byte) (any, error) {
+	var v any
+	err := cbor.Unmarshal(b, &v)
+	return v, err
+}
@@ -0,0 +1,38 @@
+package kvstore
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestCBOR(t *testing.T) {
+	db := openTestStore(t)
+	value := map[string]any{"name": "test", "tags": []any{"a", "b"}}
+	if err := db.SetWithCodec("doc", value, CBOR); err != nil {
+		t.Fatalf(`failed to set CBOR value: %v`, err)
+	}
+	b, err := db.GetRaw("doc")
+	if err != nil {
+		t.Fatalf(`failed to get raw value: %v`, err)
+	}
+	var decoded map[string]any
+	if err := cbor.Unmarshal(b, &decoded); err != nil || !reflect.DeepEqual(decoded, value) {
+		t.Errorf(`expected value readable as plain CBOR, got %v, %v`, decoded, err)
+	}
+	v, err := db.Get("doc")
+	if err != nil {
+		t.Fatalf(`failed to get CBOR value: %v`, err)
+	}
+	if m, ok := v.(map[any]any); !ok || m["name"] != "test" {
+		t.Errorf(`unexpected decoded value %v`, v)
+	}
+	if _, err := db.sqx.Exec(`UPDATE kv SET codec='missing' WHERE key='doc';`); err != nil {
+		t.Fatalf(`failed to update codec: %v`, err)
+	}
+	if _, err := db.Get("doc"); !errors.Is(err, UnknownCodecErr) {
+		t.Errorf(`expected UnknownCodecErr, got %v`, err)
+	}
+}
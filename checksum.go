@@ -0,0 +1,164 @@
+package kvstore
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// castagnoli is the CRC-32C table used for checksums of values.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// initChecksums adds the columns holding the checksums of values and defaults stored in the kv table itself.
+// Values in external files or the content table are verified with the hash stored in their place instead.
+func initChecksums(tx *sqlx.Tx) error {
+	if err := addColumn(tx, "kv", "value_sum", "INTEGER"); err != nil {
+		return err
+	}
+	return addColumn(tx, "kv", "original_sum", "INTEGER")
+}
+
+// checksum returns the checksum of an encoded value.
+func checksum(b []byte) int64 {
+	return int64(crc32.Checksum(b, castagnoli))
+}
+
+// Corruption describes a value or default that cannot be read, see Verify.
+type Corruption struct {
+	Key     string
+	Default bool  // the default is corrupt rather than the value
+	Err     error // an error wrapping IntegrityErr if the checksum does not match, the decoding error otherwise
+}
+
+// RepairPolicy determines how Repair handles corrupt rows.
+type RepairPolicy int
+
+const (
+	RepairDrop   RepairPolicy = iota // delete keys with a corrupt value or default
+	RepairRevert                     // revert corrupt values to intact defaults and remove corrupt defaults
+)
+
+// Verify reads all values and defaults and returns those whose checksums do not match or that cannot be
+// decoded, in ascending order of their keys. Values written by earlier versions without a checksum are only
+// checked for decodability. Use Repair to remove corrupt values and IntegrityCheck to check the database file.
+func (db *KVStore) Verify() ([]Corruption, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	var keys []string
+	if err := db.sqx.Select(&keys, `SELECT key FROM kv WHERE value IS NOT NULL OR original IS NOT NULL ORDER BY key ASC;`); err != nil {
+		return nil, err
+	}
+	corrupt := make([]Corruption, 0)
+	for _, key := range keys {
+		found, err := db.verify(key)
+		if err != nil {
+			return nil, err
+		}
+		corrupt = append(corrupt, found...)
+	}
+	return corrupt, nil
+}
+
+// verify checks the value and default of the key.
+func (db *KVStore) verify(key string) ([]Corruption, error) {
+	var sv storedValue
+	var value, original []byte
+	var valueSum, originalSum sql.NullInt64
+	var valueHashed, originalHashed bool
+	dest := append(sv.dest(), &value, &original, &valueSum, &originalSum, &valueHashed, &originalHashed)
+	err := db.sqx.QueryRowx(`SELECT `+db.valueColumns()+`,kv.value,kv.original,kv.value_sum,kv.original_sum,
+kv.value_ref IS NOT NULL OR kv.external IS NOT NULL,kv.original_ref IS NOT NULL FROM kv WHERE key=?;`, key).Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		// external files are verified when they are read, which fails the query if they are corrupt
+		return []Corruption{{Key: key, Err: err}}, nil
+	}
+	var corrupt []Corruption
+	if value != nil {
+		err := verifySum(sv.value, value, valueSum, valueHashed)
+		if err == nil {
+			_, err = sv.decodeValue()
+		}
+		if err != nil {
+			corrupt = append(corrupt, Corruption{Key: key, Err: err})
+		}
+	}
+	if original != nil {
+		err := verifySum(sv.original, original, originalSum, originalHashed)
+		if err == nil {
+			_, err = UnmarshalBinary(sv.original)
+		}
+		if err != nil {
+			corrupt = append(corrupt, Corruption{Key: key, Default: true, Err: err})
+		}
+	}
+	return corrupt, nil
+}
+
+// verifySum checks the encoded value b against its checksum, or against the hash stored in the value or original
+// column if hashed is true. It returns an error wrapping IntegrityErr if they do not match.
+func verifySum(b, stored []byte, sum sql.NullInt64, hashed bool) error {
+	switch {
+	case hashed:
+		h := sha256.Sum256(b)
+		if string(h[:]) != string(stored) {
+			return fmt.Errorf("%w: hash mismatch", IntegrityErr)
+		}
+	case sum.Valid && checksum(b) != sum.Int64:
+		return fmt.Errorf("%w: checksum mismatch", IntegrityErr)
+	}
+	return nil
+}
+
+// Repair verifies all values and defaults like Verify and removes those that are corrupt according to the
+// policy in one transaction. It returns the corruptions that have been repaired.
+func (db *KVStore) Repair(policy RepairPolicy) ([]Corruption, error) {
+	corrupt, err := db.Verify()
+	if err != nil || len(corrupt) == 0 {
+		return corrupt, err
+	}
+	defaults := make(map[string]bool)
+	values := make(map[string]bool)
+	for _, c := range corrupt {
+		if c.Default {
+			defaults[c.Key] = true
+		} else {
+			values[c.Key] = true
+		}
+	}
+	defer db.cache.clear()
+	err = db.inTx(func(tx *sqlx.Tx) error {
+		for _, c := range corrupt {
+			var err error
+			switch {
+			case policy == RepairDrop || (values[c.Key] && defaults[c.Key]):
+				_, err = tx.Exec(`DELETE FROM kv WHERE key=?;`, c.Key)
+			case c.Default:
+				_, err = tx.Exec(`UPDATE kv SET original=NULL,original_ref=NULL,original_type=NULL,original_sum=NULL,
+updated_at=? WHERE key=?;`, db.now().UnixMilli(), c.Key)
+			default:
+				_, err = tx.Exec(`UPDATE kv SET `+revertColumns+` WHERE key=?;`, db.now().UnixMilli(), c.Key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	db.removeOrphans()
+	return corrupt, nil
+}
@@ -0,0 +1,77 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifyRepair(t *testing.T) {
+	for _, policy := range []RepairPolicy{RepairDrop, RepairRevert} {
+		db := New(Deduplicate(64))
+		if err := db.Open(InMemory); err != nil {
+			t.Fatalf(`open: %v`, err)
+		}
+		if err := db.SetDefault("a", 1, KeyInfo{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Set("a", 2); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Set("b", "intact"); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SetDefault("c", "default", KeyInfo{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Set("d", make([]byte, 100)); err != nil {
+			t.Fatal(err)
+		}
+		if corrupt, err := db.Verify(); err != nil || len(corrupt) != 0 {
+			t.Fatalf(`expected no corruption, got %v, %v`, corrupt, err)
+		}
+		// flip a bit of the value of a, garble the default of c, and change the deduplicated content of d
+		if _, err := db.sqx.Exec(`UPDATE kv SET value=CAST(value AS BLOB)||x'00' WHERE key='a';`); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.sqx.Exec(`UPDATE kv SET original=x'0102',original_sum=NULL WHERE key='c';`); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.sqx.Exec(`UPDATE kv_content SET data=data||x'00';`); err != nil {
+			t.Fatal(err)
+		}
+		db.cache.clear()
+		corrupt, err := db.Verify()
+		if err != nil {
+			t.Fatalf(`verify: %v`, err)
+		}
+		if len(corrupt) != 3 || corrupt[0].Key != "a" || corrupt[0].Default || !errors.Is(corrupt[0].Err, IntegrityErr) ||
+			corrupt[1].Key != "c" || !corrupt[1].Default || corrupt[2].Key != "d" || !errors.Is(corrupt[2].Err, IntegrityErr) {
+			t.Fatalf(`unexpected corruptions %+v`, corrupt)
+		}
+		repaired, err := db.Repair(policy)
+		if err != nil || len(repaired) != 3 {
+			t.Fatalf(`repair: %v, %v`, repaired, err)
+		}
+		if corrupt, err := db.Verify(); err != nil || len(corrupt) != 0 {
+			t.Errorf(`expected no corruption after repair, got %v, %v`, corrupt, err)
+		}
+		v, err := db.Get("a")
+		switch policy {
+		case RepairDrop:
+			if !errors.Is(err, NotFoundErr) {
+				t.Errorf(`expected dropped key, got %v, %v`, v, err)
+			}
+		case RepairRevert:
+			if err != nil || v != 1 {
+				t.Errorf(`expected reverted value, got %v, %v`, v, err)
+			}
+		}
+		if v, err := db.Get("b"); err != nil || v != "intact" {
+			t.Errorf(`expected intact value, got %v, %v`, v, err)
+		}
+		if _, err := db.Get("c"); !errors.Is(err, NotFoundErr) {
+			t.Errorf(`expected corrupt default to be removed, got %v`, err)
+		}
+		db.Close()
+	}
+}
@@ -0,0 +1,63 @@
+package kvstore
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time to the time-based features of a store, which are TTLs, the creation and
+// modification timestamps, access tracking, and retention rules.
+type Clock interface {
+	Now() time.Time
+}
+
+// SetClock sets the clock used by the store, so that tests can control the time deterministically, for example
+// with a ManualClock. A nil clock restores the real clock, which is used by default. The clock only affects the
+// process that sets it, so it is meant for tests and should not be used for stores shared with other processes.
+func (db *KVStore) SetClock(clock Clock) {
+	if clock == nil {
+		db.clock.Store(nil)
+		return
+	}
+	db.clock.Store(&clock)
+}
+
+// now returns the current time of the clock of the store.
+func (db *KVStore) now() time.Time {
+	if clock := db.clock.Load(); clock != nil {
+		return (*clock).Now()
+	}
+	return time.Now()
+}
+
+// ManualClock is a Clock for tests whose time only changes when it is set or advanced.
+type ManualClock struct {
+	mutex sync.Mutex
+	t     time.Time
+}
+
+// NewManualClock returns a manual clock set to the given time.
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{t: t}
+}
+
+// Now returns the time of the clock.
+func (c *ManualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.t
+}
+
+// Set sets the time of the clock.
+func (c *ManualClock) Set(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.t = t
+}
+
+// Advance moves the time of the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.t = c.t.Add(d)
+}
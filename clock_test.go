@@ -0,0 +1,81 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetClock(t *testing.T) {
+	db := openTestStore(t)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	db.SetClock(clock)
+	if err := db.SetWithTTL("session", "token", time.Hour); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if m, err := db.Metadata("session"); err != nil || !m.Created.Equal(start) || !m.Modified.Equal(start) {
+		t.Errorf(`expected timestamps %v, got %v, %v, %v`, start, m.Created, m.Modified, err)
+	}
+	if exp, err := db.Expiration("session"); err != nil || !exp.Equal(start.Add(time.Hour)) {
+		t.Errorf(`expected expiration %v, got %v, %v`, start.Add(time.Hour), exp, err)
+	}
+	clock.Advance(59 * time.Minute)
+	if v, err := db.Get("session"); err != nil || v != "token" {
+		t.Errorf(`expected key before expiration, got %v, %v`, v, err)
+	}
+	clock.Advance(time.Minute)
+	if _, err := db.Get("session"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr after expiration, got %v`, err)
+	}
+	if err := db.Set("name", "Alice"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if m, err := db.Metadata("name"); err != nil || !m.Modified.Equal(start.Add(time.Hour)) {
+		t.Errorf(`expected modification at %v, got %v, %v`, start.Add(time.Hour), m.Modified, err)
+	}
+	db.SetClock(nil)
+	if err := db.Set("name", "Bob"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if m, err := db.Metadata("name"); err != nil || time.Since(m.Modified) > time.Minute {
+		t.Errorf(`expected modification at the real time, got %v, %v`, m.Modified, err)
+	}
+}
+
+func TestClockTimestamps(t *testing.T) {
+	db := openTestStore(t)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	db.SetClock(clock)
+	if err := db.SetDefault("color", "blue", KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	steps := []func() error{
+		func() error { return db.Set("color", "red") },
+		func() error { return db.Revert("color") },
+		func() error { return db.SetJSON("color", "green") },
+	}
+	for i, step := range steps {
+		clock.Advance(time.Minute)
+		if err := step(); err != nil {
+			t.Fatalf(`step %d failed: %v`, i, err)
+		}
+		m, err := db.Metadata("color")
+		if err != nil || !m.Created.Equal(start) || !m.Modified.Equal(clock.Now()) {
+			t.Errorf(`step %d: expected creation at %v and modification at %v, got %v, %v, %v`, i, start, clock.Now(),
+				m.Created, m.Modified, err)
+		}
+	}
+	clock.Advance(time.Minute)
+	if err := db.SetJSON("color", "green"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if m, err := db.Metadata("color"); err != nil || !m.Modified.Equal(start.Add(3*time.Minute)) {
+		t.Errorf(`expected unchanged value to keep its modification time, got %v, %v`, m.Modified, err)
+	}
+	var n int
+	if err := db.sqx.Get(&n, `SELECT count(*) FROM sqlite_master WHERE name IN ('kv_clock','kv_created','kv_updated');`); err != nil || n != 0 {
+		t.Errorf(`expected no clock table and timestamp triggers, got %d, %v`, n, err)
+	}
+}
@@ -0,0 +1,101 @@
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var UnknownCodecErr = errors.New(`unknown codec`)
+
+// Codec encodes and decodes values stored with SetWithCodec. The name of the codec is stored with each value,
+// so values are decoded by Get with the codec they were encoded with. Codecs must be registered with
+// RegisterCodec before values encoded with them are read.
+type Codec interface {
+	Name() string                    // the unique name of the codec
+	Marshal(v any) ([]byte, error)   // encode a value
+	Unmarshal(b []byte) (any, error) // decode a value encoded by Marshal
+}
+
+// codecs holds the registered codecs by name.
+var codecs = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{m: make(map[string]Codec)}
+
+// RegisterCodec registers a codec for decoding values by its name, replacing a codec registered under the
+// same name. The names json and raw are reserved for values set with SetJSON and SetRaw.
+func RegisterCodec(c Codec) error {
+	switch c.Name() {
+	case codecGob, codecJSON, codecRaw:
+		return fmt.Errorf("codec name %q is reserved", c.Name())
+	}
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.m[c.Name()] = c
+	return nil
+}
+
+// lookupCodec returns the registered codec with the given name.
+func lookupCodec(name string) (Codec, error) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	c, ok := codecs.m[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", UnknownCodecErr, name)
+	}
+	return c, nil
+}
+
+// SetWithCodec sets the value for the given key like Set, encoding it with the given codec instead of gob.
+// The codec is registered with RegisterCodec if it is not yet registered.
+func (db *KVStore) SetWithCodec(key string, value any, c Codec) error {
+	return db.setCodec(key, value, c, false)
+}
+
+// setCodec sets the value for the given key encoded with the codec, ignoring whether the key is locked if
+// force is true.
+func (db *KVStore) setCodec(key string, value any, c Codec, force bool) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if _, err := lookupCodec(c.Name()); err != nil {
+		if err := RegisterCodec(c); err != nil {
+			return err
+		}
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	if err := db.checkKey(db.sqx, key, value, force); err != nil {
+		return err
+	}
+	b, err := c.Marshal(value)
+	if err != nil {
+		return err
+	}
+	notify := db.hasListeners()
+	var old any
+	if notify {
+		old = db.current(db.sqx, key)
+	}
+	defer db.cache.remove(key)
+	err = db.inTx(func(tx *sqlx.Tx) error {
+		if err := db.checkQuota(tx, key, len(b)); err != nil {
+			return err
+		}
+		return db.putCodec(tx, key, b, c.Name(), typeName(value))
+	})
+	if err != nil {
+		return err
+	}
+	if notify {
+		if err := db.notify(change{key: key, old: old, new: value}); err != nil {
+			return err
+		}
+	}
+	return db.evict(key)
+}
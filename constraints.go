@@ -0,0 +1,147 @@
+package kvstore
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ConstraintErr = errors.New(`value violates the constraints of the key`)
+
+// keyInfoExtra holds the fields of KeyInfo that are persisted in the extra column of the kv table.
+type keyInfoExtra struct {
+	ValueType string
+	Min       *float64
+	Max       *float64
+	Enum      []any
+	Unit      string
+	Enforce   bool
+	Locked    bool
+	Sensitive bool
+	Hints     UIHints
+}
+
+// marshalExtra gob encodes the optional fields of the key info.
+func (info KeyInfo) marshalExtra() ([]byte, error) {
+	extra := keyInfoExtra{
+		ValueType: info.ValueType,
+		Min:       info.Min,
+		Max:       info.Max,
+		Enum:      info.Enum,
+		Unit:      info.Unit,
+		Enforce:   info.Enforce,
+		Locked:    info.Locked,
+		Sensitive: info.Sensitive,
+		Hints:     info.UIHints,
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&extra); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalExtra decodes optional fields encoded by marshalExtra into the key info. Nothing is
+// done if b is nil.
+func (info *KeyInfo) unmarshalExtra(b []byte) error {
+	if b == nil {
+		return nil
+	}
+	var extra keyInfoExtra
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&extra); err != nil {
+		return err
+	}
+	info.ValueType = extra.ValueType
+	info.Min = extra.Min
+	info.Max = extra.Max
+	info.Enum = extra.Enum
+	info.Unit = extra.Unit
+	info.Enforce = extra.Enforce
+	info.Locked = extra.Locked
+	info.Sensitive = extra.Sensitive
+	info.UIHints = extra.Hints
+	return nil
+}
+
+// Check returns an error wrapping ConstraintErr if the value does not satisfy the constraints of the key info,
+// nil otherwise. Check does not take into account whether the constraints are enforced or not.
+func (info KeyInfo) Check(value any) error {
+	if info.ValueType != "" && fmt.Sprintf("%T", value) != info.ValueType {
+		return fmt.Errorf("%w: expected value of type %v, given %T", ConstraintErr, info.ValueType, value)
+	}
+	if info.Min != nil || info.Max != nil {
+		f, ok := toFloat64(value)
+		if !ok {
+			return fmt.Errorf("%w: expected a numeric value, given %T", ConstraintErr, value)
+		}
+		if info.Min != nil && f < *info.Min {
+			return fmt.Errorf("%w: value %v is less than minimum %v", ConstraintErr, value, *info.Min)
+		}
+		if info.Max != nil && f > *info.Max {
+			return fmt.Errorf("%w: value %v is greater than maximum %v", ConstraintErr, value, *info.Max)
+		}
+	}
+	if len(info.Enum) > 0 {
+		for _, choice := range info.Enum {
+			if reflect.DeepEqual(choice, value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: value %v is not one of %v", ConstraintErr, value, info.Enum)
+	}
+	return nil
+}
+
+// checkConstraints checks the value against the constraints stored for the key if they are enforced and against
+// the registered validators, and returns an error wrapping KeyLockedErr if the key is locked.
+func (db *KVStore) checkConstraints(q sqlx.Queryer, key string, value any) error {
+	return db.checkKey(q, key, value, false)
+}
+
+// checkKey checks the value against the constraints stored for the key if they are enforced and against the
+// registered validators, and whether the key is locked unless force is true.
+func (db *KVStore) checkKey(q sqlx.Queryer, key string, value any, force bool) error {
+	if err := checkKind(q, key, "value"); err != nil {
+		return err
+	}
+	var b []byte
+	err := sqlx.Get(q, &b, `SELECT extra FROM kv WHERE key=? LIMIT 1;`, key)
+	if errors.Is(err, sql.ErrNoRows) || b == nil {
+		return db.validate(key, value)
+	}
+	if err != nil {
+		return err
+	}
+	var info KeyInfo
+	if err := info.unmarshalExtra(b); err != nil {
+		return err
+	}
+	if info.Locked && !force {
+		return fmt.Errorf("%w: %v", KeyLockedErr, key)
+	}
+	if info.Enforce {
+		if err := info.Check(value); err != nil {
+			return err
+		}
+	}
+	return db.validate(key, value)
+}
+
+// toFloat64 converts numeric values to float64, returns false if the value is not numeric.
+func toFloat64(value any) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
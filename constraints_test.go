@@ -0,0 +1,56 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConstraints(t *testing.T) {
+	db := openTestStore(t)
+	minimum, maximum := 1.0, 10.0
+	err := db.SetDefault("volume", 5, KeyInfo{
+		Description: "audio volume",
+		Category:    "audio",
+		ValueType:   "int",
+		Min:         &minimum,
+		Max:         &maximum,
+		Unit:        "dB",
+		Enforce:     true,
+	})
+	if err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	info, ok := db.Info("volume")
+	if !ok {
+		t.Fatalf(`failed to get key info`)
+	}
+	if info.ValueType != "int" || *info.Min != 1 || *info.Max != 10 || info.Unit != "dB" || !info.Enforce {
+		t.Errorf(`extended key info was not persisted: %+v`, info)
+	}
+	if err := db.Set("volume", 7); err != nil {
+		t.Errorf(`failed to set valid value: %v`, err)
+	}
+	if err := db.Set("volume", 11); !errors.Is(err, ConstraintErr) {
+		t.Errorf(`expected ConstraintErr for value above maximum, got %v`, err)
+	}
+	if err := db.Set("volume", "loud"); !errors.Is(err, ConstraintErr) {
+		t.Errorf(`expected ConstraintErr for wrong type, got %v`, err)
+	}
+	if err := db.SetMany(map[string]any{"volume": 0}); !errors.Is(err, ConstraintErr) {
+		t.Errorf(`expected ConstraintErr for value below minimum, got %v`, err)
+	}
+	err = db.SetDefault("theme", "light", KeyInfo{Enum: []any{"light", "dark"}})
+	if err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.Set("theme", "blue"); err != nil {
+		t.Errorf(`constraints should not be enforced unless requested: %v`, err)
+	}
+	info, _ = db.Info("theme")
+	if err := info.Check("blue"); !errors.Is(err, ConstraintErr) {
+		t.Errorf(`expected ConstraintErr for value not in enum, got %v`, err)
+	}
+	if err := info.Check("dark"); err != nil {
+		t.Errorf(`expected enum value to be valid, got %v`, err)
+	}
+}
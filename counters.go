@@ -0,0 +1,92 @@
+package kvstore
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+)
+
+var ExpvarNameErr = errors.New(`expvar variable already published under the given name`)
+
+// opKind identifies the operations that are counted and reported by SlowOperations.
+type opKind int
+
+const (
+	opGet opKind = iota
+	opGetMany
+	opGetAll
+	opSet
+	opSetMany
+	opSetDefault
+	opSetDefaults
+	opRevert
+	opDelete
+	opDeleteMany
+	opUpdate
+	numOps
+)
+
+// opNames are the names of the operations used in SlowOp and Counters.
+var opNames = [numOps]string{"get", "get many", "get all", "set", "set many", "set default", "set defaults",
+	"revert", "delete", "delete many", "update"}
+
+// counters counts operations, errors, and cache accesses for the lifetime of a store.
+type counters struct {
+	ops         [numOps]atomic.Uint64
+	errors      [numOps]atomic.Uint64
+	cacheHits   atomic.Uint64
+	cacheMisses atomic.Uint64
+}
+
+// Counters is a snapshot of the activity of a store since it was created, see KVStore.Counters.
+type Counters struct {
+	Ops          map[string]uint64 // the number of calls of Get, Set, and the other operations by name, see SlowOp
+	Errors       map[string]uint64 // the number of calls returning an error other than NotFoundErr by operation
+	CacheHits    uint64            // the number of values read by Get from the read cache, see Cache
+	CacheMisses  uint64            // the number of values read by Get from the database while the cache is enabled
+	CacheHitRate float64           // the fraction of cache hits, 0 if the cache has not been used
+}
+
+// Counters returns the number of operations, errors, and read cache accesses since the store was created.
+// The counters are kept in memory without locking and are not reset when the store is closed.
+func (db *KVStore) Counters() Counters {
+	c := Counters{Ops: make(map[string]uint64), Errors: make(map[string]uint64)}
+	for op := range numOps {
+		if n := db.counters.ops[op].Load(); n > 0 {
+			c.Ops[opNames[op]] = n
+		}
+		if n := db.counters.errors[op].Load(); n > 0 {
+			c.Errors[opNames[op]] = n
+		}
+	}
+	c.CacheHits = db.counters.cacheHits.Load()
+	c.CacheMisses = db.counters.cacheMisses.Load()
+	if total := c.CacheHits + c.CacheMisses; total > 0 {
+		c.CacheHitRate = float64(c.CacheHits) / float64(total)
+	}
+	return c
+}
+
+// PublishExpvar publishes the counters of the store as expvar variable with the given name, so that they are
+// served as JSON by the /debug/vars handler of the expvar package together with the other variables of the
+// process. An error wrapping ExpvarNameErr is returned if a variable with the name has already been published,
+// since expvar variables cannot be removed.
+func (db *KVStore) PublishExpvar(name string) error {
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("%w: %q", ExpvarNameErr, name)
+	}
+	expvar.Publish(name, expvar.Func(func() any { return db.Counters() }))
+	return nil
+}
+
+// cacheAccess counts a read of the cache, which is only counted if the cache is enabled.
+func (db *KVStore) cacheAccess(hit bool) {
+	switch {
+	case db.cache == nil:
+	case hit:
+		db.counters.cacheHits.Add(1)
+	default:
+		db.counters.cacheMisses.Add(1)
+	}
+}
@@ -0,0 +1,62 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"testing"
+)
+
+func TestCounters(t *testing.T) {
+	db := New(Cache(10, 0))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	if err := db.Set("name", "Alice"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	for range 3 {
+		if _, err := db.Get("name"); err != nil {
+			t.Fatalf(`failed to get key: %v`, err)
+		}
+	}
+	if _, err := db.Get("missing"); !errors.Is(err, NotFoundErr) {
+		t.Fatalf(`expected NotFoundErr, got %v`, err)
+	}
+	if err := db.SetDefault("locked", 1, KeyInfo{Locked: true}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.Set("locked", 2); !errors.Is(err, KeyLockedErr) {
+		t.Fatalf(`expected KeyLockedErr, got %v`, err)
+	}
+	if err := db.ForceSet("locked", 2); err != nil {
+		t.Fatalf(`failed to force set key: %v`, err)
+	}
+	if err := db.SetNil("empty"); err != nil {
+		t.Fatalf(`failed to set nil: %v`, err)
+	}
+	c := db.Counters()
+	if c.Ops["set"] != 4 || c.Ops["get"] != 4 || c.Ops["set default"] != 1 {
+		t.Errorf(`wrong operation counts: %v`, c.Ops)
+	}
+	if len(c.Errors) != 1 || c.Errors["set"] != 1 {
+		t.Errorf(`expected one failed set, got %v`, c.Errors)
+	}
+	if c.CacheHits != 2 || c.CacheMisses != 2 || c.CacheHitRate != 0.5 {
+		t.Errorf(`expected 2 cache hits and misses, got %d, %d, %v`, c.CacheHits, c.CacheMisses, c.CacheHitRate)
+	}
+	if err := db.PublishExpvar("kvstore_test_counters"); err != nil {
+		t.Fatalf(`failed to publish counters: %v`, err)
+	}
+	if err := db.PublishExpvar("kvstore_test_counters"); !errors.Is(err, ExpvarNameErr) {
+		t.Errorf(`expected ExpvarNameErr, got %v`, err)
+	}
+	var published Counters
+	if err := json.Unmarshal([]byte(expvar.Get("kvstore_test_counters").String()), &published); err != nil {
+		t.Fatalf(`invalid expvar JSON: %v`, err)
+	}
+	if published.Ops["set"] != 4 || published.CacheHits != 2 {
+		t.Errorf(`wrong published counters: %+v`, published)
+	}
+}
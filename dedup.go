@@ -0,0 +1,67 @@
+package kvstore
+
+import (
+	"crypto/sha256"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Deduplicate configures the store to keep encoded values and defaults larger than threshold bytes in a
+// separate content table keyed by their SHA-256 hash, so that keys sharing the same large value or default
+// consume the space only once. Content is removed automatically when it is no longer referenced.
+func Deduplicate(threshold int) Option {
+	return func(o *options) {
+		o.dedupThreshold = threshold
+	}
+}
+
+// contentColumn returns the SQL expression reading a column of kv that may reference the content table.
+func contentColumn(column string) string {
+	return `CASE WHEN kv.` + column + `_ref IS NULL THEN kv.` + column +
+		` ELSE (SELECT data FROM kv_content WHERE hash=kv.` + column + `_ref) END`
+}
+
+// originalColumn is the SQL expression for the encoded default of a key.
+var originalColumn = contentColumn("original")
+
+// initContent creates the content table, the columns referencing it, and the triggers removing content
+// that is no longer referenced. A referencing row holds the hash in both the value or original column
+// and the corresponding reference column, so that the value column changes whenever the content does.
+func initContent(tx *sqlx.Tx) error {
+	if err := addColumn(tx, "kv", "value_ref", "BLOB"); err != nil {
+		return err
+	}
+	if err := addColumn(tx, "kv", "original_ref", "BLOB"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_content(
+  hash BLOB PRIMARY KEY NOT NULL,
+  data BLOB NOT NULL
+) WITHOUT ROWID;
+CREATE INDEX IF NOT EXISTS kv_value_ref ON kv(value_ref) WHERE value_ref IS NOT NULL;
+CREATE INDEX IF NOT EXISTS kv_original_ref ON kv(original_ref) WHERE original_ref IS NOT NULL;
+CREATE TRIGGER IF NOT EXISTS kv_content_update AFTER UPDATE OF value_ref,original_ref ON kv BEGIN
+  DELETE FROM kv_content WHERE hash IN (old.value_ref,old.original_ref)
+    AND NOT EXISTS(SELECT 1 FROM kv WHERE value_ref=kv_content.hash)
+    AND NOT EXISTS(SELECT 1 FROM kv WHERE original_ref=kv_content.hash);
+END;
+CREATE TRIGGER IF NOT EXISTS kv_content_delete AFTER DELETE ON kv BEGIN
+  DELETE FROM kv_content WHERE hash IN (old.value_ref,old.original_ref)
+    AND NOT EXISTS(SELECT 1 FROM kv WHERE value_ref=kv_content.hash)
+    AND NOT EXISTS(SELECT 1 FROM kv WHERE original_ref=kv_content.hash);
+END;
+`)
+	return err
+}
+
+// dedup stores b in the content table if deduplication applies to it and returns its hash, nil otherwise.
+// This must be done in the same transaction as writing the row referencing the content.
+func (db *KVStore) dedup(ex sqlx.Execer, b []byte) ([]byte, error) {
+	if db.opts.dedupThreshold <= 0 || len(b) <= db.opts.dedupThreshold {
+		return nil, nil
+	}
+	sum := sha256.Sum256(b)
+	_, err := ex.Exec(`INSERT INTO kv_content(hash,data) VALUES(?,?) ON CONFLICT DO NOTHING;`, sum[:], b)
+	return sum[:], err
+}
@@ -0,0 +1,53 @@
+package kvstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeduplicate(t *testing.T) {
+	db := New(Deduplicate(256))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	template := strings.Repeat("template ", 100)
+	for _, key := range []string{"a", "b", "c"} {
+		if err := db.SetDefault(key, template, KeyInfo{Category: "templates"}); err != nil {
+			t.Fatalf(`failed to set default: %v`, err)
+		}
+	}
+	if err := db.Set("a", template); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	count := func() int {
+		var n int
+		if err := db.sqx.Get(&n, `SELECT COUNT(*) FROM kv_content;`); err != nil {
+			t.Fatalf(`failed to count content: %v`, err)
+		}
+		return n
+	}
+	if n := count(); n != 1 {
+		t.Errorf(`expected content to be stored once, got %v copies`, n)
+	}
+	for _, key := range []string{"a", "b"} {
+		if v, err := db.Get(key); v != template || err != nil {
+			t.Errorf(`expected template for %v, got %v`, key, err)
+		}
+	}
+	if v, err := db.GetDefault("c"); v != template || err != nil {
+		t.Errorf(`expected template default, got %v`, err)
+	}
+	if err := db.Revert("a"); err != nil {
+		t.Fatalf(`failed to revert key: %v`, err)
+	}
+	if v, err := db.Get("a"); v != template || err != nil {
+		t.Errorf(`expected reverted template, got %v`, err)
+	}
+	if err := db.DeleteByCategory("templates"); err != nil {
+		t.Fatalf(`failed to delete keys: %v`, err)
+	}
+	if n := count(); n != 0 {
+		t.Errorf(`expected unreferenced content to be removed, got %v rows`, n)
+	}
+}
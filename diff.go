@@ -0,0 +1,64 @@
+package kvstore
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+)
+
+// KeyDiff is a key whose value differs between two stores, see Diff. Ours is the value in the store Diff is
+// called on, and Theirs the value in the other store; the value is nil in a store that does not have the key.
+type KeyDiff struct {
+	Key    string
+	Ours   any
+	Theirs any
+}
+
+// Differences lists the keys that differ between two stores in ascending order, see Diff.
+type Differences struct {
+	Added   []KeyDiff // keys only in the other store
+	Removed []KeyDiff // keys only in this store
+	Changed []KeyDiff // keys with different values in both stores
+}
+
+// Empty returns true if the stores do not differ.
+func (d Differences) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares the key-value pairs of the store with those of the other store as returned by GetAll, so
+// defaults count as values. Keys are added or removed in the sense that the other store has them but this store
+// has not or vice versa, so that applying the differences to this store would turn it into the other store.
+// Values are compared with reflect.DeepEqual, including the actual values of sensitive keys if the other store
+// is a KVStore.
+func (db *KVStore) Diff(other KeyValueStore) (Differences, error) {
+	var d Differences
+	ours, err := db.getAll(0)
+	if err != nil {
+		return d, err
+	}
+	theirs, err := readAll(other)
+	if err != nil {
+		return d, err
+	}
+	for k, v := range ours {
+		t, ok := theirs[k]
+		switch {
+		case !ok:
+			d.Removed = append(d.Removed, KeyDiff{Key: k, Ours: v})
+		case !reflect.DeepEqual(v, t):
+			d.Changed = append(d.Changed, KeyDiff{Key: k, Ours: v, Theirs: t})
+		}
+	}
+	for k, t := range theirs {
+		if _, ok := ours[k]; !ok {
+			d.Added = append(d.Added, KeyDiff{Key: k, Theirs: t})
+		}
+	}
+	for _, diffs := range [][]KeyDiff{d.Added, d.Removed, d.Changed} {
+		slices.SortFunc(diffs, func(a, b KeyDiff) int {
+			return cmp.Compare(a.Key, b.Key)
+		})
+	}
+	return d, nil
+}
@@ -0,0 +1,32 @@
+package kvstore
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	db := openTestStore(t)
+	other := openTestStore(t)
+	if err := db.SetMany(map[string]any{"same": 1, "changed": "old", "removed": true}); err != nil {
+		t.Fatalf(`failed to set keys: %v`, err)
+	}
+	if err := other.SetMany(map[string]any{"same": 1, "changed": "new", "added": 2.5, "added2": "x"}); err != nil {
+		t.Fatalf(`failed to set keys: %v`, err)
+	}
+	d, err := db.Diff(other)
+	if err != nil {
+		t.Fatalf(`failed to diff stores: %v`, err)
+	}
+	if len(d.Added) != 2 || d.Added[0] != (KeyDiff{Key: "added", Theirs: 2.5}) || d.Added[1].Key != "added2" {
+		t.Errorf(`wrong added keys: %+v`, d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0] != (KeyDiff{Key: "removed", Ours: true}) {
+		t.Errorf(`wrong removed keys: %+v`, d.Removed)
+	}
+	if len(d.Changed) != 1 || d.Changed[0] != (KeyDiff{Key: "changed", Ours: "old", Theirs: "new"}) {
+		t.Errorf(`wrong changed keys: %+v`, d.Changed)
+	}
+	if d, err := db.Diff(db); err != nil || !d.Empty() {
+		t.Errorf(`expected no differences to itself, got %+v, %v`, d, err)
+	}
+}
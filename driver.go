@@ -0,0 +1,100 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// The sqlite driver is selected with build tags. By default, the pure Go driver github.com/ncruces/go-sqlite3
+// is used. The tag kvstore_modernc selects the pure Go driver modernc.org/sqlite, and the tag kvstore_mattn
+// selects the cgo driver github.com/mattn/go-sqlite3, which only supports FullTextSearch if the tag
+// sqlite_fts5 is set as well. Values cannot be kept in external files with these two drivers, see
+// ExternalValues.
+//
+// Each driver file provides sqliteDriver, externalFunction, connHook, isBusy, dataSourceName, and openMemory.
+
+// connector opens connections with the base connector, initializes them with the hook of the driver, and
+// rewrites all statements if rename is set, see TableName.
+type connector struct {
+	base   driver.Connector
+	hook   func(driver.Conn) error
+	rename func(string) string
+}
+
+// Connect opens and initializes a connection.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.hook != nil {
+		if err := c.hook(conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if c.rename != nil {
+		return &tableConn{Conn: conn, rename: c.rename}, nil
+	}
+	return conn, nil
+}
+
+// Driver returns the driver of the base connector.
+func (c *connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// dsnConnector is a connector for drivers that do not implement driver.DriverContext.
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+// Connect opens a connection to the data source.
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver returns the driver.
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// openDB opens a connection pool for the data source using the selected driver. If a table name has been
+// configured, all statements are rewritten to use it.
+func (db *KVStore) openDB(dsn string) (*sql.DB, error) {
+	c := &connector{hook: db.connHook()}
+	if table := db.opts.tableName(); table != defaultTable {
+		if !validTableName.MatchString(table) {
+			return nil, InvalidTableNameErr
+		}
+		c.rename = renameTable(table)
+	}
+	var err error
+	if dc, ok := sqliteDriver.(driver.DriverContext); ok {
+		c.base, err = dc.OpenConnector(dsn)
+	} else {
+		c.base = &dsnConnector{driver: sqliteDriver, dsn: dsn}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(c), nil
+}
+
+// uriPath returns the escaped absolute path of the file for use in a sqlite URI.
+func uriPath(file string) (string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	p := filepath.ToSlash(abs)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Path: p}).EscapedPath(), nil
+}
@@ -0,0 +1,76 @@
+//go:build kvstore_mattn && !kvstore_modernc
+
+package kvstore
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriver is the driver used to open databases.
+var sqliteDriver driver.Driver = &sqlite3.SQLiteDriver{}
+
+// fileLocking is false on platforms on which the driver cannot lock database files.
+const fileLocking = true
+
+// externalFunction is true if the driver supports the kv_external function, see ExternalValues.
+const externalFunction = false
+
+// connHook returns the function initializing new connections, which registers the regexp function implementing
+// the REGEXP operator.
+func (db *KVStore) connHook() func(driver.Conn) error {
+	return func(c driver.Conn) error {
+		return c.(*sqlite3.SQLiteConn).RegisterFunc("regexp", matchRegexp, true)
+	}
+}
+
+// isBusy returns true if the error indicates that the database is locked by another connection.
+func isBusy(err error) bool {
+	var e sqlite3.Error
+	return errors.As(err, &e) && (e.Code == sqlite3.ErrBusy || e.Code == sqlite3.ErrLocked)
+}
+
+// dataSourceName returns the sqlite URI for the database file with the given escaped absolute path, which is
+// opened with the named VFS unless it is empty. Pragmas are passed as parameters of the driver so that they apply
+// to every connection of the connection pool. Transactions acquire the write lock immediately, so sqlite's busy
+// timeout also applies to transactions that read before they write.
+func dataSourceName(p, vfsName string, busyTimeout time.Duration) string {
+	query := url.Values{}
+	if vfsName != "" {
+		query.Set("vfs", vfsName)
+	}
+	query.Set("_txlock", "immediate")
+	query.Set("_busy_timeout", fmt.Sprint(busyTimeout.Milliseconds()))
+	query.Set("_journal_mode", "WAL")
+	query.Set("_synchronous", "NORMAL")
+	query.Set("_auto_vacuum", "FULL")
+	query.Set("_cache_size", "2000")
+	return "file://" + p + "?" + query.Encode()
+}
+
+// openMemory creates the in-memory database with the given name, which is shared by all connections of the
+// process using sqlite's shared cache, and returns its sqlite URI and a function deleting it. The database
+// exists as long as it has a connection, so a connection outside of the connection pool is kept open.
+func openMemory(name string, busyTimeout time.Duration) (string, func(), error) {
+	dsn := memoryURI(name, busyTimeout)
+	conn, err := sqliteDriver.Open(dsn)
+	if err != nil {
+		return "", nil, err
+	}
+	return dsn, func() { conn.Close() }, nil
+}
+
+// memoryURI returns the sqlite URI of the in-memory database with the given name.
+func memoryURI(name string, busyTimeout time.Duration) string {
+	query := url.Values{}
+	query.Set("mode", "memory")
+	query.Set("cache", "shared")
+	query.Set("_txlock", "immediate")
+	query.Set("_busy_timeout", fmt.Sprint(busyTimeout.Milliseconds()))
+	return "file:" + url.PathEscape(name) + "?" + query.Encode()
+}
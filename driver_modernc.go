@@ -0,0 +1,114 @@
+//go:build kvstore_modernc
+
+package kvstore
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteDriver is the driver used to open databases. It must be the instance registered with database/sql,
+// since functions registered with the package, such as regexp in init, only apply to its connections.
+var sqliteDriver = registeredDriver()
+
+// registeredDriver returns the driver instance registered with database/sql under the name sqlite.
+func registeredDriver() driver.Driver {
+	db, err := sql.Open("sqlite", "")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+	return db.Driver()
+}
+
+// fileLocking is false on platforms on which the driver cannot lock database files.
+const fileLocking = true
+
+// externalFunction is true if the driver supports the kv_external function, see ExternalValues.
+const externalFunction = false
+
+// connHook returns the function initializing new connections, which is not needed for this driver because
+// functions are registered globally, see init.
+func (db *KVStore) connHook() func(driver.Conn) error {
+	return nil
+}
+
+// init registers the regexp function implementing the REGEXP operator for all connections of the driver.
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("regexp", 2,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			pattern, _ := args[0].(string)
+			s, _ := args[1].(string)
+			ok, err := matchRegexp(pattern, s)
+			if err != nil || !ok {
+				return int64(0), err
+			}
+			return int64(1), nil
+		})
+}
+
+// isBusy returns true if the error indicates that the database is locked by another connection.
+func isBusy(err error) bool {
+	var e *sqlite.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	code := e.Code() & 0xff
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
+
+// pragmas returns the pragmas applied to every connection.
+func pragmas(busyTimeout time.Duration) []string {
+	return []string{
+		fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()),
+		"journal_mode(WAL)",
+		"synchronous(NORMAL)",
+		"auto_vacuum(FULL)",
+		"journal_size_limit(67108864)",
+		"mmap_size(134217728)",
+		"cache_size(2000)",
+	}
+}
+
+// dataSourceName returns the sqlite URI for the database file with the given escaped absolute path, which is
+// opened with the named VFS unless it is empty. Pragmas are passed as part of the URI so that they apply to every
+// connection of the connection pool. Transactions acquire the write lock immediately, so sqlite's busy timeout
+// also applies to transactions that read before they write.
+func dataSourceName(p, vfsName string, busyTimeout time.Duration) string {
+	query := url.Values{}
+	if vfsName != "" {
+		query.Set("vfs", vfsName)
+	}
+	query.Set("_txlock", "immediate")
+	query["_pragma"] = pragmas(busyTimeout)
+	return "file://" + p + "?" + query.Encode()
+}
+
+// openMemory creates the in-memory database with the given name, which is shared by all connections of the
+// process using sqlite's shared cache, and returns its sqlite URI and a function deleting it. The database
+// exists as long as it has a connection, so a connection outside of the connection pool is kept open.
+func openMemory(name string, busyTimeout time.Duration) (string, func(), error) {
+	dsn := memoryURI(name, busyTimeout)
+	conn, err := sqliteDriver.Open(dsn)
+	if err != nil {
+		return "", nil, err
+	}
+	return dsn, func() { conn.Close() }, nil
+}
+
+// memoryURI returns the sqlite URI of the in-memory database with the given name.
+func memoryURI(name string, busyTimeout time.Duration) string {
+	query := url.Values{}
+	query.Set("mode", "memory")
+	query.Set("cache", "shared")
+	query.Set("_txlock", "immediate")
+	query["_pragma"] = []string{fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds())}
+	return "file:" + url.PathEscape(name) + "?" + query.Encode()
+}
@@ -0,0 +1,103 @@
+//go:build !kvstore_modernc && !kvstore_mattn
+
+package kvstore
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ncruces/go-sqlite3"
+	sqlite "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+	"github.com/ncruces/go-sqlite3/vfs"
+	"github.com/ncruces/go-sqlite3/vfs/memdb"
+)
+
+// sqliteDriver is the driver used to open databases.
+var sqliteDriver driver.Driver = &sqlite.SQLite{}
+
+// fileLocking is false on platforms such as GOOS=js on which the driver cannot lock database files, which
+// are then opened by a single connection without locking.
+const fileLocking = vfs.SupportsFileLocking
+
+// externalFunction is true if the driver supports the kv_external function, see ExternalValues.
+const externalFunction = true
+
+// connHook returns the function initializing new connections, which registers the SQL functions of the store.
+func (db *KVStore) connHook() func(driver.Conn) error {
+	return func(c driver.Conn) error {
+		return db.initConn(c.(sqlite.Conn).Raw())
+	}
+}
+
+// initConn registers the SQL functions of the store on a new connection. The kv_external function returns the
+// contents of an external file after verifying its checksum, and regexp implements the REGEXP operator.
+func (db *KVStore) initConn(c *sqlite3.Conn) error {
+	err := c.CreateFunction("kv_external", 1, sqlite3.DETERMINISTIC, func(ctx sqlite3.Context, arg ...sqlite3.Value) {
+		b, err := db.readExternal(arg[0].Text())
+		if err != nil {
+			ctx.ResultError(err)
+			return
+		}
+		ctx.ResultBlob(b)
+	})
+	if err != nil {
+		return err
+	}
+	return c.CreateFunction("regexp", 2, sqlite3.DETERMINISTIC, func(ctx sqlite3.Context, arg ...sqlite3.Value) {
+		ok, err := matchRegexp(arg[0].Text(), arg[1].Text())
+		if err != nil {
+			ctx.ResultError(err)
+			return
+		}
+		ctx.ResultBool(ok)
+	})
+}
+
+// isBusy returns true if the error indicates that the database is locked by another connection.
+func isBusy(err error) bool {
+	return errors.Is(err, sqlite3.BUSY) || errors.Is(err, sqlite3.LOCKED)
+}
+
+// dataSourceName returns the sqlite URI for the database file with the given escaped absolute path, which is
+// opened with the named VFS unless it is empty. Pragmas are passed as part of the URI so that they apply to every
+// connection of the connection pool. Transactions acquire the write lock immediately, so sqlite's busy timeout
+// also applies to transactions that read before they write.
+func dataSourceName(p, vfsName string, busyTimeout time.Duration) string {
+	query := url.Values{}
+	if vfsName != "" {
+		query.Set("vfs", vfsName)
+	} else if !fileLocking {
+		query.Set("nolock", "1")
+	}
+	query.Set("_txlock", "immediate")
+	query["_pragma"] = []string{
+		fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()),
+		"journal_mode(WAL)",
+		"synchronous(NORMAL)",
+		"auto_vacuum(FULL)",
+		"journal_size_limit(67108864)",
+		"mmap_size(134217728)",
+		"cache_size(2000)",
+	}
+	return "file://" + p + "?" + query.Encode()
+}
+
+// openMemory creates the in-memory database with the given name, which is shared by all connections of the
+// process using the memdb VFS, and returns its sqlite URI and a function deleting it.
+func openMemory(name string, busyTimeout time.Duration) (string, func(), error) {
+	memdb.Create(name, nil)
+	return memoryURI(name, busyTimeout), func() { memdb.Delete(name) }, nil
+}
+
+// memoryURI returns the sqlite URI of the in-memory database with the given name.
+func memoryURI(name string, busyTimeout time.Duration) string {
+	query := url.Values{}
+	query.Set("vfs", "memdb")
+	query.Set("_txlock", "immediate")
+	query["_pragma"] = []string{fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds())}
+	return "file:/" + url.PathEscape(name) + "?" + query.Encode()
+}
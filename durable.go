@@ -0,0 +1,100 @@
+package kvstore
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SetDurable sets the value for the given key like Set, but commits the write with sqlite's synchronous mode
+// FULL, so that the value survives a power failure or operating system crash once SetDurable returns. Other
+// writes use the faster mode NORMAL, in which the last transactions may be rolled back by such a failure. Use
+// SetDurable for critical values such as license keys. The value is never deferred in write-behind mode.
+func (db *KVStore) SetDurable(key string, value any) error {
+	return keyError("set", key, db.setDurable(key, value))
+}
+
+// setDurable implements SetDurable.
+func (db *KVStore) setDurable(key string, value any) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	value, err := db.beforeSet(key, value)
+	if err != nil {
+		return err
+	}
+	if err := db.checkKey(db.sqx, key, value, false); err != nil {
+		return err
+	}
+	codec := codecGob
+	var b []byte
+	if isMessage(value) {
+		codec = Proto.Name()
+		b, err = Proto.Marshal(value)
+	} else {
+		b, err = db.encode(value)
+	}
+	if err != nil {
+		return err
+	}
+	notify := db.hasListeners()
+	var old any
+	if notify {
+		old = db.current(db.sqx, key)
+	}
+	defer db.cache.remove(key)
+	err = db.inDurableTx(func(tx *sqlx.Tx) error {
+		if err := db.checkQuota(tx, key, len(b)); err != nil {
+			return err
+		}
+		return db.putCodec(tx, key, b, codec, typeName(value))
+	})
+	if err != nil {
+		return err
+	}
+	if notify {
+		if err := db.notify(change{key: key, old: old, new: value}); err != nil {
+			return err
+		}
+	}
+	db.afterSet(key, value)
+	return db.evict(key)
+}
+
+// inDurableTx runs fn within a write transaction like inTx, on a connection whose synchronous mode is set to
+// FULL until the transaction has been committed. The previous mode of the connection is restored afterwards.
+func (db *KVStore) inDurableTx(fn func(tx *sqlx.Tx) error) error {
+	ctx := context.Background()
+	conn, err := db.writeDB().Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var mode int
+	if err := conn.GetContext(ctx, &mode, `PRAGMA synchronous;`); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, `PRAGMA synchronous=FULL;`); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, fmt.Sprintf(`PRAGMA synchronous=%d;`, mode))
+	var tx *sqlx.Tx
+	err = db.retry(func() error {
+		var err error
+		tx, err = conn.BeginTxx(ctx, nil)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
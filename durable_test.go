@@ -0,0 +1,29 @@
+package kvstore
+
+import (
+	"testing"
+)
+
+func TestSetDurable(t *testing.T) {
+	db := New(ConnectionPool(1, 1))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer db.Close()
+	if err := db.SetDurable("license", "ABCD-1234"); err != nil {
+		t.Fatalf(`set durable: %v`, err)
+	}
+	if v, err := db.Get("license"); err != nil || v != "ABCD-1234" {
+		t.Errorf(`expected value, got %v, %v`, v, err)
+	}
+	var mode int
+	if err := db.sqx.Get(&mode, `PRAGMA synchronous;`); err != nil || mode != 1 {
+		t.Errorf(`expected synchronous mode NORMAL to be restored, got %v, %v`, mode, err)
+	}
+	if err := db.Lock("license"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDurable("license", "other"); err == nil {
+		t.Errorf(`expected locked key to be rejected`)
+	}
+}
@@ -0,0 +1,175 @@
+package kvstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var EncryptionKeyErr = errors.New(`missing or invalid encryption key`)
+
+// Encrypted values and defaults are sealed with AES-GCM and encoded like values of registered marshalers as a
+// zero byte, followed by encryptedMark, the ID of the key, the nonce, and the sealed encoding. Gob encoded values
+// are identified by the zero byte, which cannot start a gob stream, while the codec of values encoded otherwise
+// is prefixed with encryptedCodec in the codec column.
+const (
+	encryptedMark  = 'e'
+	encryptedCodec = "encrypted:"
+	keyIDSize      = 8
+)
+
+// encryptionKeys holds the ciphers of the keys configured with EncryptCategories by key ID, so that values can
+// be decrypted wherever they are decoded.
+var encryptionKeys = struct {
+	sync.RWMutex
+	m map[string]cipher.AEAD
+}{m: make(map[string]cipher.AEAD)}
+
+// encryption holds the key and categories configured with EncryptCategories.
+type encryption struct {
+	key        []byte
+	categories []string
+	id         string
+	aead       cipher.AEAD
+}
+
+// EncryptCategories configures the store to encrypt values and defaults of keys in the given categories at rest
+// with AES-GCM, using a key of 16, 24, or 32 bytes for AES-128, AES-192, or AES-256. Other values are stored
+// unencrypted, avoiding the overhead of encrypting the whole database for ordinary preferences. Values are
+// encrypted when they are written, so values of keys moved into an encrypted category by SetDefault are
+// encrypted the next time they are set. Keys are registered for the whole process when a store is opened, and
+// reading values encrypted with a key that has not been registered returns an error wrapping EncryptionKeyErr.
+// Open returns such an error if the key has an invalid size. Encrypted
+// values are not indexed for full-text search, and SetJSON returns UnsupportedErr for keys in encrypted
+// categories.
+func EncryptCategories(key []byte, categories ...string) Option {
+	return func(o *options) {
+		o.encryption = &encryption{key: slices.Clone(key), categories: slices.Clone(categories)}
+	}
+}
+
+// register creates the cipher of the configured key and registers it for decryption.
+func (e *encryption) register() error {
+	if e == nil || e.aead != nil {
+		return nil
+	}
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return fmt.Errorf("%w: %w", EncryptionKeyErr, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(e.key)
+	e.id, e.aead = string(sum[:keyIDSize]), aead
+	encryptionKeys.Lock()
+	defer encryptionKeys.Unlock()
+	encryptionKeys.m[e.id] = aead
+	return nil
+}
+
+// encrypts returns true if values in the category are encrypted.
+func (e *encryption) encrypts(category string) bool {
+	return e != nil && slices.Contains(e.categories, category)
+}
+
+// seal encrypts an encoded value with a random nonce, or with a nonce derived from the value if deterministic is
+// true, so that unchanged defaults are stored unchanged.
+func (e *encryption) seal(b []byte, deterministic bool) ([]byte, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if deterministic {
+		mac := hmac.New(sha256.New, e.key)
+		mac.Write(b)
+		copy(nonce, mac.Sum(nil))
+	} else if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := append([]byte{marshalerMark, encryptedMark}, e.id...)
+	sealed = append(sealed, nonce...)
+	return e.aead.Seal(sealed, nonce, b, nil), nil
+}
+
+// isEncrypted returns true if b is an encrypted value.
+func isEncrypted(b []byte) bool {
+	return len(b) > 1 && b[0] == marshalerMark && b[1] == encryptedMark
+}
+
+// decrypt returns the encoded value sealed in an encrypted value.
+func decrypt(b []byte) ([]byte, error) {
+	if len(b) < 2+keyIDSize {
+		return nil, fmt.Errorf("%w: truncated encrypted value", IntegrityErr)
+	}
+	encryptionKeys.RLock()
+	aead, ok := encryptionKeys.m[string(b[2:2+keyIDSize])]
+	encryptionKeys.RUnlock()
+	if !ok {
+		return nil, EncryptionKeyErr
+	}
+	b = b[2+keyIDSize:]
+	if len(b) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: truncated encrypted value", IntegrityErr)
+	}
+	plain, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decrypt value: %w", IntegrityErr, err)
+	}
+	return plain, nil
+}
+
+// encryptValue encrypts a value encoded with the given codec for the key if its category is encrypted, and
+// returns the value and codec to be stored.
+func (db *KVStore) encryptValue(q sqlx.Queryer, key string, b []byte, codec string) ([]byte, string, error) {
+	if db.opts.encryption == nil {
+		return b, codec, nil
+	}
+	var category sql.NullString
+	err := sqlx.Get(q, &category, `SELECT category FROM kv WHERE key=?;`, key)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, "", err
+	}
+	if !db.opts.encryption.encrypts(category.String) {
+		return b, codec, nil
+	}
+	if b, err = db.opts.encryption.seal(b, false); err != nil {
+		return nil, "", err
+	}
+	if codec != codecGob {
+		codec = encryptedCodec + codec
+	}
+	return b, codec, nil
+}
+
+// encryptedCategory returns an error wrapping UnsupportedErr if the key is in an encrypted category.
+func (db *KVStore) encryptedCategory(q sqlx.Queryer, key string) error {
+	if db.opts.encryption == nil {
+		return nil
+	}
+	var category sql.NullString
+	err := sqlx.Get(q, &category, `SELECT category FROM kv WHERE key=?;`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if db.opts.encryption.encrypts(category.String) {
+		return fmt.Errorf("%w: key %q is in encrypted category %q", UnsupportedErr, key, category.String)
+	}
+	return nil
+}
+
+// plainCodec returns the codec of a value without the prefix of encrypted values, and whether it has the prefix.
+func plainCodec(codec string) (string, bool) {
+	return strings.CutPrefix(codec, encryptedCodec)
+}
@@ -0,0 +1,85 @@
+package kvstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestEncryptCategories(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	dir := t.TempDir()
+	db := New(EncryptCategories(key, "secrets"))
+	if err := db.Open(dir); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	if err := db.SetDefault("token", "default-token", KeyInfo{Category: "secrets"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.SetDefault("theme", "dark", KeyInfo{Category: "appearance"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if v, err := db.Get("token"); err != nil || v != "default-token" {
+		t.Errorf(`expected decrypted default, got %v, %v`, v, err)
+	}
+	if err := db.Set("token", "s3cr3t-value"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.SetRaw("token", []byte("raw-s3cr3t")); err != nil {
+		t.Fatalf(`failed to set raw value: %v`, err)
+	}
+	if v, err := db.Get("token"); err != nil || !bytes.Equal(v.([]byte), []byte("raw-s3cr3t")) {
+		t.Errorf(`expected decrypted raw value, got %v, %v`, v, err)
+	}
+	if b, err := db.GetRaw("token"); err != nil || !bytes.Equal(b, []byte("raw-s3cr3t")) {
+		t.Errorf(`expected GetRaw to return the decrypted raw bytes, got %q, %v`, b, err)
+	}
+	if err := db.Set("token", "s3cr3t-value"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if b, err := db.GetRaw("token"); err != nil {
+		t.Errorf(`failed to get raw encoding: %v`, err)
+	} else if v, err := UnmarshalBinary(b); err != nil || v != "s3cr3t-value" {
+		t.Errorf(`expected GetRaw to return the decrypted encoding, got %v, %v`, v, err)
+	}
+	var value, original, plain []byte
+	if err := db.sqx.QueryRowx(`SELECT value,original FROM kv WHERE key='token';`).Scan(&value, &original); err != nil {
+		t.Fatalf(`failed to read row: %v`, err)
+	}
+	if bytes.Contains(value, []byte("s3cr3t-value")) || bytes.Contains(original, []byte("default-token")) {
+		t.Errorf(`expected value and default to be encrypted at rest`)
+	}
+	if err := db.Set("theme", "light"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.sqx.Get(&plain, `SELECT value FROM kv WHERE key='theme';`); err != nil || !bytes.Contains(plain, []byte("light")) {
+		t.Errorf(`expected values in other categories to be stored unencrypted, got %q, %v`, plain, err)
+	}
+	if err := db.SetJSON("token", "x"); !errors.Is(err, UnsupportedErr) {
+		t.Errorf(`expected UnsupportedErr from SetJSON, got %v`, err)
+	}
+	if err := db.Revert("token"); err != nil {
+		t.Fatalf(`failed to revert key: %v`, err)
+	}
+	if v, err := db.Get("token"); err != nil || v != "default-token" {
+		t.Errorf(`expected reverted default, got %v, %v`, v, err)
+	}
+	db.Close()
+	if err := New(EncryptCategories([]byte("short"), "secrets")).Open(t.TempDir()); !errors.Is(err, EncryptionKeyErr) {
+		t.Errorf(`expected EncryptionKeyErr for invalid key, got %v`, err)
+	}
+	// keys are registered per process, so forget the key to read the database like another process would
+	sum := sha256.Sum256(key)
+	encryptionKeys.Lock()
+	delete(encryptionKeys.m, string(sum[:keyIDSize]))
+	encryptionKeys.Unlock()
+	other := New(EncryptCategories(bytes.Repeat([]byte{8}, 32), "secrets"))
+	if err := other.Open(dir); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer other.Close()
+	if _, err := other.Get("token"); !errors.Is(err, EncryptionKeyErr) {
+		t.Errorf(`expected error reading with wrong key, got %v`, err)
+	}
+}
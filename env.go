@@ -0,0 +1,129 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// EnvOverlay configures the store to let environment variables override stored values and defaults on Get and
+// GetWithSource, so that deployments can force settings without changing the database. The variable for a key
+// is named after the prefix followed by an underscore and the key in upper case, with all characters other than
+// letters and digits replaced by underscores; for example, the variable for the key "network.proxy" with prefix
+// "MYAPP" is MYAPP_NETWORK_PROXY. The variable's text is converted to the type of the value or default that would
+// otherwise be returned: strings are used as they are, booleans and numbers are parsed with strconv, durations
+// with time.ParseDuration, times as RFC 3339, and other types as JSON. If the key has neither value nor default,
+// the text is returned as a string. An error wrapping TypeMismatchErr is returned if the text cannot be converted.
+// Environment variables are read on every call and never written to the database.
+func EnvOverlay(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = prefix
+	}
+}
+
+// EnvName returns the name of the environment variable overriding the key, the empty string if no overlay has
+// been configured with EnvOverlay.
+func (db *KVStore) EnvName(key string) string {
+	if db.opts.envPrefix == "" {
+		return ""
+	}
+	name := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, key)
+	return strings.TrimSuffix(db.opts.envPrefix, "_") + "_" + name
+}
+
+// override returns the value overriding the key, converted to the type of the value v that would otherwise be
+// returned with error err, and its source. Command-line flags registered with RegisterFlags take precedence over
+// environment variables. The source is SourceNone if the key is not overridden.
+func (db *KVStore) override(key string, v any, err error) (any, Source, error) {
+	if err != nil && !errors.Is(err, NotFoundErr) {
+		return nil, SourceNone, nil
+	}
+	if fv, ok := db.flags.get(key); ok {
+		return fv, SourceFlag, nil
+	}
+	if ev, ok, err := db.envOverride(key, v, err); ok {
+		return ev, SourceEnvironment, err
+	}
+	return nil, SourceNone, nil
+}
+
+// envOverride returns the value of the environment variable overriding the key, converted to the type of the
+// value v that would otherwise be returned with error err, and whether the variable is set.
+func (db *KVStore) envOverride(key string, v any, err error) (any, bool, error) {
+	name := db.EnvName(key)
+	if name == "" || (err != nil && !errors.Is(err, NotFoundErr)) {
+		return nil, false, nil
+	}
+	s, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, false, nil
+	}
+	if err != nil {
+		v = nil
+	}
+	ev, err := parseText(s, v)
+	if err != nil {
+		return nil, true, fmt.Errorf("%w: environment variable %v: %v", TypeMismatchErr, name, err)
+	}
+	return ev, true, nil
+}
+
+// parseText converts the text of an environment variable or command-line flag to the type of like.
+func parseText(s string, like any) (any, error) {
+	switch like.(type) {
+	case nil, string:
+		return s, nil
+	case []byte:
+		return []byte(s), nil
+	case time.Duration:
+		return time.ParseDuration(s)
+	case time.Time:
+		return time.Parse(time.RFC3339Nano, s)
+	}
+	t := reflect.TypeOf(like)
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 0, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		v.SetString(s)
+	default:
+		if err := json.Unmarshal([]byte(s), v.Addr().Interface()); err != nil {
+			return nil, err
+		}
+	}
+	return v.Interface(), nil
+}
@@ -0,0 +1,61 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEnvOverlay(t *testing.T) {
+	db := New(EnvOverlay("MYAPP"))
+	if err := db.Open(InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer db.Close()
+	if name := db.EnvName("network.proxy"); name != "MYAPP_NETWORK_PROXY" {
+		t.Errorf(`expected MYAPP_NETWORK_PROXY, got %v`, name)
+	}
+	if err := db.Set("network.proxy", "stored.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Set("retries", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDefault("scale", 1.5, KeyInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MYAPP_NETWORK_PROXY", "env.example.com")
+	t.Setenv("MYAPP_RETRIES", "5")
+	t.Setenv("MYAPP_SCALE", "2.5")
+	t.Setenv("MYAPP_UNSTORED", "text")
+	if v, source, err := db.GetWithSource("network.proxy"); err != nil || v != "env.example.com" || source != SourceEnvironment {
+		t.Errorf(`expected value of environment variable, got %v, %v, %v`, v, source, err)
+	}
+	if v, err := db.Get("retries"); err != nil || v != 5 {
+		t.Errorf(`expected int 5, got %#v, %v`, v, err)
+	}
+	if v, err := db.Get("scale"); err != nil || v != 2.5 {
+		t.Errorf(`expected float64 2.5, got %#v, %v`, v, err)
+	}
+	if v, err := parseText("1m", time.Second); err != nil || v != time.Minute {
+		t.Errorf(`expected duration, got %#v, %v`, v, err)
+	}
+	if v, err := db.Get("unstored"); err != nil || v != "text" {
+		t.Errorf(`expected string, got %#v, %v`, v, err)
+	}
+	if v, err := db.Get("network.proxy"); err != nil || v != "env.example.com" {
+		t.Errorf(`expected value of environment variable, got %v, %v`, v, err)
+	}
+	t.Setenv("MYAPP_RETRIES", "many")
+	if _, err := db.Get("retries"); !errors.Is(err, TypeMismatchErr) {
+		t.Errorf(`expected TypeMismatchErr, got %v`, err)
+	}
+	plain := New()
+	if err := plain.Open(InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer plain.Close()
+	if _, err := plain.Get("unstored"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected environment to be ignored without overlay, got %v`, err)
+	}
+}
@@ -0,0 +1,110 @@
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode classifies the errors returned by the store, so that callers can branch on them without comparing
+// against every sentinel error.
+type ErrorCode int
+
+const (
+	CodeOther            ErrorCode = iota // the error is not one of the errors below, e.g. an I/O or sqlite error
+	CodeNotFound                          // NotFoundErr
+	CodeNotOpen                           // NotOpenErr
+	CodeNoDefault                         // NoDefaultErr
+	CodeLocked                            // KeyLockedErr
+	CodeConstraint                        // ConstraintErr
+	CodeTypeMismatch                      // TypeMismatchErr
+	CodeRevisionMismatch                  // RevisionMismatchErr
+	CodeKeyExists                         // KeyExistsErr
+	CodeIntegrity                         // IntegrityErr
+)
+
+// codes maps sentinel errors to their codes.
+var codes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{NotFoundErr, CodeNotFound},
+	{NotOpenErr, CodeNotOpen},
+	{NoDefaultErr, CodeNoDefault},
+	{KeyLockedErr, CodeLocked},
+	{ConstraintErr, CodeConstraint},
+	{TypeMismatchErr, CodeTypeMismatch},
+	{RevisionMismatchErr, CodeRevisionMismatch},
+	{KeyExistsErr, CodeKeyExists},
+	{IntegrityErr, CodeIntegrity},
+}
+
+// String returns a human-readable name of the code.
+func (c ErrorCode) String() string {
+	switch c {
+	case CodeNotFound:
+		return "not found"
+	case CodeNotOpen:
+		return "not open"
+	case CodeNoDefault:
+		return "no default"
+	case CodeLocked:
+		return "locked"
+	case CodeConstraint:
+		return "constraint"
+	case CodeTypeMismatch:
+		return "type mismatch"
+	case CodeRevisionMismatch:
+		return "revision mismatch"
+	case CodeKeyExists:
+		return "key exists"
+	case CodeIntegrity:
+		return "integrity"
+	}
+	return "other"
+}
+
+// KeyError is returned by operations on a single key, such as Get, Set, Delete, and Revert. It records the
+// key, the operation, and the code of the underlying error, which can still be tested with errors.Is, for
+// example errors.Is(err, NotFoundErr).
+type KeyError struct {
+	Key  string
+	Op   string // the operation, e.g. "get" or "set"
+	Code ErrorCode
+	Err  error
+}
+
+// Error returns the operation, the key, and the message of the underlying error.
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%s %q: %v", e.Op, e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code of the error, CodeOther if it is not one of the errors of this package.
+func CodeOf(err error) ErrorCode {
+	var ke *KeyError
+	if errors.As(err, &ke) {
+		return ke.Code
+	}
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return CodeOther
+}
+
+// keyError wraps err in a KeyError for the operation and key, unless it is nil or already a KeyError.
+func keyError(op, key string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var ke *KeyError
+	if errors.As(err, &ke) {
+		return err
+	}
+	return &KeyError{Key: key, Op: op, Code: CodeOf(err), Err: err}
+}
@@ -0,0 +1,35 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeyError(t *testing.T) {
+	db := openTestStore(t)
+	_, err := db.Get("missing")
+	var ke *KeyError
+	if !errors.As(err, &ke) {
+		t.Fatalf(`expected KeyError, got %v`, err)
+	}
+	if ke.Key != "missing" || ke.Op != "get" || ke.Code != CodeNotFound || !errors.Is(err, NotFoundErr) {
+		t.Errorf(`unexpected KeyError %+v`, ke)
+	}
+	if err.Error() != `get "missing": key not found` {
+		t.Errorf(`unexpected error message %q`, err.Error())
+	}
+	if err := db.Set("a", "text"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if _, err := db.GetInt("a"); CodeOf(err) != CodeTypeMismatch || !errors.Is(err, TypeMismatchErr) {
+		t.Errorf(`expected type mismatch, got %v`, err)
+	}
+	if CodeOf(NotOpenErr) != CodeNotOpen || CodeOf(errors.New("other")) != CodeOther {
+		t.Errorf(`unexpected codes for unwrapped errors`)
+	}
+	db.Close()
+	err = db.Delete("a")
+	if !errors.As(err, &ke) || ke.Op != "delete" || ke.Code != CodeNotOpen || !errors.Is(err, NotOpenErr) {
+		t.Errorf(`expected KeyError for closed store, got %v`, err)
+	}
+}
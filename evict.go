@@ -0,0 +1,110 @@
+package kvstore
+
+import (
+	"slices"
+)
+
+// EvictionPolicy specifies which keys are removed first when a bounded store exceeds its limits.
+type EvictionPolicy int
+
+const (
+	EvictLRU    EvictionPolicy = iota // least recently used keys first
+	EvictLFU                          // least frequently read keys first, least recently used first among equals
+	EvictOldest                       // least recently created keys first
+)
+
+// orderBy returns the SQL ORDER BY clause listing eviction candidates in the order they are evicted.
+func (p EvictionPolicy) orderBy() string {
+	lastUse := `max(coalesce(a.last_accessed,0),coalesce(kv.updated_at,0))`
+	switch p {
+	case EvictLFU:
+		return `ORDER BY coalesce(a.access_count,0),` + lastUse + `,kv.key`
+	case EvictOldest:
+		return `ORDER BY coalesce(kv.created_at,0),kv.key`
+	}
+	return `ORDER BY ` + lastUse + `,kv.key`
+}
+
+// Bounded limits the store to at most maxEntries keys and maxBytes bytes of encoded values and defaults.
+// A limit is ignored if it is not positive. Whenever a write exceeds a limit, keys are deleted according to
+// the policy until the store is within its limits again, turning the store into a bounded persistent cache.
+// Keys just written are never evicted by the same write. EvictLRU and EvictLFU enable TrackAccess.
+func Bounded(maxEntries int, maxBytes int64, policy EvictionPolicy) Option {
+	return func(o *options) {
+		o.maxEntries = maxEntries
+		o.maxBytes = maxBytes
+		o.eviction = policy
+		if policy != EvictOldest {
+			o.trackAccess = true
+		}
+	}
+}
+
+// evict deletes keys until the store is within the limits configured with Bounded, never deleting the
+// excluded keys.
+func (db *KVStore) evict(exclude ...string) error {
+	if db.opts.maxEntries <= 0 && db.opts.maxBytes <= 0 {
+		return nil
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var entries, size int64
+	err = tx.QueryRowx(`SELECT COUNT(*),COALESCE(SUM(LENGTH(value)),0)+COALESCE(SUM(LENGTH(original)),0) FROM kv;`).
+		Scan(&entries, &size)
+	if err != nil {
+		return err
+	}
+	over := func() bool {
+		return (db.opts.maxEntries > 0 && entries > int64(db.opts.maxEntries)) ||
+			(db.opts.maxBytes > 0 && size > db.opts.maxBytes)
+	}
+	if !over() {
+		return nil
+	}
+	rows, err := tx.Queryx(`SELECT kv.key,COALESCE(LENGTH(kv.value),0)+COALESCE(LENGTH(kv.original),0)
+FROM kv LEFT JOIN kv_access a ON a.key=kv.key ` + db.opts.eviction.orderBy() + `;`)
+	if err != nil {
+		return err
+	}
+	var victims []string
+	for over() && rows.Next() {
+		var key string
+		var n int64
+		if err := rows.Scan(&key, &n); err != nil {
+			rows.Close()
+			return err
+		}
+		if slices.Contains(exclude, key) {
+			continue
+		}
+		victims = append(victims, key)
+		entries--
+		size -= n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	defer db.cache.remove(victims...)
+	notify := db.hasListeners()
+	var changes []change
+	for _, key := range victims {
+		if notify {
+			changes = append(changes, change{key: key, old: db.current(tx, key)})
+		}
+		if _, err := tx.Exec(`DELETE FROM kv WHERE key=?;`, key); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := db.notify(changes...); err != nil {
+		return err
+	}
+	db.removeOrphans()
+	return nil
+}
@@ -0,0 +1,46 @@
+package kvstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBounded(t *testing.T) {
+	for _, tc := range []struct {
+		policy  EvictionPolicy
+		evicted string
+	}{{EvictLRU, "c"}, {EvictLFU, "b"}, {EvictOldest, "a"}} {
+		db := New(Bounded(3, 0, tc.policy))
+		if err := db.Open(t.TempDir()); err != nil {
+			t.Fatalf(`failed to open database: %v`, err)
+		}
+		for _, key := range []string{"a", "b", "c"} {
+			if err := db.Set(key, key); err != nil {
+				t.Fatalf(`failed to set key: %v`, err)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		// c is read twice before b and a are read once, so c is least recently used and b is least
+		// frequently used, being read as often as a but earlier
+		for _, key := range []string{"c", "c", "b", "a"} {
+			if _, err := db.Get(key); err != nil {
+				t.Fatalf(`failed to get key: %v`, err)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if err := db.Set("d", "d"); err != nil {
+			t.Fatalf(`failed to set key: %v`, err)
+		}
+		keys, err := db.LeastRecentlyUsed(0)
+		if err != nil {
+			t.Fatalf(`failed to list keys: %v`, err)
+		}
+		if len(keys) != 3 {
+			t.Errorf(`expected 3 keys with policy %v, got %v`, tc.policy, keys)
+		}
+		if ok, _ := db.Has(tc.evicted); ok {
+			t.Errorf(`expected %v to be evicted with policy %v, got %v`, tc.evicted, tc.policy, keys)
+		}
+		db.Close()
+	}
+}
@@ -0,0 +1,173 @@
+package kvstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// externalGrace is the minimum age of an unreferenced external file before it is removed. Writers refresh
+// the modification time of files they reference, so files are not removed while another write is pending.
+const externalGrace = time.Minute
+
+// ExternalValues configures the store to keep encoded values larger than threshold bytes as files in the
+// values subdirectory of the store's directory, so that the database stays small. Only a reference to the file
+// and a checksum of its contents are stored in the database, and Get and Set work as usual. Files are named after
+// the SHA-256 hash of their contents, so keys with identical values share a file. Files that are no longer
+// referenced are removed by Delete and Close. Defaults are always stored in the database. External values require
+// the default sqlite driver; Open returns UnsupportedErr if another driver has been selected.
+func ExternalValues(threshold int) Option {
+	return func(o *options) {
+		o.externalThreshold = threshold
+	}
+}
+
+// initExternal adds the column referencing external files and the triggers keeping track of files that may no
+// longer be referenced.
+func initExternal(tx *sqlx.Tx) error {
+	if err := addColumn(tx, "kv", "external", "TEXT"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+CREATE INDEX IF NOT EXISTS kv_external ON kv(external) WHERE external IS NOT NULL;
+CREATE TABLE IF NOT EXISTS kv_orphans(name TEXT PRIMARY KEY NOT NULL);
+CREATE TRIGGER IF NOT EXISTS kv_external_update AFTER UPDATE OF external ON kv
+WHEN old.external IS NOT NULL AND old.external IS NOT new.external BEGIN
+  INSERT INTO kv_orphans(name) SELECT old.external WHERE NOT EXISTS(SELECT 1 FROM kv_orphans WHERE name=old.external);
+END;
+CREATE TRIGGER IF NOT EXISTS kv_external_delete AFTER DELETE ON kv WHEN old.external IS NOT NULL BEGIN
+  INSERT INTO kv_orphans(name) SELECT old.external WHERE NOT EXISTS(SELECT 1 FROM kv_orphans WHERE name=old.external);
+END;
+`)
+	return err
+}
+
+// externalPath returns the path of the external file with the given name.
+func (db *KVStore) externalPath(name string) string {
+	dir := "values"
+	if table := db.opts.tableName(); table != defaultTable {
+		dir = table + "_values"
+	}
+	return filepath.Join(filepath.Dir(db.path), dir, name[:2], name)
+}
+
+// readExternal returns the contents of an external file, an error wrapping IntegrityErr if they do not match
+// the checksum in the file's name.
+func (db *KVStore) readExternal(name string) ([]byte, error) {
+	if len(name) != 2*sha256.Size {
+		return nil, fmt.Errorf("%w: invalid external file name %q", IntegrityErr, name)
+	}
+	b, err := os.ReadFile(db.externalPath(name))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(b)
+	if hex.EncodeToString(sum[:]) != name {
+		return nil, fmt.Errorf("%w: checksum mismatch for external file %v", IntegrityErr, name)
+	}
+	return b, nil
+}
+
+// writeExternal writes an encoded value to an external file unless it already exists and returns the
+// checksum naming the file.
+func (db *KVStore) writeExternal(b []byte) ([]byte, string, error) {
+	sum := sha256.Sum256(b)
+	name := hex.EncodeToString(sum[:])
+	path := db.externalPath(name)
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err == nil {
+		return sum[:], name, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, "", err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), name+".*.tmp")
+	if err != nil {
+		return nil, "", err
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, "", err
+	}
+	return sum[:], name, nil
+}
+
+// put stores a gob encoded value of the named type for the key, see putCodec.
+func (db *KVStore) put(ex sqlx.Ext, key string, b []byte, typ string) error {
+	return db.putCodec(ex, key, b, codecGob, typ)
+}
+
+// putCodec stores an encoded value for the key, encrypted if its category is encrypted, and in an external file
+// or the content table if it exceeds the configured thresholds. Deduplicated values must be written within a
+// transaction.
+func (db *KVStore) putCodec(ex sqlx.Ext, key string, b []byte, codec, typ string) error {
+	b, codec, err := db.encryptValue(ex, key, b, codec)
+	if err != nil {
+		return err
+	}
+	now := db.now().UnixMilli()
+	if db.opts.externalThreshold <= 0 || len(b) <= db.opts.externalThreshold {
+		hash, err := db.dedup(ex, b)
+		if err != nil {
+			return err
+		}
+		if hash == nil {
+			return put(ex, key, b, codec, typ, nil, nil, now)
+		}
+		return put(ex, key, hash, codec, typ, nil, hash, now)
+	}
+	sum, name, err := db.writeExternal(b)
+	if err != nil {
+		return err
+	}
+	return put(ex, key, sum, codec, typ, name, nil, now)
+}
+
+// removeOrphans removes external files that are no longer referenced. This is done on a best-effort basis,
+// and files that cannot be removed yet are tried again later.
+func (db *KVStore) removeOrphans() {
+	var names []string
+	if err := db.sqx.Select(&names, `SELECT name FROM kv_orphans;`); err != nil || len(names) == 0 {
+		return
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+	for _, name := range names {
+		var referenced bool
+		if err := tx.Get(&referenced, `SELECT EXISTS(SELECT 1 FROM kv WHERE external=?);`, name); err != nil {
+			return
+		}
+		if !referenced {
+			path := db.externalPath(name)
+			info, err := os.Stat(path)
+			if err == nil && time.Since(info.ModTime()) < externalGrace {
+				continue
+			}
+			if err == nil && os.Remove(path) != nil {
+				continue
+			}
+		}
+		if _, err := tx.Exec(`DELETE FROM kv_orphans WHERE name=?;`, name); err != nil {
+			return
+		}
+	}
+	tx.Commit()
+}
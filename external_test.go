@@ -0,0 +1,79 @@
+//go:build !kvstore_modernc && !kvstore_mattn
+
+package kvstore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExternalValues(t *testing.T) {
+	db := New(ExternalValues(1024))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	large := strings.Repeat("x", 4096)
+	if err := db.SetMany(map[string]any{"a": large, "b": large, "small": "y"}); err != nil {
+		t.Fatalf(`failed to set values: %v`, err)
+	}
+	files, _ := filepath.Glob(filepath.Join(filepath.Dir(db.path), "values", "*", "*"))
+	if len(files) != 1 {
+		t.Fatalf(`expected one shared external file, got %v`, files)
+	}
+	var size int
+	if err := db.sqx.Get(&size, `SELECT MAX(LENGTH(value)) FROM kv;`); err != nil || size > 1024 {
+		t.Errorf(`expected only references in the database, got %v bytes, %v`, size, err)
+	}
+	if v, err := db.Get("a"); v != large || err != nil {
+		t.Errorf(`failed to get external value: %v`, err)
+	}
+	all, err := db.GetAll(0)
+	if err != nil || all["b"] != large || all["small"] != "y" {
+		t.Errorf(`failed to get all values: %v`, err)
+	}
+	if err := db.DeleteMany([]string{"a", "b"}); err != nil {
+		t.Fatalf(`failed to delete keys: %v`, err)
+	}
+	if _, err := os.Stat(files[0]); err != nil {
+		t.Errorf(`expected recently written file to be kept for the grace period: %v`, err)
+	}
+	old := time.Now().Add(-2 * externalGrace)
+	os.Chtimes(files[0], old, old)
+	db.removeOrphans()
+	if _, err := os.Stat(files[0]); !os.IsNotExist(err) {
+		t.Errorf(`expected unreferenced file to be removed, got %v`, err)
+	}
+	if err := db.Set("c", large); err != nil {
+		t.Fatalf(`failed to set value: %v`, err)
+	}
+	if err := os.WriteFile(files[0], []byte("corrupt"), 0644); err != nil {
+		t.Fatalf(`failed to corrupt file: %v`, err)
+	}
+	db.cache.clear()
+	if _, err := db.Get("c"); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf(`expected checksum error for corrupted file, got %v`, err)
+	}
+}
+
+func TestSetIfAbsentExternal(t *testing.T) {
+	db := New(ExternalValues(1024))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	large := strings.Repeat("x", 10240)
+	if ok, err := db.SetIfAbsent("a", large); !ok || err != nil {
+		t.Fatalf(`failed to set absent key: %v, %v`, ok, err)
+	}
+	files, _ := filepath.Glob(filepath.Join(filepath.Dir(db.path), "values", "*", "*"))
+	if len(files) != 1 {
+		t.Errorf(`expected value set with SetIfAbsent to be stored externally, got %v`, files)
+	}
+	if v, err := db.Get("a"); v != large || err != nil {
+		t.Errorf(`failed to get external value: %v`, err)
+	}
+}
@@ -0,0 +1,111 @@
+package kvstore
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// flagOverlay holds the values of command-line flags registered with RegisterFlags that override stored values.
+type flagOverlay struct {
+	mutex  sync.RWMutex
+	values map[string]any
+}
+
+// get returns the value of the flag for key and whether it has been set.
+func (o *flagOverlay) get(key string) (any, bool) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// set records the value of the flag for key.
+func (o *flagOverlay) set(key string, v any) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if o.values == nil {
+		o.values = make(map[string]any)
+	}
+	o.values[key] = v
+}
+
+// RegisterFlags defines a command-line flag in fs for each of the given keys, or for all keys that have a value or
+// a default if no keys are given. Flags are named after their keys and use the description of the key info as
+// usage text and the current value as default. Their text is converted to the type of the current value as with
+// EnvOverlay. If persist is true, parsed flag values are written to the store with Set; otherwise they override
+// stored values and environment variables on Get and GetWithSource for the lifetime of the store without being
+// written, so that the precedence is flag, environment, stored value, default. Keys whose flag is already
+// defined in fs are skipped. The store must be open.
+func (db *KVStore) RegisterFlags(fs *flag.FlagSet, persist bool, keys ...string) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if len(keys) == 0 {
+		if err := db.flushPending(); err != nil {
+			return err
+		}
+		if err := db.sqx.Select(&keys, `SELECT key FROM kv WHERE value IS NOT NULL OR original IS NOT NULL ORDER BY key ASC;`); err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		if fs.Lookup(key) != nil {
+			continue
+		}
+		info, _ := db.Info(key)
+		fs.Var(&keyFlag{db: db, key: key, persist: persist}, key, info.Description)
+	}
+	return nil
+}
+
+// keyFlag is a flag.Value setting or overriding the value of a key.
+type keyFlag struct {
+	db      *KVStore
+	key     string
+	persist bool
+}
+
+// String returns the current value of the key.
+func (f *keyFlag) String() string {
+	if f == nil || f.db == nil {
+		return ""
+	}
+	v, err := f.db.Get(f.key)
+	if err != nil || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// Set converts the text to the type of the current value and sets or overrides the value of the key.
+func (f *keyFlag) Set(s string) error {
+	v, err := f.db.Get(f.key)
+	if err != nil && !errors.Is(err, NotFoundErr) {
+		return err
+	}
+	if err != nil {
+		v = nil
+	}
+	parsed, err := parseText(s, v)
+	if err != nil {
+		return fmt.Errorf("%w: %v", TypeMismatchErr, err)
+	}
+	if f.persist {
+		return f.db.Set(f.key, parsed)
+	}
+	f.db.flags.set(f.key, parsed)
+	return nil
+}
+
+// IsBoolFlag allows boolean flags to be given without a value, e.g. -verbose instead of -verbose=true.
+func (f *keyFlag) IsBoolFlag() bool {
+	if f == nil || f.db == nil {
+		return false
+	}
+	v, err := f.db.Get(f.key)
+	_, ok := v.(bool)
+	return err == nil && ok
+}
@@ -0,0 +1,70 @@
+package kvstore
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	db := New(EnvOverlay("FLAGTEST"))
+	if err := db.Open(InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer db.Close()
+	if err := db.SetDefault("verbose", false, KeyInfo{Description: "print more output"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Set("retries", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Set("proxy", "stored.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FLAGTEST_PROXY", "env.example.com")
+	t.Setenv("FLAGTEST_RETRIES", "4")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if err := db.RegisterFlags(fs, false); err != nil {
+		t.Fatalf(`register flags: %v`, err)
+	}
+	f := fs.Lookup("verbose")
+	if f == nil || f.Usage != "print more output" || f.DefValue != "false" {
+		t.Fatalf(`unexpected flag %+v`, f)
+	}
+	if err := fs.Parse([]string{"-verbose", "-proxy", "flag.example.com"}); err != nil {
+		t.Fatalf(`parse: %v`, err)
+	}
+	if v, source, err := db.GetWithSource("verbose"); err != nil || v != true || source != SourceFlag {
+		t.Errorf(`expected flag value, got %v, %v, %v`, v, source, err)
+	}
+	if v, err := db.Get("proxy"); err != nil || v != "flag.example.com" {
+		t.Errorf(`expected flag to take precedence over environment, got %v, %v`, v, err)
+	}
+	if v, err := db.Get("retries"); err != nil || v != 4 {
+		t.Errorf(`expected environment to take precedence over stored value, got %v, %v`, v, err)
+	}
+	if err := fs.Parse([]string{"-retries", "many"}); err == nil {
+		t.Errorf(`expected error for invalid flag value`)
+	}
+
+	persisted := New()
+	if err := persisted.Open(InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer persisted.Close()
+	if err := persisted.Set("retries", 3); err != nil {
+		t.Fatal(err)
+	}
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := persisted.RegisterFlags(fs, true, "retries"); err != nil {
+		t.Fatalf(`register flags: %v`, err)
+	}
+	if err := fs.Parse([]string{"-retries=7"}); err != nil {
+		t.Fatalf(`parse: %v`, err)
+	}
+	if v, source, err := persisted.GetWithSource("retries"); err != nil || v != 7 || source != SourceValue {
+		t.Errorf(`expected persisted flag value, got %v, %v, %v`, v, source, err)
+	}
+}
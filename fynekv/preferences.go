@@ -0,0 +1,250 @@
+// Package fynekv provides preferences for GUI toolkits backed by a key value store. Preferences implements the
+// fyne.Preferences interface of the Fyne toolkit (fyne.io/fyne/v2) structurally, so this package does not
+// depend on Fyne, and can be used as a generic preferences API by other toolkits as well. Defaults, categories,
+// and Revert of the underlying store remain available for preference dialogs through Store.
+package fynekv
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/rasteric/kvstore"
+)
+
+// Preferences reads and writes preferences in a key value store. Getters return the fallback if the key has
+// neither a value nor a default or if its value has a different type. Since the interface does not return
+// errors, errors other than kvstore.NotFoundErr are passed to the handler set with OnError.
+type Preferences struct {
+	db *kvstore.KVStore
+
+	mutex     sync.RWMutex
+	listeners []func()
+	onError   func(key string, err error)
+}
+
+// New returns preferences backed by the open store db.
+func New(db *kvstore.KVStore) *Preferences {
+	return &Preferences{db: db}
+}
+
+// Store returns the underlying store.
+func (p *Preferences) Store() *kvstore.KVStore {
+	return p.db
+}
+
+// OnError sets the function called with errors of getters and setters, which are ignored by default.
+func (p *Preferences) OnError(fn func(key string, err error)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.onError = fn
+}
+
+// report passes an error to the error handler unless it is nil or kvstore.NotFoundErr.
+func (p *Preferences) report(key string, err error) {
+	if err == nil || errors.Is(err, kvstore.NotFoundErr) {
+		return
+	}
+	p.mutex.RLock()
+	fn := p.onError
+	p.mutex.RUnlock()
+	if fn != nil {
+		fn(key, err)
+	}
+}
+
+// get stores the value for key in dest and returns whether this succeeded.
+func (p *Preferences) get(key string, dest any) bool {
+	err := p.db.GetInto(key, dest)
+	if errors.Is(err, kvstore.TypeMismatchErr) {
+		return false
+	}
+	p.report(key, err)
+	return err == nil
+}
+
+// set sets the value for key.
+func (p *Preferences) set(key string, value any) {
+	p.report(key, p.db.Set(key, value))
+}
+
+// Bool returns the boolean value for the key, false if there is none.
+func (p *Preferences) Bool(key string) bool {
+	return p.BoolWithFallback(key, false)
+}
+
+// BoolWithFallback returns the boolean value for the key, the fallback if there is none.
+func (p *Preferences) BoolWithFallback(key string, fallback bool) bool {
+	var v bool
+	if p.get(key, &v) {
+		return v
+	}
+	return fallback
+}
+
+// SetBool sets a boolean value for the key.
+func (p *Preferences) SetBool(key string, value bool) {
+	p.set(key, value)
+}
+
+// BoolList returns the list of booleans for the key, nil if there is none.
+func (p *Preferences) BoolList(key string) []bool {
+	return p.BoolListWithFallback(key, nil)
+}
+
+// BoolListWithFallback returns the list of booleans for the key, the fallback if there is none.
+func (p *Preferences) BoolListWithFallback(key string, fallback []bool) []bool {
+	var v []bool
+	if p.get(key, &v) {
+		return v
+	}
+	return fallback
+}
+
+// SetBoolList sets a list of booleans for the key.
+func (p *Preferences) SetBoolList(key string, value []bool) {
+	p.set(key, value)
+}
+
+// Float returns the float value for the key, 0 if there is none.
+func (p *Preferences) Float(key string) float64 {
+	return p.FloatWithFallback(key, 0)
+}
+
+// FloatWithFallback returns the float value for the key, the fallback if there is none.
+func (p *Preferences) FloatWithFallback(key string, fallback float64) float64 {
+	var v float64
+	if p.get(key, &v) {
+		return v
+	}
+	return fallback
+}
+
+// SetFloat sets a float value for the key.
+func (p *Preferences) SetFloat(key string, value float64) {
+	p.set(key, value)
+}
+
+// FloatList returns the list of floats for the key, nil if there is none.
+func (p *Preferences) FloatList(key string) []float64 {
+	return p.FloatListWithFallback(key, nil)
+}
+
+// FloatListWithFallback returns the list of floats for the key, the fallback if there is none.
+func (p *Preferences) FloatListWithFallback(key string, fallback []float64) []float64 {
+	var v []float64
+	if p.get(key, &v) {
+		return v
+	}
+	return fallback
+}
+
+// SetFloatList sets a list of floats for the key.
+func (p *Preferences) SetFloatList(key string, value []float64) {
+	p.set(key, value)
+}
+
+// Int returns the integer value for the key, 0 if there is none.
+func (p *Preferences) Int(key string) int {
+	return p.IntWithFallback(key, 0)
+}
+
+// IntWithFallback returns the integer value for the key, the fallback if there is none.
+func (p *Preferences) IntWithFallback(key string, fallback int) int {
+	var v int
+	if p.get(key, &v) {
+		return v
+	}
+	return fallback
+}
+
+// SetInt sets an integer value for the key.
+func (p *Preferences) SetInt(key string, value int) {
+	p.set(key, value)
+}
+
+// IntList returns the list of integers for the key, nil if there is none.
+func (p *Preferences) IntList(key string) []int {
+	return p.IntListWithFallback(key, nil)
+}
+
+// IntListWithFallback returns the list of integers for the key, the fallback if there is none.
+func (p *Preferences) IntListWithFallback(key string, fallback []int) []int {
+	var v []int
+	if p.get(key, &v) {
+		return v
+	}
+	return fallback
+}
+
+// SetIntList sets a list of integers for the key.
+func (p *Preferences) SetIntList(key string, value []int) {
+	p.set(key, value)
+}
+
+// String returns the string value for the key, the empty string if there is none.
+func (p *Preferences) String(key string) string {
+	return p.StringWithFallback(key, "")
+}
+
+// StringWithFallback returns the string value for the key, the fallback if there is none.
+func (p *Preferences) StringWithFallback(key, fallback string) string {
+	var v string
+	if p.get(key, &v) {
+		return v
+	}
+	return fallback
+}
+
+// SetString sets a string value for the key.
+func (p *Preferences) SetString(key string, value string) {
+	p.set(key, value)
+}
+
+// StringList returns the list of strings for the key, nil if there is none.
+func (p *Preferences) StringList(key string) []string {
+	return p.StringListWithFallback(key, nil)
+}
+
+// StringListWithFallback returns the list of strings for the key, the fallback if there is none.
+func (p *Preferences) StringListWithFallback(key string, fallback []string) []string {
+	var v []string
+	if p.get(key, &v) {
+		return v
+	}
+	return fallback
+}
+
+// SetStringList sets a list of strings for the key.
+func (p *Preferences) SetStringList(key string, value []string) {
+	p.set(key, value)
+}
+
+// RemoveValue reverts the key to its default, which removes it if it has no default.
+func (p *Preferences) RemoveValue(key string) {
+	err := p.db.Revert(key)
+	if errors.Is(err, kvstore.NoDefaultErr) {
+		err = p.db.Delete(key)
+	}
+	p.report(key, err)
+}
+
+// AddChangeListener registers a function called whenever a preference changes.
+func (p *Preferences) AddChangeListener(fn func()) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.listeners) == 0 {
+		p.db.OnChange(func(string, any, any) {
+			for _, fn := range p.ChangeListeners() {
+				fn()
+			}
+		})
+	}
+	p.listeners = append(p.listeners, fn)
+}
+
+// ChangeListeners returns the functions registered with AddChangeListener.
+func (p *Preferences) ChangeListeners() []func() {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return append([]func(){}, p.listeners...)
+}
@@ -0,0 +1,65 @@
+package fynekv
+
+import (
+	"testing"
+
+	"github.com/rasteric/kvstore"
+)
+
+func TestPreferences(t *testing.T) {
+	db := kvstore.New()
+	if err := db.Open(kvstore.InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer db.Close()
+	p := New(db)
+	var errs []error
+	p.OnError(func(key string, err error) { errs = append(errs, err) })
+	changes := 0
+	p.AddChangeListener(func() { changes++ })
+
+	if v := p.IntWithFallback("width", 640); v != 640 {
+		t.Errorf(`expected fallback, got %v`, v)
+	}
+	if err := db.SetDefault("width", 800, kvstore.KeyInfo{Category: "window"}); err != nil {
+		t.Fatal(err)
+	}
+	if v := p.IntWithFallback("width", 640); v != 800 {
+		t.Errorf(`expected default, got %v`, v)
+	}
+	p.SetInt("width", 1024)
+	if v := p.Int("width"); v != 1024 {
+		t.Errorf(`expected 1024, got %v`, v)
+	}
+	p.RemoveValue("width")
+	if v := p.Int("width"); v != 800 {
+		t.Errorf(`expected default after RemoveValue, got %v`, v)
+	}
+	p.SetString("name", "demo")
+	if v := p.IntWithFallback("name", 5); v != 5 {
+		t.Errorf(`expected fallback for type mismatch, got %v`, v)
+	}
+	p.SetBool("dark", true)
+	p.SetFloat("scale", 1.5)
+	p.SetStringList("recent", []string{"a.txt", "b.txt"})
+	p.SetIntList("sizes", []int{1, 2})
+	if !p.Bool("dark") || p.Float("scale") != 1.5 || p.String("name") != "demo" {
+		t.Errorf(`unexpected values %v, %v, %v`, p.Bool("dark"), p.Float("scale"), p.String("name"))
+	}
+	if v := p.StringList("recent"); len(v) != 2 || v[1] != "b.txt" {
+		t.Errorf(`unexpected list %v`, v)
+	}
+	if v := p.IntList("sizes"); len(v) != 2 || v[0] != 1 {
+		t.Errorf(`unexpected list %v`, v)
+	}
+	p.RemoveValue("name")
+	if v := p.StringWithFallback("name", "none"); v != "none" {
+		t.Errorf(`expected fallback after RemoveValue, got %v`, v)
+	}
+	if changes == 0 || len(p.ChangeListeners()) != 1 {
+		t.Errorf(`expected change listener to be called, got %v calls`, changes)
+	}
+	if len(errs) != 0 {
+		t.Errorf(`unexpected errors %v`, errs)
+	}
+}
@@ -0,0 +1,108 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Hashes map string fields to values and are stored separately from ordinary values, one row per field, so that
+// a field can be updated without rewriting the whole map. HSet returns KeyExistsErr if the key holds anything but
+// a hash, and the methods for ordinary keys such as Has and Delete do not see hashes. Values must be gob
+// serializable.
+
+// initHashes creates the table holding hash fields.
+func initHashes(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_hash(
+  key TEXT NOT NULL,
+  field TEXT NOT NULL,
+  value BLOB,
+  PRIMARY KEY(key,field)
+) WITHOUT ROWID;
+`)
+	return err
+}
+
+// HSet sets the field of the hash stored at key to the given value, creating the hash if necessary.
+func (db *KVStore) HSet(key, field string, value any) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	b, err := db.encode(value)
+	if err != nil {
+		return err
+	}
+	return db.inTx(func(tx *sqlx.Tx) error {
+		if err := checkKind(tx, key, "hash"); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`INSERT INTO kv_hash(key,field,value) VALUES(?,?,?)
+ON CONFLICT(key,field) DO UPDATE SET value=excluded.value;`, key, field, b)
+		return err
+	})
+}
+
+// HGet returns the value of the field of the hash stored at key, NotFoundErr if there is no such field.
+func (db *KVStore) HGet(key, field string) (any, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	var b []byte
+	err := db.sqx.Get(&b, `SELECT value FROM kv_hash WHERE key=? AND field=?;`, key, field)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, NotFoundErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalBinary(b)
+}
+
+// HDel removes the given fields from the hash stored at key and returns the number of fields removed.
+func (db *KVStore) HDel(key string, fields ...string) (int, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return 0, NotOpenErr
+	}
+	if len(fields) == 0 {
+		return 0, nil
+	}
+	query, args, err := sqlx.In(`DELETE FROM kv_hash WHERE key=? AND field IN (?);`, key, fields)
+	if err != nil {
+		return 0, err
+	}
+	result, err := db.exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// HGetAll returns all fields of the hash stored at key, an empty map if there is no such hash.
+func (db *KVStore) HGetAll(key string) (map[string]any, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	rows, err := db.sqx.Queryx(`SELECT field,value FROM kv_hash WHERE key=?;`, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make(map[string]any)
+	for rows.Next() {
+		var field string
+		var b []byte
+		if err := rows.Scan(&field, &b); err != nil {
+			return result, err
+		}
+		v, err := UnmarshalBinary(b)
+		if err != nil {
+			return result, err
+		}
+		result[field] = v
+	}
+	return result, rows.Err()
+}
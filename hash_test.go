@@ -0,0 +1,32 @@
+package kvstore
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	db := openTestStore(t)
+	for field, v := range map[string]any{"width": 800, "height": 600, "title": "main"} {
+		if err := db.HSet("window", field, v); err != nil {
+			t.Fatalf(`failed to set field: %v`, err)
+		}
+	}
+	if err := db.HSet("window", "width", 1024); err != nil {
+		t.Fatalf(`failed to set field: %v`, err)
+	}
+	if v, err := db.HGet("window", "width"); v != 1024 || err != nil {
+		t.Errorf(`expected 1024, got %v, %v`, v, err)
+	}
+	if n, err := db.HDel("window", "title", "missing"); n != 1 || err != nil {
+		t.Errorf(`expected 1 deleted field, got %v, %v`, n, err)
+	}
+	if _, err := db.HGet("window", "title"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+	all, err := db.HGetAll("window")
+	if err != nil || !reflect.DeepEqual(all, map[string]any{"width": 1024, "height": 600}) {
+		t.Errorf(`unexpected fields %v, %v`, all, err)
+	}
+}
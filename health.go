@@ -0,0 +1,50 @@
+package kvstore
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// State is the state of a store as returned by State.
+type State int
+
+const (
+	StateClosed State = iota // the store has not been opened yet or has been closed
+	StateOpen                // the store is open
+	StateFailed              // opening or closing the store failed
+)
+
+// String returns a human-readable name of the state.
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateFailed:
+		return "failed"
+	}
+	return "closed"
+}
+
+// State returns whether the store is open, closed, or failed to open or close.
+func (db *KVStore) State() State {
+	switch state := atomic.LoadUint32(&db.state); {
+	case state > 255:
+		return StateOpen
+	case state == 3:
+		return StateFailed
+	}
+	return StateClosed
+}
+
+// Ping verifies that the store is open, the database connection is alive, and the kv table can be read. It is
+// intended for health checks.
+func (db *KVStore) Ping(ctx context.Context) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.sqx.PingContext(ctx); err != nil {
+		return err
+	}
+	var n int
+	return db.sqx.QueryRowxContext(ctx, `SELECT count(*) FROM (SELECT 1 FROM kv LIMIT 1);`).Scan(&n)
+}
@@ -0,0 +1,36 @@
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPingAndState(t *testing.T) {
+	db := New()
+	if s := db.State(); s != StateClosed {
+		t.Errorf(`expected new store to be closed, got %v`, s)
+	}
+	if err := db.Ping(context.Background()); !errors.Is(err, NotOpenErr) {
+		t.Errorf(`expected NotOpenErr, got %v`, err)
+	}
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	if s := db.State(); s != StateOpen {
+		t.Errorf(`expected open store, got %v`, s)
+	}
+	if err := db.Ping(context.Background()); err != nil {
+		t.Errorf(`expected successful ping, got %v`, err)
+	}
+	if _, err := db.sqx.Exec(`ALTER TABLE kv RENAME TO kv_renamed;`); err != nil {
+		t.Fatalf(`failed to rename table: %v`, err)
+	}
+	if err := db.Ping(context.Background()); err == nil {
+		t.Errorf(`expected ping to fail without kv table`)
+	}
+	db.Close()
+	if s := db.State(); s != StateClosed {
+		t.Errorf(`expected closed store, got %v`, s)
+	}
+}
@@ -0,0 +1,113 @@
+package kvstore
+
+import "sync"
+
+// Hooks implement cross-cutting concerns such as sanitization, metrics, or replication around the basic
+// operations. BeforeSet and AfterSet hooks apply to Set, SetNil, ForceSet, SetMany, SetWithTTL, SetWithSlidingTTL,
+// SetDurable, SetAsync, SetIfAbsent, SetWithRevision, and SetIfRevision, BeforeDelete hooks to Delete, DeleteMany,
+// DeleteByCategory, DeleteByPrefix, DeleteSubtree, and the key replaced by Rename and Copy, and AfterGet hooks to
+// Get. Specialized methods such as SetRaw, SetJSON, SetCodec, and transactions bypass hooks. Hooks of the same
+// kind are called in the order of registration, and are kept when the store is closed and opened again.
+
+// BeforeSetHook is called with the key and value before a value is set. It returns the value to be set instead,
+// which may be the given value, or an error that vetoes the operation and is returned to the caller.
+type BeforeSetHook func(key string, value any) (any, error)
+
+// AfterSetHook is called with the key and the value that has been set after the value has been written or, in
+// write-behind mode, queued.
+type AfterSetHook func(key string, value any)
+
+// BeforeDeleteHook is called with the key before a key is deleted. It returns an error to veto the operation,
+// which is then returned to the caller.
+type BeforeDeleteHook func(key string) error
+
+// AfterGetHook is called with the key and the result of Get, and returns the result to be returned instead.
+type AfterGetHook func(key string, value any, err error) (any, error)
+
+// hooks holds the hooks registered in this process.
+type hooks struct {
+	mutex        sync.RWMutex
+	beforeSet    []BeforeSetHook
+	afterSet     []AfterSetHook
+	beforeDelete []BeforeDeleteHook
+	afterGet     []AfterGetHook
+}
+
+// BeforeSet registers a hook that is called before a value is set and may transform or veto the value.
+func (db *KVStore) BeforeSet(h BeforeSetHook) {
+	db.hooks.mutex.Lock()
+	defer db.hooks.mutex.Unlock()
+	db.hooks.beforeSet = append(db.hooks.beforeSet, h)
+}
+
+// AfterSet registers a hook that is called after a value has been set.
+func (db *KVStore) AfterSet(h AfterSetHook) {
+	db.hooks.mutex.Lock()
+	defer db.hooks.mutex.Unlock()
+	db.hooks.afterSet = append(db.hooks.afterSet, h)
+}
+
+// BeforeDelete registers a hook that is called before a key is deleted and may veto the deletion.
+func (db *KVStore) BeforeDelete(h BeforeDeleteHook) {
+	db.hooks.mutex.Lock()
+	defer db.hooks.mutex.Unlock()
+	db.hooks.beforeDelete = append(db.hooks.beforeDelete, h)
+}
+
+// AfterGet registers a hook that is called with the result of Get and may transform it.
+func (db *KVStore) AfterGet(h AfterGetHook) {
+	db.hooks.mutex.Lock()
+	defer db.hooks.mutex.Unlock()
+	db.hooks.afterGet = append(db.hooks.afterGet, h)
+}
+
+// beforeSet passes the value through the BeforeSet hooks and returns the value to be set.
+func (db *KVStore) beforeSet(key string, value any) (any, error) {
+	db.hooks.mutex.RLock()
+	defer db.hooks.mutex.RUnlock()
+	for _, h := range db.hooks.beforeSet {
+		var err error
+		if value, err = h(key, value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// afterSet calls the AfterSet hooks.
+func (db *KVStore) afterSet(key string, value any) {
+	db.hooks.mutex.RLock()
+	defer db.hooks.mutex.RUnlock()
+	for _, h := range db.hooks.afterSet {
+		h(key, value)
+	}
+}
+
+// beforeDelete calls the BeforeDelete hooks and returns the first error.
+func (db *KVStore) beforeDelete(key string) error {
+	db.hooks.mutex.RLock()
+	defer db.hooks.mutex.RUnlock()
+	for _, h := range db.hooks.beforeDelete {
+		if err := h(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasDeleteHooks returns true if BeforeDelete hooks are registered.
+func (db *KVStore) hasDeleteHooks() bool {
+	db.hooks.mutex.RLock()
+	defer db.hooks.mutex.RUnlock()
+	return len(db.hooks.beforeDelete) > 0
+}
+
+// afterGet passes the result of Get through the AfterGet hooks.
+func (db *KVStore) afterGet(key string, value any, err error) (any, error) {
+	db.hooks.mutex.RLock()
+	defer db.hooks.mutex.RUnlock()
+	for _, h := range db.hooks.afterGet {
+		value, err = h(key, value, err)
+	}
+	return value, err
+}
@@ -0,0 +1,140 @@
+package kvstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	db := openTestStore(t)
+	vetoErr := errors.New("vetoed")
+	db.BeforeSet(func(key string, value any) (any, error) {
+		if s, ok := value.(string); ok {
+			return strings.TrimSpace(s), nil
+		}
+		return value, nil
+	})
+	db.BeforeSet(func(key string, value any) (any, error) {
+		if key == "readonly" {
+			return nil, vetoErr
+		}
+		return value, nil
+	})
+	var set []string
+	db.AfterSet(func(key string, value any) {
+		set = append(set, key+"="+value.(string))
+	})
+	db.BeforeDelete(func(key string) error {
+		if key == "keep" {
+			return vetoErr
+		}
+		return nil
+	})
+	db.AfterGet(func(key string, value any, err error) (any, error) {
+		if errors.Is(err, NotFoundErr) && key == "fallback" {
+			return "computed", nil
+		}
+		return value, err
+	})
+	if err := db.Set("name", "  Alice "); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if v, err := db.Get("name"); err != nil || v != "Alice" {
+		t.Errorf(`expected transformed value, got %v, %v`, v, err)
+	}
+	if err := db.Set("readonly", "x"); !errors.Is(err, vetoErr) {
+		t.Errorf(`expected vetoed set, got %v`, err)
+	}
+	if err := db.SetMany(map[string]any{"keep": " k "}); err != nil {
+		t.Fatalf(`failed to set keys: %v`, err)
+	}
+	if len(set) != 2 || set[0] != "name=Alice" || set[1] != "keep=k" {
+		t.Errorf(`wrong AfterSet calls: %v`, set)
+	}
+	if err := db.Delete("keep"); !errors.Is(err, vetoErr) {
+		t.Errorf(`expected vetoed delete, got %v`, err)
+	}
+	if err := db.DeleteMany([]string{"name", "keep"}); !errors.Is(err, vetoErr) {
+		t.Errorf(`expected vetoed delete, got %v`, err)
+	}
+	if ok, _ := db.Has("name"); !ok {
+		t.Errorf(`expected no key to be deleted when one deletion is vetoed`)
+	}
+	if v, err := db.Get("fallback"); err != nil || v != "computed" {
+		t.Errorf(`expected value from AfterGet hook, got %v, %v`, v, err)
+	}
+}
+
+func TestHooksConditionalSet(t *testing.T) {
+	db := openTestStore(t)
+	db.BeforeSet(func(key string, value any) (any, error) {
+		if key == "readonly" {
+			return nil, errors.New("vetoed")
+		}
+		return strings.TrimSpace(value.(string)), nil
+	})
+	var set []string
+	db.AfterSet(func(key string, value any) {
+		set = append(set, key+"="+value.(string))
+	})
+	if ok, err := db.SetIfAbsent("a", " x "); !ok || err != nil {
+		t.Fatalf(`failed to set absent key: %v, %v`, ok, err)
+	}
+	if ok, err := db.SetIfAbsent("readonly", "x"); ok || err == nil {
+		t.Errorf(`expected vetoed SetIfAbsent, got %v, %v`, ok, err)
+	}
+	if _, err := db.SetIfRevision("b", " y ", 0); err != nil {
+		t.Fatalf(`failed to set key with revision: %v`, err)
+	}
+	if _, err := db.SetWithRevision("readonly", "x"); err == nil {
+		t.Errorf(`expected vetoed SetWithRevision`)
+	}
+	if v, _ := db.Get("b"); v != "y" {
+		t.Errorf(`expected transformed value, got %v`, v)
+	}
+	if len(set) != 2 || set[0] != "a=x" || set[1] != "b=y" {
+		t.Errorf(`wrong AfterSet calls: %v`, set)
+	}
+}
+
+func TestHooksBulkDelete(t *testing.T) {
+	db := openTestStore(t)
+	for _, k := range []string{"app/a", "app/protected", "other", "target"} {
+		if err := db.Set(k, k); err != nil {
+			t.Fatalf(`failed to set key: %v`, err)
+		}
+	}
+	var deleted []string
+	db.BeforeDelete(func(key string) error {
+		if key == "app/protected" || key == "target" {
+			return errors.New("vetoed")
+		}
+		deleted = append(deleted, key)
+		return nil
+	})
+	if err := db.DeleteByPrefix("app/"); err == nil {
+		t.Errorf(`expected vetoed DeleteByPrefix`)
+	}
+	if err := db.DeleteSubtree("app"); err == nil {
+		t.Errorf(`expected vetoed DeleteSubtree`)
+	}
+	if ok, _ := db.Has("app/a"); !ok {
+		t.Errorf(`expected no key to be deleted when one deletion is vetoed`)
+	}
+	if err := db.Rename("other", "target", true); err == nil {
+		t.Errorf(`expected vetoed overwrite by Rename`)
+	}
+	if err := db.Copy("other", "target"); err == nil {
+		t.Errorf(`expected vetoed overwrite by Copy`)
+	}
+	if v, _ := db.Get("target"); v != "target" {
+		t.Errorf(`expected target to be kept, got %v`, v)
+	}
+	if err := db.Rename("other", "moved", true); err != nil {
+		t.Fatalf(`failed to rename key: %v`, err)
+	}
+	if len(deleted) != 2 || deleted[0] != "app/a" || deleted[1] != "app/a" {
+		t.Errorf(`wrong BeforeDelete calls: %v`, deleted)
+	}
+}
@@ -0,0 +1,248 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var NoIndexErr = errors.New(`no index registered with the given name`)
+
+// Extractor derives the value to be indexed from a key and its value. It returns false if the key should not
+// be indexed. Indexed values must be of a type that sqlite can store, such as integers, floats, strings, and
+// booleans.
+type Extractor func(key string, value any) (any, bool)
+
+// secondaryIndexes holds the extractors registered in this process.
+type secondaryIndexes struct {
+	mutex      sync.RWMutex
+	extractors map[string]Extractor
+}
+
+// RegisterIndex registers a secondary index with the given name whose entries are derived from keys and their
+// values by the extractor, and builds the index for all existing keys. The default is indexed for keys that
+// have no value. Indexes are kept up to date for all changes, but only indexes registered in this process can
+// be queried with QueryIndex and QueryIndexRange. Registering an index with the same name again replaces it.
+func (db *KVStore) RegisterIndex(name string, extract Extractor) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := trackIndexes(tx); err != nil {
+		return err
+	}
+	if err := db.buildIndex(tx, name, extract); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	db.indexes.mutex.Lock()
+	defer db.indexes.mutex.Unlock()
+	if db.indexes.extractors == nil {
+		db.indexes.extractors = make(map[string]Extractor)
+	}
+	db.indexes.extractors[name] = extract
+	return nil
+}
+
+// initIndexes stops tracking changed keys when the store is opened, since no index is registered yet. The
+// tracking is resumed by RegisterIndex, so that kv_index_dirty does not grow in processes without indexes.
+func initIndexes(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+DROP TRIGGER IF EXISTS kv_index_insert;
+DROP TRIGGER IF EXISTS kv_index_update;
+DROP TABLE IF EXISTS kv_index_dirty;
+`)
+	return err
+}
+
+// trackIndexes creates the index table and the triggers recording the keys changed since the last update of the
+// indexes in kv_index_dirty.
+func trackIndexes(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_index(
+  name TEXT NOT NULL,
+  key TEXT NOT NULL,
+  value,
+  PRIMARY KEY(name,key)
+);
+CREATE INDEX IF NOT EXISTS kv_index_value ON kv_index(name,value);
+CREATE TABLE IF NOT EXISTS kv_index_dirty(key TEXT PRIMARY KEY NOT NULL);
+CREATE TRIGGER IF NOT EXISTS kv_index_insert AFTER INSERT ON kv BEGIN
+  INSERT INTO kv_index_dirty(key) SELECT new.key WHERE NOT EXISTS(SELECT 1 FROM kv_index_dirty WHERE key=new.key);
+END;
+CREATE TRIGGER IF NOT EXISTS kv_index_update AFTER UPDATE ON kv BEGIN
+  INSERT INTO kv_index_dirty(key) SELECT old.key WHERE NOT EXISTS(SELECT 1 FROM kv_index_dirty WHERE key=old.key);
+  INSERT INTO kv_index_dirty(key) SELECT new.key WHERE NOT EXISTS(SELECT 1 FROM kv_index_dirty WHERE key=new.key);
+END;
+CREATE TRIGGER IF NOT EXISTS kv_index_delete AFTER DELETE ON kv BEGIN
+  DELETE FROM kv_index WHERE key=old.key;
+END;
+`)
+	return err
+}
+
+// buildIndex replaces the entries of the named index with entries for all existing keys.
+func (db *KVStore) buildIndex(tx *sqlx.Tx, name string, extract Extractor) error {
+	if _, err := tx.Exec(`DELETE FROM kv_index WHERE name=?;`, name); err != nil {
+		return err
+	}
+	rows, err := tx.Queryx(`SELECT key,` + db.valueColumns() + ` FROM kv;`)
+	if err != nil {
+		return err
+	}
+	entries := make(map[string]any)
+	for rows.Next() {
+		var key string
+		var sv storedValue
+		if err := rows.Scan(append([]any{&key}, sv.dest()...)...); err != nil {
+			rows.Close()
+			return err
+		}
+		v, ok, err := sv.decode()
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if indexed, ok := extract(key, v); ok {
+			entries[key] = indexed
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for key, indexed := range entries {
+		if _, err := tx.Exec(`INSERT INTO kv_index(name,key,value) VALUES(?,?,?);`, name, key, indexed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueryIndex returns all keys in ascending order whose indexed value in the given index equals value.
+func (db *KVStore) QueryIndex(name string, value any) ([]string, error) {
+	return db.queryIndex(name, `value=?`, value)
+}
+
+// QueryIndexRange returns all keys in ascending order whose indexed value in the given index is between min
+// and max, inclusively.
+func (db *KVStore) QueryIndexRange(name string, min, max any) ([]string, error) {
+	return db.queryIndex(name, `value BETWEEN ? AND ?`, min, max)
+}
+
+// queryIndex updates the secondary indexes and returns the keys matching the condition in the given index.
+func (db *KVStore) queryIndex(name, cond string, args ...any) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	db.indexes.mutex.RLock()
+	_, ok := db.indexes.extractors[name]
+	db.indexes.mutex.RUnlock()
+	if !ok {
+		return nil, NoIndexErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	if err := db.updateIndexes(); err != nil {
+		return nil, err
+	}
+	return db.liveKeys(`kv.key IN (SELECT key FROM kv_index WHERE name=? AND `+cond+`)`, `ORDER BY kv.key ASC`,
+		append([]any{name}, args...)...)
+}
+
+// updateIndexes recomputes the entries of all registered indexes for keys changed since the last update. Entries
+// of indexes not registered in this process are left alone, since they cannot be recomputed without their
+// extractors. If another process has stopped tracking changed keys by opening the store, the registered indexes
+// are rebuilt and the tracking is resumed.
+func (db *KVStore) updateIndexes() error {
+	db.indexes.mutex.RLock()
+	defer db.indexes.mutex.RUnlock()
+	names := make([]string, 0, len(db.indexes.extractors))
+	for name := range db.indexes.extractors {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	deleteQuery, deleteArgs, err := sqlx.In(`DELETE FROM kv_index WHERE name IN (?) AND key=?;`, names, "")
+	if err != nil {
+		return err
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var tracked bool
+	if err := tx.Get(&tracked, `SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type='trigger' AND name='kv_index_update');`); err != nil {
+		return err
+	}
+	if !tracked {
+		if err := trackIndexes(tx); err != nil {
+			return err
+		}
+		for name, extract := range db.indexes.extractors {
+			if err := db.buildIndex(tx, name, extract); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	}
+	var dirty []string
+	if err := tx.Select(&dirty, `SELECT key FROM kv_index_dirty;`); err != nil {
+		return err
+	}
+	if len(dirty) == 0 {
+		return nil
+	}
+	for _, key := range dirty {
+		deleteArgs[len(deleteArgs)-1] = key
+		if _, err := tx.Exec(deleteQuery, deleteArgs...); err != nil {
+			return err
+		}
+		var sv storedValue
+		err := tx.QueryRowx(`SELECT `+db.valueColumns()+` FROM kv WHERE key=?;`, key).Scan(sv.dest()...)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		v, ok, err := sv.decode()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		for name, extract := range db.indexes.extractors {
+			indexed, ok := extract(key, v)
+			if !ok {
+				continue
+			}
+			if _, err := tx.Exec(`INSERT INTO kv_index(name,key,value) VALUES(?,?,?);`, name, key, indexed); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM kv_index_dirty;`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,102 @@
+package kvstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSecondaryIndex(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.SetMany(map[string]any{"user.1": "alice", "user.2": "bob", "user.3": "carol", "other": 42}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	err := db.RegisterIndex("length", func(key string, value any) (any, bool) {
+		s, ok := value.(string)
+		return len(s), ok
+	})
+	if err != nil {
+		t.Fatalf(`failed to register index: %v`, err)
+	}
+	keys, err := db.QueryIndex("length", 5)
+	if err != nil {
+		t.Fatalf(`failed to query index: %v`, err)
+	}
+	if strings.Join(keys, ",") != "user.1,user.3" {
+		t.Errorf(`wrong keys for index query: %v`, keys)
+	}
+	if err := db.Set("user.2", "robert"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Delete("user.3"); err != nil {
+		t.Fatalf(`failed to delete key: %v`, err)
+	}
+	if err := db.SetDefault("user.4", "dave", KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	keys, _ = db.QueryIndexRange("length", 4, 6)
+	if strings.Join(keys, ",") != "user.1,user.2,user.4" {
+		t.Errorf(`index was not updated, got %v`, keys)
+	}
+	if _, err := db.QueryIndex("unknown", 1); !errors.Is(err, NoIndexErr) {
+		t.Errorf(`expected NoIndexErr, got %v`, err)
+	}
+}
+
+func TestUnregisteredIndexKept(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.Set("user.1", "alice"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	for _, name := range []string{"length", "upper"} {
+		if err := db.RegisterIndex(name, func(key string, value any) (any, bool) { return value, true }); err != nil {
+			t.Fatalf(`failed to register index: %v`, err)
+		}
+	}
+	// the upper index is only registered in another process from here on
+	delete(db.indexes.extractors, "upper")
+	if err := db.Set("user.1", "alicia"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if keys, err := db.QueryIndex("length", "alicia"); err != nil || len(keys) != 1 {
+		t.Errorf(`expected updated index entry, got %v, %v`, keys, err)
+	}
+	var n int
+	if err := db.sqx.Get(&n, `SELECT COUNT(*) FROM kv_index WHERE name='upper';`); err != nil || n != 1 {
+		t.Errorf(`expected entry of unregistered index to be kept, got %v, %v`, n, err)
+	}
+}
+
+func TestIndexTracking(t *testing.T) {
+	dir := t.TempDir()
+	db := New()
+	if err := db.Open(dir); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	if err := db.RegisterIndex("value", func(key string, value any) (any, bool) { return value, true }); err != nil {
+		t.Fatalf(`failed to register index: %v`, err)
+	}
+	// another process opening the store without registering indexes stops tracking changed keys
+	other := New()
+	if err := other.Open(dir); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer other.Close()
+	if err := other.Set("a", "x"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	var n int
+	if err := other.sqx.Get(&n, `SELECT COUNT(*) FROM sqlite_master WHERE name='kv_index_dirty';`); err != nil || n != 0 {
+		t.Errorf(`expected no tracking of changed keys without indexes, got %v, %v`, n, err)
+	}
+	if keys, err := db.QueryIndex("value", "x"); err != nil || len(keys) != 1 || keys[0] != "a" {
+		t.Errorf(`expected index to be rebuilt, got %v, %v`, keys, err)
+	}
+	if err := other.Set("b", "x"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if keys, err := db.QueryIndex("value", "x"); err != nil || len(keys) != 2 {
+		t.Errorf(`expected tracking to be resumed, got %v, %v`, keys, err)
+	}
+}
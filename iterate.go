@@ -0,0 +1,126 @@
+package kvstore
+
+import (
+	"sync/atomic"
+)
+
+// ForEach calls fn for each key in ascending order with its value, or its default if no value is set. Unlike
+// GetAll, rows are decoded one at a time so memory usage stays bounded for large stores. Iteration stops
+// as soon as fn returns an error, which is then returned by ForEach.
+func (db *KVStore) ForEach(fn func(key string, value any) error) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	redacted, err := db.redactedKeys()
+	if err != nil {
+		return err
+	}
+	var sliding []string
+	defer func() { db.recordAccess(sliding...) }() // after the rows have been closed
+	rows, err := db.queryLive(db.sqx, `1`, `ORDER BY kv.key ASC`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		key, sv, slides, err := scanLive(rows)
+		if err != nil {
+			return err
+		}
+		v, ok, err := sv.decode()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if slides {
+			sliding = append(sliding, key)
+		}
+		if redacted[key] {
+			v = Redacted
+		}
+		if err := fn(key, v); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Order specifies the order of key-value pairs returned by GetPage.
+type Order int
+
+const (
+	OrderKeyAsc       Order = iota // ascending by key
+	OrderKeyDesc                   // descending by key
+	OrderCategoryAsc               // ascending by category, then ascending by key
+	OrderCategoryDesc              // descending by category, then ascending by key
+)
+
+// orderBy returns the SQL ORDER BY clause for the order.
+func (o Order) orderBy() string {
+	switch o {
+	case OrderKeyDesc:
+		return `ORDER BY kv.key DESC`
+	case OrderCategoryAsc:
+		return `ORDER BY kv.category ASC, kv.key ASC`
+	case OrderCategoryDesc:
+		return `ORDER BY kv.category DESC, kv.key ASC`
+	}
+	return `ORDER BY kv.key ASC`
+}
+
+// Pair is a key together with its value.
+type Pair struct {
+	Key   string
+	Value any
+}
+
+// GetPage returns at most limit key-value pairs in the given order, skipping the first offset pairs. If limit is 0
+// or negative, all remaining pairs are returned. As with Get, the default is returned for keys without value.
+// Keys without category come first in ascending category order.
+func (db *KVStore) GetPage(limit, offset int, order Order) ([]Pair, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	redacted, err := db.redactedKeys()
+	if err != nil {
+		return nil, err
+	}
+	var sliding []string
+	defer func() { db.recordAccess(sliding...) }() // after the rows have been closed
+	rows, err := db.queryLive(db.sqx, `value IS NOT NULL OR original IS NOT NULL`, order.orderBy()+` LIMIT ? OFFSET ?`,
+		limit, max(offset, 0))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	page := make([]Pair, 0)
+	for rows.Next() {
+		key, sv, slides, err := scanLive(rows)
+		if err != nil {
+			return page, err
+		}
+		v, _, err := sv.decode()
+		if err != nil {
+			return page, err
+		}
+		if slides {
+			sliding = append(sliding, key)
+		}
+		if redacted[key] {
+			v = Redacted
+		}
+		page = append(page, Pair{Key: key, Value: v})
+	}
+	return page, rows.Err()
+}
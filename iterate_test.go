@@ -0,0 +1,77 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.SetMany(map[string]any{"a": 1, "b": 2, "c": 3}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	if err := db.SetDefault("d", 4, KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	var keys []string
+	sum := 0
+	err := db.ForEach(func(key string, value any) error {
+		keys = append(keys, key)
+		sum += value.(int)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf(`for each failed: %v`, err)
+	}
+	if len(keys) != 4 || keys[0] != "a" || keys[3] != "d" || sum != 10 {
+		t.Errorf(`wrong iteration, got keys %v and sum %v`, keys, sum)
+	}
+	stop := errors.New("stop")
+	n := 0
+	err = db.ForEach(func(key string, value any) error {
+		n++
+		if key == "b" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || n != 2 {
+		t.Errorf(`expected early exit after 2 keys with callback error, got %v after %v keys`, err, n)
+	}
+}
+
+func TestGetPage(t *testing.T) {
+	db := openTestStore(t)
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		category := "second"
+		if i%2 == 0 {
+			category = "first"
+		}
+		if err := db.SetDefault(key, i, KeyInfo{Category: category}); err != nil {
+			t.Fatalf(`failed to set default: %v`, err)
+		}
+	}
+	page, err := db.GetPage(2, 1, OrderKeyAsc)
+	if err != nil {
+		t.Fatalf(`failed to get page: %v`, err)
+	}
+	if len(page) != 2 || page[0] != (Pair{"b", 1}) || page[1] != (Pair{"c", 2}) {
+		t.Errorf(`wrong ascending page: %v`, page)
+	}
+	page, _ = db.GetPage(2, 0, OrderKeyDesc)
+	if len(page) != 2 || page[0].Key != "e" || page[1].Key != "d" {
+		t.Errorf(`wrong descending page: %v`, page)
+	}
+	page, _ = db.GetPage(0, 2, OrderCategoryAsc)
+	keys := ""
+	for _, p := range page {
+		keys += p.Key
+	}
+	if keys != "ebd" {
+		t.Errorf(`wrong page ordered by category, got keys %v`, keys)
+	}
+	page, _ = db.GetPage(1, 0, OrderCategoryDesc)
+	if len(page) != 1 || page[0].Key != "b" {
+		t.Errorf(`wrong page ordered by descending category: %v`, page)
+	}
+}
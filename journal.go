@@ -0,0 +1,133 @@
+package kvstore
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+var NothingToUndoErr = errors.New(`nothing to undo`)
+var NothingToRedoErr = errors.New(`nothing to redo`)
+
+// Journal enables a change journal holding up to depth of the most recent mutations, which can be reverted
+// with Undo and reapplied with Redo. Changes made by one operation such as SetMany or DeleteByCategory form
+// a single mutation. The journal is kept in memory and only covers values; defaults and key info removed by
+// Delete are not restored by Undo.
+func Journal(depth int) Option {
+	return func(o *options) {
+		o.journalDepth = depth
+	}
+}
+
+// journal holds the undo and redo stacks of change groups.
+type journal struct {
+	mutex sync.Mutex
+	undo  [][]change
+	redo  [][]change
+}
+
+// record adds a group of changes to the undo stack and clears the redo stack.
+func (db *KVStore) record(changes []change) {
+	if db.opts.journalDepth <= 0 || len(changes) == 0 {
+		return
+	}
+	j := &db.journal
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.undo = append(j.undo, slices.Clone(changes))
+	if len(j.undo) > db.opts.journalDepth {
+		j.undo = j.undo[len(j.undo)-db.opts.journalDepth:]
+	}
+	j.redo = nil
+}
+
+// CanUndo returns true if there is a mutation that can be reverted with Undo.
+func (db *KVStore) CanUndo() bool {
+	db.journal.mutex.Lock()
+	defer db.journal.mutex.Unlock()
+	return len(db.journal.undo) > 0
+}
+
+// CanRedo returns true if there is a mutation that can be reapplied with Redo.
+func (db *KVStore) CanRedo() bool {
+	db.journal.mutex.Lock()
+	defer db.journal.mutex.Unlock()
+	return len(db.journal.redo) > 0
+}
+
+// Undo reverts the most recent mutation in the journal in one transaction, NothingToUndoErr if there is none.
+func (db *KVStore) Undo() error {
+	return db.replay(&db.journal.undo, &db.journal.redo, true, NothingToUndoErr)
+}
+
+// Redo reapplies the most recently reverted mutation in one transaction, NothingToRedoErr if there is none.
+func (db *KVStore) Redo() error {
+	return db.replay(&db.journal.redo, &db.journal.undo, false, NothingToRedoErr)
+}
+
+// replay pops a group of changes from one stack, applies their old values if undo is true and their new values
+// otherwise, and pushes the group onto the other stack.
+func (db *KVStore) replay(from, to *[][]change, undo bool, emptyErr error) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	j := &db.journal
+	j.mutex.Lock()
+	if len(*from) == 0 {
+		j.mutex.Unlock()
+		return emptyErr
+	}
+	group := (*from)[len(*from)-1]
+	applied := make([]change, 0, len(group))
+	for i := range group {
+		c := group[i]
+		if undo {
+			c = group[len(group)-1-i]
+			c.old, c.new = c.new, c.old
+		}
+		applied = append(applied, c)
+	}
+	if err := db.apply(applied); err != nil {
+		j.mutex.Unlock()
+		return err
+	}
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, group)
+	j.mutex.Unlock()
+	// listeners are called after unlocking the journal, since they may change the store themselves
+	db.dispatch(applied...)
+	return db.replicate(applied...)
+}
+
+// apply sets the keys of the given changes to their new values in one transaction, deleting keys whose new
+// value is nil. Constraints and locks are not checked, since the values have been stored before.
+func (db *KVStore) apply(changes []change) error {
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	keys := make([]string, 0, len(changes))
+	for _, c := range changes {
+		keys = append(keys, c.key)
+		if c.new == nil {
+			if _, err := tx.Exec(`DELETE FROM kv WHERE key=?;`, c.key); err != nil {
+				return err
+			}
+			continue
+		}
+		b, err := MarshalBinary(c.new)
+		if err != nil {
+			return err
+		}
+		if err := db.put(tx, c.key, b, typeName(c.new)); err != nil {
+			return err
+		}
+	}
+	defer db.cache.remove(keys...)
+	return tx.Commit()
+}
@@ -0,0 +1,62 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUndoRedo(t *testing.T) {
+	db := New(Journal(10))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	if err := db.Undo(); !errors.Is(err, NothingToUndoErr) {
+		t.Errorf(`expected NothingToUndoErr, got %v`, err)
+	}
+	if err := db.Set("a", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.SetMany(map[string]any{"a": 2, "b": 3}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	if err := db.Undo(); err != nil {
+		t.Fatalf(`failed to undo: %v`, err)
+	}
+	if v, _ := db.Get("a"); v != 1 {
+		t.Errorf(`expected 1 after undo, got %v`, v)
+	}
+	if ok, _ := db.Has("b"); ok {
+		t.Errorf(`expected b to be removed by undo`)
+	}
+	if !db.CanRedo() {
+		t.Fatalf(`expected redo to be possible`)
+	}
+	if err := db.Redo(); err != nil {
+		t.Fatalf(`failed to redo: %v`, err)
+	}
+	all, _ := db.GetAll(0)
+	if len(all) != 2 || all["a"] != 2 || all["b"] != 3 {
+		t.Errorf(`unexpected values after redo: %v`, all)
+	}
+	if err := db.Redo(); !errors.Is(err, NothingToRedoErr) {
+		t.Errorf(`expected NothingToRedoErr, got %v`, err)
+	}
+	if err := db.Undo(); err != nil {
+		t.Fatalf(`failed to undo: %v`, err)
+	}
+	if err := db.Delete("a"); err != nil {
+		t.Fatalf(`failed to delete key: %v`, err)
+	}
+	if db.CanRedo() {
+		t.Errorf(`expected a new mutation to clear the redo stack`)
+	}
+	for range 2 {
+		if err := db.Undo(); err != nil {
+			t.Fatalf(`failed to undo: %v`, err)
+		}
+	}
+	if ok, _ := db.Has("a"); ok || db.CanUndo() {
+		t.Errorf(`expected all mutations to be undone`)
+	}
+}
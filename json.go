@@ -0,0 +1,115 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SetJSON sets the value for the given key to the JSON encoding of value. Unlike gob encoded values set with
+// Set, JSON values are stored as text, so they can be queried with QueryJSON and sqlite's JSON functions
+// and read by tools not written in Go. Get returns JSON values decoded into the types encoding/json uses
+// for interface values, e.g. map[string]any for objects and float64 for numbers.
+func (db *KVStore) SetJSON(key string, value any) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	if err := db.checkConstraints(db.sqx, key, value); err != nil {
+		return err
+	}
+	if err := db.encryptedCategory(db.sqx, key); err != nil {
+		return err
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	notify := db.hasListeners()
+	var old any
+	if notify {
+		old = db.current(db.sqx, key)
+	}
+	defer db.cache.remove(key)
+	err = db.inTx(func(tx *sqlx.Tx) error {
+		if err := db.checkQuota(tx, key, len(b)); err != nil {
+			return err
+		}
+		now := db.now().UnixMilli()
+		_, err := tx.Exec(`INSERT INTO kv(key,value,codec,value_type,value_sum,created_at,updated_at) VALUES(?,?,?,?,?,?,?)
+ON CONFLICT(key) DO UPDATE SET value=excluded.value,codec=excluded.codec,value_type=excluded.value_type,value_sum=excluded.value_sum,
+external=NULL,value_ref=NULL,updated_at=CASE WHEN value IS excluded.value THEN updated_at ELSE excluded.updated_at END;`,
+			key, string(b), codecJSON, nullString(typeName(value)), checksum(b), now, now)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if notify {
+		if err := db.notify(change{key: key, old: old, new: value}); err != nil {
+			return err
+		}
+	}
+	return db.evict(key)
+}
+
+// GetJSON decodes the value for the given key, or the default if no value is set, into the value pointed
+// to by dest using encoding/json. Values that have not been set with SetJSON are converted to JSON first.
+func (db *KVStore) GetJSON(key string, dest any) error {
+	sliding, err := db.getJSON(key, dest)
+	switch {
+	case err != nil:
+	case sliding:
+		db.recordAccess(key)
+	default:
+		db.touch(key)
+	}
+	return err
+}
+
+// getJSON decodes the value for key into dest without recording the access, and returns whether reading the key
+// extends its lifetime.
+func (db *KVStore) getJSON(key string, dest any) (bool, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return false, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return false, err
+	}
+	sv, x, err := db.readValue(db.sqx, key)
+	if err != nil {
+		return false, err
+	}
+	if sv.value != nil && sv.codec.String == codecJSON {
+		return x.sliding, json.Unmarshal(sv.value, dest)
+	}
+	v, ok, err := sv.decode()
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return false, NotFoundErr
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return false, err
+	}
+	return x.sliding, json.Unmarshal(b, dest)
+}
+
+// QueryJSON returns all keys in ascending order whose values have been set with SetJSON and contain the given
+// value at the given JSON path, for example QueryJSON("$.enabled", true). The path uses sqlite's JSON path
+// syntax. Only values of basic types can be compared.
+func (db *KVStore) QueryJSON(path string, value any) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	return db.liveKeys(`kv.codec=? AND json_valid(kv.value) AND json_extract(kv.value,?)=?`, `ORDER BY kv.key ASC`,
+		codecJSON, path, value)
+}
@@ -0,0 +1,62 @@
+package kvstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testPlugin struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Level   int    `json:"level"`
+}
+
+func TestJSON(t *testing.T) {
+	db := openTestStore(t)
+	plugins := map[string]testPlugin{
+		"plugin.spell": {"spell", true, 1},
+		"plugin.sync":  {"sync", false, 2},
+		"plugin.lint":  {"lint", true, 2},
+	}
+	for k, p := range plugins {
+		if err := db.SetJSON(k, p); err != nil {
+			t.Fatalf(`failed to set JSON value: %v`, err)
+		}
+	}
+	var p testPlugin
+	if err := db.GetJSON("plugin.sync", &p); err != nil || p != plugins["plugin.sync"] {
+		t.Errorf(`expected %v, got %v, %v`, plugins["plugin.sync"], p, err)
+	}
+	v, err := db.Get("plugin.spell")
+	if err != nil {
+		t.Fatalf(`failed to get JSON value: %v`, err)
+	}
+	if m, ok := v.(map[string]any); !ok || m["name"] != "spell" || m["level"] != 1.0 {
+		t.Errorf(`unexpected decoded JSON value: %v`, v)
+	}
+	keys, err := db.QueryJSON("$.enabled", true)
+	if err != nil {
+		t.Fatalf(`failed to query JSON: %v`, err)
+	}
+	if strings.Join(keys, ",") != "plugin.lint,plugin.spell" {
+		t.Errorf(`wrong keys for JSON query: %v`, keys)
+	}
+	keys, _ = db.QueryJSON("$.name", "sync")
+	if strings.Join(keys, ",") != "plugin.sync" {
+		t.Errorf(`wrong keys for JSON query: %v`, keys)
+	}
+	if err := db.Set("plugin.sync", "disabled"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if v, _ := db.Get("plugin.sync"); v != "disabled" {
+		t.Errorf(`expected gob value after Set, got %v`, v)
+	}
+	var s string
+	if err := db.GetJSON("plugin.sync", &s); err != nil || s != "disabled" {
+		t.Errorf(`expected gob value converted to JSON, got %v, %v`, s, err)
+	}
+	if err := db.GetJSON("missing", &s); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+}
@@ -0,0 +1,63 @@
+package kvstore
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// KeySeparator separates the parts of composite keys created with JoinKey.
+const KeySeparator = '/'
+
+// keyEscape escapes separators and itself within parts of composite keys.
+const keyEscape = '\\'
+
+// BytesKey returns a key for a binary ID. The bytes are hex encoded, so keys created from byte slices
+// sort in the same order as the byte slices.
+func BytesKey(b []byte) string {
+	return hex.EncodeToString(b)
+}
+
+// KeyBytes returns the byte slice a key has been created from with BytesKey.
+func KeyBytes(key string) ([]byte, error) {
+	return hex.DecodeString(key)
+}
+
+// JoinKey returns a composite key consisting of the given parts separated by KeySeparator. Separators and
+// backslashes within parts are escaped with a backslash, so SplitKey returns the original parts.
+func JoinKey(parts ...string) string {
+	var sb strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			sb.WriteRune(KeySeparator)
+		}
+		for _, r := range part {
+			if r == KeySeparator || r == keyEscape {
+				sb.WriteRune(keyEscape)
+			}
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// SplitKey splits a composite key created with JoinKey into its parts.
+func SplitKey(key string) []string {
+	parts := make([]string, 0, strings.Count(key, string(KeySeparator))+1)
+	var sb strings.Builder
+	escaped := false
+	for _, r := range key {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == keyEscape:
+			escaped = true
+		case r == KeySeparator:
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return append(parts, sb.String())
+}
@@ -0,0 +1,41 @@
+package kvstore
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+func TestCompositeKeys(t *testing.T) {
+	tests := [][]string{
+		{"user", "42", "name"},
+		{"a/b", `c\d`, ""},
+		{""},
+		{`\/`, "/"},
+	}
+	for _, parts := range tests {
+		key := JoinKey(parts...)
+		if split := SplitKey(key); !slices.Equal(split, parts) {
+			t.Errorf(`expected %q, got %q for key %q`, parts, split, key)
+		}
+	}
+	if key := JoinKey("user", "42"); key != "user/42" {
+		t.Errorf(`unexpected composite key %q`, key)
+	}
+	id := []byte{0, 1, 0xfe, 0xff}
+	b, err := KeyBytes(BytesKey(id))
+	if err != nil || !bytes.Equal(b, id) {
+		t.Errorf(`binary key did not round-trip: %v, %v`, b, err)
+	}
+	if BytesKey([]byte{1, 0}) > BytesKey([]byte{1, 1}) {
+		t.Errorf(`binary keys do not preserve order`)
+	}
+	db := openTestStore(t)
+	key := JoinKey(BytesKey(id), "a/b")
+	if err := db.Set(key, 1); err != nil {
+		t.Fatalf(`failed to set composite key: %v`, err)
+	}
+	if v, err := db.Get(key); v != 1 || err != nil {
+		t.Errorf(`failed to get composite key: %v, %v`, v, err)
+	}
+}
@@ -0,0 +1,46 @@
+package kvstore
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Lists, sets, hashes, and streamed values are stored in tables of their own and share the namespace of keys with
+// ordinary values and defaults, but a key can only hold one kind of value at a time: writing a kind of value to a
+// key that holds another kind fails with KeyExistsErr. Keys holding lists, sets, hashes, or streamed values are not
+// visible to the methods for ordinary keys such as Has, Get, Delete, GetAll, Rename, and the TTL methods, and are
+// removed with the methods of their kind.
+
+// keyKinds are the kinds of values a key can hold together with the condition selecting the keys of the kind.
+var keyKinds = []struct{ name, cond string }{
+	{"value", `SELECT key FROM kv WHERE key=? AND (value IS NOT NULL OR original IS NOT NULL)`},
+	{"list", `SELECT key FROM kv_list WHERE key=?`},
+	{"set", `SELECT key FROM kv_set WHERE key=?`},
+	{"hash", `SELECT key FROM kv_hash WHERE key=?`},
+	{"stream", `SELECT key FROM kv_stream WHERE key=?`},
+}
+
+// checkKind returns KeyExistsErr if the key holds a kind of value other than the given kind.
+func checkKind(q sqlx.Queryer, key, kind string) error {
+	var query string
+	var args []any
+	for _, k := range keyKinds {
+		if k.name == kind {
+			continue
+		}
+		if query != "" {
+			query += ` UNION ALL `
+		}
+		query += `SELECT '` + k.name + `' FROM (` + k.cond + ` LIMIT 1)`
+		args = append(args, key)
+	}
+	var other []string
+	if err := sqlx.Select(q, &other, query+` LIMIT 1;`, args...); err != nil {
+		return err
+	}
+	if len(other) > 0 {
+		return fmt.Errorf("%w: %v holds a %s", KeyExistsErr, key, other[0])
+	}
+	return nil
+}
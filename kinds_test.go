@@ -0,0 +1,60 @@
+package kvstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyKinds(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.Set("value", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.SetDefault("default", 1, KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.ListPush("list", 1); err != nil {
+		t.Fatalf(`failed to push to list: %v`, err)
+	}
+	if _, err := db.SAdd("set", 1); err != nil {
+		t.Fatalf(`failed to add to set: %v`, err)
+	}
+	if err := db.HSet("hash", "field", 1); err != nil {
+		t.Fatalf(`failed to set hash field: %v`, err)
+	}
+	if err := db.SetReader("stream", strings.NewReader("data")); err != nil {
+		t.Fatalf(`failed to set streamed value: %v`, err)
+	}
+	writes := map[string]func(key string) error{
+		"Set":        func(key string) error { return db.Set(key, 2) },
+		"SetDefault": func(key string) error { return db.SetDefault(key, 2, KeyInfo{}) },
+		"ListPush":   func(key string) error { return db.ListPush(key, 2) },
+		"SAdd":       func(key string) error { _, err := db.SAdd(key, 2); return err },
+		"HSet":       func(key string) error { return db.HSet(key, "field", 2) },
+		"SetReader":  func(key string) error { return db.SetReader(key, strings.NewReader("more")) },
+	}
+	kinds := map[string]string{"Set": "value", "SetDefault": "value", "ListPush": "list", "SAdd": "set",
+		"HSet": "hash", "SetReader": "stream"}
+	for name, write := range writes {
+		for _, key := range []string{"value", "default", "list", "set", "hash", "stream"} {
+			err := write(key)
+			same := kinds[name] == key || kinds[name] == "value" && key == "default"
+			if same && err != nil {
+				t.Errorf(`%s(%q) failed: %v`, name, key, err)
+			}
+			if !same && !errors.Is(err, KeyExistsErr) {
+				t.Errorf(`expected KeyExistsErr from %s(%q), got %v`, name, key, err)
+			}
+		}
+	}
+	if err := db.Rename("value", "list", true); !errors.Is(err, KeyExistsErr) {
+		t.Errorf(`expected KeyExistsErr when renaming onto a list, got %v`, err)
+	}
+	if err := db.ListTrim("list", 0); err != nil {
+		t.Fatalf(`failed to remove list: %v`, err)
+	}
+	if err := db.Set("list", 3); err != nil {
+		t.Errorf(`expected key of removed list to be usable, got %v`, err)
+	}
+}
@@ -0,0 +1,136 @@
+// Package koanfkv exposes a key value store as a provider for the configuration library koanf
+// (github.com/knadh/koanf), so that applications using koanf can source values from a kvstore and persist
+// them back into it. The provider implements koanf's Provider interface structurally, so this package does
+// not depend on koanf. Use it with a nil parser:
+//
+//	k := koanf.New(".")
+//	p := koanfkv.New(store, ".")
+//	err := k.Load(p, nil)
+//	...
+//	err = p.Save(k.All())
+package koanfkv
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rasteric/kvstore"
+)
+
+var UnsupportedErr = errors.New(`operation is not supported by the kvstore provider`)
+var KeyConflictErr = errors.New(`key is both a value and a prefix of other keys`)
+
+// Provider reads the key-value pairs of a store for koanf and writes values back into it.
+type Provider struct {
+	store kvstore.KeyValueStore
+	delim string
+
+	mutex    sync.Mutex
+	watching bool
+	listener kvstore.ListenerID
+}
+
+// New returns a provider for the store. Keys are split into nested maps at delim, which should be koanf's key
+// delimiter; keys are used as they are if delim is empty.
+func New(store kvstore.KeyValueStore, delim string) *Provider {
+	return &Provider{store: store, delim: delim}
+}
+
+// ReadBytes returns UnsupportedErr, since the store holds typed values rather than an encoded document.
+func (p *Provider) ReadBytes() ([]byte, error) {
+	return nil, UnsupportedErr
+}
+
+// Read returns all key-value pairs of the store, including defaults, as a map nested at the delimiter. An error
+// wrapping KeyConflictErr is returned if a key holding a value is also a prefix of another key.
+func (p *Provider) Read() (map[string]any, error) {
+	pairs, err := p.store.GetAll(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.delim == "" {
+		return pairs, nil
+	}
+	result := make(map[string]any)
+	for _, key := range slices.Sorted(maps.Keys(pairs)) {
+		parts := strings.Split(key, p.delim)
+		m := result
+		for _, part := range parts[:len(parts)-1] {
+			switch child := m[part].(type) {
+			case nil:
+				next := make(map[string]any)
+				m[part] = next
+				m = next
+			case map[string]any:
+				m = child
+			default:
+				return nil, fmt.Errorf("%w: %v", KeyConflictErr, key)
+			}
+		}
+		last := parts[len(parts)-1]
+		if _, ok := m[last]; ok {
+			return nil, fmt.Errorf("%w: %v", KeyConflictErr, key)
+		}
+		m[last] = pairs[key]
+	}
+	return result, nil
+}
+
+// Save writes the values, for example the result of koanf's All or Raw methods, into the store in one
+// transaction. Nested maps are flattened into keys joined with the delimiter.
+func (p *Provider) Save(values map[string]any) error {
+	pairs := make(map[string]any)
+	p.flatten(pairs, "", values)
+	return p.store.SetMany(pairs)
+}
+
+// flatten adds the values to pairs with keys prefixed by prefix.
+func (p *Provider) flatten(pairs map[string]any, prefix string, values map[string]any) {
+	for k, v := range values {
+		if prefix != "" {
+			k = prefix + p.delim + k
+		}
+		if m, ok := v.(map[string]any); ok && p.delim != "" {
+			p.flatten(pairs, k, m)
+			continue
+		}
+		pairs[k] = v
+	}
+}
+
+// Watch calls cb with a nil event and error whenever a key of the store changes, so that koanf can reload
+// the configuration. It returns UnsupportedErr unless the store is a *kvstore.KVStore. Only one callback can
+// be watching at a time; it is replaced by later calls.
+func (p *Provider) Watch(cb func(event any, err error)) error {
+	db, ok := p.store.(*kvstore.KVStore)
+	if !ok {
+		return UnsupportedErr
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.watching {
+		db.RemoveChangeListener(p.listener)
+	}
+	p.listener = db.OnChange(func(string, any, any) { cb(nil, nil) })
+	p.watching = true
+	return nil
+}
+
+// Unwatch stops calling the callback registered with Watch.
+func (p *Provider) Unwatch() error {
+	db, ok := p.store.(*kvstore.KVStore)
+	if !ok {
+		return UnsupportedErr
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.watching {
+		db.RemoveChangeListener(p.listener)
+		p.watching = false
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package koanfkv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rasteric/kvstore"
+)
+
+func TestProvider(t *testing.T) {
+	db := kvstore.New()
+	if err := db.Open(kvstore.InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer db.Close()
+	if err := db.Set("server.port", 8080); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDefault("server.host", "localhost", kvstore.KeyInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Set("debug", true); err != nil {
+		t.Fatal(err)
+	}
+	p := New(db, ".")
+	if _, err := p.ReadBytes(); !errors.Is(err, UnsupportedErr) {
+		t.Errorf(`expected UnsupportedErr, got %v`, err)
+	}
+	m, err := p.Read()
+	if err != nil {
+		t.Fatalf(`read: %v`, err)
+	}
+	server, ok := m["server"].(map[string]any)
+	if !ok || server["port"] != 8080 || server["host"] != "localhost" || m["debug"] != true {
+		t.Errorf(`unexpected map %v`, m)
+	}
+
+	changes := 0
+	if err := p.Watch(func(event any, err error) { changes++ }); err != nil {
+		t.Fatalf(`watch: %v`, err)
+	}
+	if err := p.Save(map[string]any{"server": map[string]any{"port": 9090}, "name": "app"}); err != nil {
+		t.Fatalf(`save: %v`, err)
+	}
+	if v, err := db.Get("server.port"); err != nil || v != 9090 {
+		t.Errorf(`expected saved value, got %v, %v`, v, err)
+	}
+	if v, err := db.Get("name"); err != nil || v != "app" {
+		t.Errorf(`expected saved value, got %v, %v`, v, err)
+	}
+	if changes == 0 {
+		t.Errorf(`expected watch callback to be called`)
+	}
+	if err := p.Unwatch(); err != nil {
+		t.Fatalf(`unwatch: %v`, err)
+	}
+
+	if err := db.Set("server", "conflict"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Read(); !errors.Is(err, KeyConflictErr) {
+		t.Errorf(`expected KeyConflictErr, got %v`, err)
+	}
+}
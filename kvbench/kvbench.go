@@ -0,0 +1,101 @@
+// Package kvbench provides standardized benchmark workloads that run against any implementation of
+// kvstore.KeyValueStore, so that backends and options affecting performance can be compared under the same
+// load. Call Run from a benchmark:
+//
+//	func BenchmarkStore(b *testing.B) {
+//		kvbench.Run(b, func(b *testing.B) kvstore.KeyValueStore {
+//			db := kvstore.New(kvstore.Cache(1000, 0))
+//			if err := db.Open(b.TempDir()); err != nil {
+//				b.Fatal(err)
+//			}
+//			return db
+//		})
+//	}
+//
+// and compare the results of several runs with a tool such as benchstat.
+package kvbench
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/rasteric/kvstore"
+)
+
+// Factory returns a new, empty store that has been opened. Run closes the store at the end of each benchmark.
+type Factory func(b *testing.B) kvstore.KeyValueStore
+
+// Workload describes a benchmark. The store is filled with Keys keys holding byte slices of ValueSize bytes
+// before the timer starts, and each operation then reads or overwrites a random key.
+type Workload struct {
+	Name      string
+	Keys      int     // the number of keys
+	ValueSize int     // the size of values in bytes
+	ReadRatio float64 // the fraction of operations that are reads, the others are writes
+}
+
+// The standard workloads run by Run if no workloads are given.
+var (
+	ReadHeavy   = Workload{Name: "ReadHeavy", Keys: 1000, ValueSize: 128, ReadRatio: 0.95}
+	WriteHeavy  = Workload{Name: "WriteHeavy", Keys: 1000, ValueSize: 128, ReadRatio: 0.05}
+	Mixed       = Workload{Name: "Mixed", Keys: 1000, ValueSize: 128, ReadRatio: 0.5}
+	LargeValues = Workload{Name: "LargeValues", Keys: 100, ValueSize: 256 << 10, ReadRatio: 0.5}
+)
+
+// Workloads returns the standard workloads.
+func Workloads() []Workload {
+	return []Workload{ReadHeavy, WriteHeavy, Mixed, LargeValues}
+}
+
+// Run runs the given workloads, or the standard workloads if none are given, as sub-benchmarks of b, each on a
+// new store returned by factory. Throughput is reported in bytes of values read or written per second.
+func Run(b *testing.B, factory Factory, workloads ...Workload) {
+	if len(workloads) == 0 {
+		workloads = Workloads()
+	}
+	for _, w := range workloads {
+		b.Run(w.Name, func(b *testing.B) {
+			s := factory(b)
+			defer s.Close()
+			w.run(b, s)
+		})
+	}
+}
+
+// run fills the store and runs the workload on it.
+func (w Workload) run(b *testing.B, s kvstore.KeyValueStore) {
+	keys := make([]string, max(w.Keys, 1))
+	rng := rand.New(rand.NewPCG(1, 2))
+	value := func() []byte {
+		v := make([]byte, w.ValueSize)
+		for i := range v {
+			v[i] = byte(rng.Uint32())
+		}
+		return v
+	}
+	batch := make(map[string]any)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("bench/%06d", i)
+		batch[keys[i]] = value()
+		if len(batch) == 100 || i == len(keys)-1 {
+			if err := s.SetMany(batch); err != nil {
+				b.Fatalf(`failed to fill store: %v`, err)
+			}
+			clear(batch)
+		}
+	}
+	values := [][]byte{value(), value()}
+	b.SetBytes(int64(w.ValueSize))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := keys[rng.IntN(len(keys))]
+		if rng.Float64() < w.ReadRatio {
+			if _, err := s.Get(key); err != nil {
+				b.Fatalf(`failed to get %q: %v`, key, err)
+			}
+		} else if err := s.Set(key, values[i%2]); err != nil {
+			b.Fatalf(`failed to set %q: %v`, key, err)
+		}
+	}
+}
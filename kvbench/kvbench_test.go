@@ -0,0 +1,41 @@
+package kvbench
+
+import (
+	"testing"
+
+	"github.com/rasteric/kvstore"
+	"github.com/rasteric/kvstore/testkv"
+)
+
+func BenchmarkKVStore(b *testing.B) {
+	Run(b, func(b *testing.B) kvstore.KeyValueStore {
+		db := kvstore.New()
+		if err := db.Open(b.TempDir()); err != nil {
+			b.Fatalf(`open: %v`, err)
+		}
+		return db
+	})
+}
+
+func TestRun(t *testing.T) {
+	small := Workload{Name: "Small", Keys: 10, ValueSize: 16, ReadRatio: 0.5}
+	var s *testkv.Store
+	result := testing.Benchmark(func(b *testing.B) {
+		Run(b, func(b *testing.B) kvstore.KeyValueStore {
+			s = testkv.New()
+			return s
+		}, small)
+	})
+	if result.N == 0 {
+		t.Fatalf(`benchmark did not run`)
+	}
+	if err := s.Open(""); err != nil {
+		t.Fatal(err)
+	}
+	all, err := s.GetAll(0)
+	if err != nil || len(all) != small.Keys {
+		t.Errorf(`expected %d keys, got %d, %v`, small.Keys, len(all), err)
+	}
+	s.AssertCalled(t, "Get", "")
+	s.AssertCalled(t, "Set", "")
+}
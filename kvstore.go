@@ -1,15 +1,18 @@
 package kvstore
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync/atomic"
+	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/ncruces/go-sqlite3/driver"
-	_ "github.com/ncruces/go-sqlite3/embed"
 )
 
 var NotFoundErr = errors.New(`key not found`)
@@ -17,6 +20,9 @@ var NotOpenErr = errors.New(`key value store is closed`)
 var AlreadyOpenErr = errors.New(`database already open`)
 var NoDefaultErr = errors.New(`no default value set for given key`)
 
+// maxBatchVariables is the maximum number of keys passed to a single query in batch operations.
+const maxBatchVariables = 500
+
 // KeyValueStore is the interface for a key value database.
 type KeyValueStore interface {
 	Open(path string) error                   // open the database at directory path
@@ -35,39 +41,120 @@ type KeyValueStore interface {
 }
 
 // KeyInfo is provides information about a key. This is useful for preference systems.
+// Apart from Description, Category and the embedded UIHints, all fields are optional constraints on the values
+// of the key. They are only enforced at Set time if Enforce is true, otherwise they merely serve as information.
 type KeyInfo struct {
 	Description string
 	Category    string
+	ValueType   string   // the Go type of values as printed by fmt's %T verb, e.g. "int" or "main.Settings"
+	Min         *float64 // the minimum of numeric values
+	Max         *float64 // the maximum of numeric values
+	Enum        []any    // the only values allowed for the key
+	Unit        string   // the unit of values, e.g. "px" or "seconds"
+	Enforce     bool     // reject values violating the constraints with ConstraintErr at Set time
+	Locked      bool     // reject all values with KeyLockedErr at Set time, see Lock and ForceSet
+	Sensitive   bool     // the value is a secret such as a token that is redacted in exports, see RedactSensitive
+	UIHints
 }
 
 // KVStore implements KvStore interface with an sqlite database backend.
 type KVStore struct {
-	path  string
-	sqx   *sqlx.DB
-	sq    *sql.DB
-	state uint32
+	path   string
+	memory string // the name of the in-memory database if opened with InMemory
+	sqx    *sqlx.DB
+	sq     *sql.DB
+	writer *sqlx.DB // the single connection used for writes in single-writer mode, nil otherwise
+	shared bool     // opened with OpenWithDB on a database managed by the application
+	state  uint32
+	opts   options
+
+	listeners   listeners
+	writeBehind writeBehind
+	cache       *readCache
+	indexes     secondaryIndexes
+	journal     journal
+	types       []string // names of types registered before Open
+	flags       flagOverlay
+	async       asyncWrites
+	validators  validators
+	hooks       hooks
+	loaders     loaders
+	replication replication
+	checksum    storeChecksum
+	maintenance maintenance
+	clock       atomic.Pointer[Clock]
+	counters    counters
+
+	releaseMemory func() // releases the in-memory database when the store is closed
 }
 
-// New creates a new key value store that is not yet opened.
-func New() *KVStore {
-	return &KVStore{}
+// New creates a new key value store that is not yet opened, configured with the given options.
+func New(opts ...Option) *KVStore {
+	db := &KVStore{}
+	for _, opt := range opts {
+		opt(&db.opts)
+	}
+	db.cache = newReadCache(db.opts.cacheEntries, db.opts.cacheBytes)
+	return db
 }
 
 var _ KeyValueStore = (*KVStore)(nil)
 
-// Open a database at the path specified when the database was created,
-// which holds all database files. If directories to path/name do not exist, they are created
-// recursively with Unix permissions 0755.
+// Open a database at the path specified when the database was created, which holds all database files. If
+// directories to path/name do not exist, they are created recursively with Unix permissions 0755. If path is
+// InMemory, the database is held in memory only, and if a VFS has been configured, path is a directory within the
+// VFS. A closed store can be opened again with the same or a different path; the undo history and the secondary
+// indexes registered with RegisterIndex are not carried over.
 func (db *KVStore) Open(path string) error {
 	if atomic.LoadUint32(&db.state) > 255 {
 		return AlreadyOpenErr
 	}
+	db.shared = false
+	if !externalFunction && db.opts.externalThreshold > 0 {
+		return fmt.Errorf("%w: ExternalValues", UnsupportedErr)
+	}
+	var dsn string
+	var err error
+	switch {
+	case path == InMemory:
+		dsn, err = db.memoryDataSourceName()
+	case db.opts.vfs != "":
+		dsn, err = db.vfsDataSourceName(path)
+	default:
+		dsn, err = db.fileDataSourceName(path)
+	}
+	if err != nil {
+		return err
+	}
+	db.sq, err = db.openDB(dsn)
+	if err != nil {
+		db.closeMemory()
+		return err
+	}
+	db.sqx = sqlx.NewDb(db.sq, "sqlite3")
+	if err := db.openWriter(dsn); err != nil {
+		db.sqx.Close()
+		db.closeMemory()
+		return err
+	}
+	if err := db.init(); err != nil {
+		db.closeWriter()
+		db.sqx.Close()
+		db.closeMemory()
+		return err
+	}
+	return nil
+}
+
+// fileDataSourceName creates the directory at path if necessary and returns the sqlite URI for the
+// database file in it.
+func (db *KVStore) fileDataSourceName(path string) (string, error) {
 	db.path = path
 	var err error
 	if db.path == "" {
 		db.path, err = os.Getwd()
 		if err != nil {
-			return err
+			return "", err
 		}
 	}
 	_, err = os.Stat(db.path)
@@ -75,34 +162,66 @@ func (db *KVStore) Open(path string) error {
 		if os.IsNotExist(err) {
 			err := os.MkdirAll(db.path, 0755)
 			if err != nil {
-				return err
+				return "", err
 			}
 		}
 	}
 	file := filepath.Join(db.path, "kvstore.sqlite")
 	db.path = file
-	db.sq, err = sql.Open("sqlite3", file)
+	p, err := uriPath(file)
 	if err != nil {
-		return err
+		return "", err
 	}
-	db.sqx = sqlx.NewDb(db.sq, "sqlite3")
-	if err != nil {
+	return dataSourceName(p, "", db.opts.retry.busyTimeout()), nil
+}
+
+// addColumn adds a column to the given table unless it already exists, so databases created by
+// earlier versions are upgraded.
+func addColumn(ex sqlx.Ext, table, column, decl string) error {
+	var exists bool
+	// the table name is part of the statement so that it is rewritten for stores with a custom table name
+	err := sqlx.Get(ex, &exists, `SELECT EXISTS(SELECT 1 FROM pragma_table_info('`+table+`') WHERE name=?);`, column)
+	if err != nil || exists {
 		return err
 	}
-	return db.init()
+	_, err = ex.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + decl + `;`)
+	return err
 }
 
-// init initializes the database tables if necessary.
+// init initializes the database tables if necessary. This is done in one transaction so that several
+// processes may open the same database concurrently.
 func (db *KVStore) init() error {
-	_, err := db.sqx.Exec(`
-PRAGMA journal_mode=WAL;
-PRAGMA synchronous=NORMAL;
-PRAGMA auto_vacuum=FULL;
-PRAGMA journal_size_limit = 67108864;
-PRAGMA mmap_size = 134217728;
-PRAGMA cache_size = 2000;
-PRAGMA busy_timeout = 5000;
+	if err := db.opts.encryption.register(); err != nil {
+		atomic.StoreUint32(&db.state, 3)
+		return err
+	}
+	tx, err := db.begin()
+	if err == nil {
+		err = db.initTables(tx)
+		if err == nil {
+			err = tx.Commit()
+		} else {
+			tx.Rollback()
+		}
+	}
+	if err != nil {
+		atomic.StoreUint32(&db.state, 3)
+		return err
+	}
+	if db.opts.multiProcess {
+		if err := db.cache.watch(db.sq); err != nil {
+			atomic.StoreUint32(&db.state, 3)
+			return err
+		}
+	}
+	atomic.StoreUint32(&db.state, 256)
+	db.startWriteBehind()
+	return nil
+}
 
+// initTables creates and upgrades the tables within the given transaction.
+func (db *KVStore) initTables(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
 CREATE TABLE IF NOT EXISTS kv(
   key TEXT PRIMARY KEY NOT NULL,
   value BLOB,
@@ -110,13 +229,69 @@ CREATE TABLE IF NOT EXISTS kv(
   info TEXT,
   category TEXT
 );
+CREATE INDEX IF NOT EXISTS kv_category ON kv(category);
 `)
 	if err != nil {
-		atomic.StoreUint32(&db.state, 3)
 		return err
 	}
-	atomic.StoreUint32(&db.state, 256)
-	return nil
+	if err := addColumn(tx, "kv", "extra", "BLOB"); err != nil {
+		return err
+	}
+	if err := addColumn(tx, "kv", "codec", "TEXT"); err != nil {
+		return err
+	}
+	if err := initExternal(tx); err != nil {
+		return err
+	}
+	if err := initContent(tx); err != nil {
+		return err
+	}
+	if err := initTimestamps(tx); err != nil {
+		return err
+	}
+	if err := initAccess(tx); err != nil {
+		return err
+	}
+	if err := initExpiry(tx); err != nil {
+		return err
+	}
+	if err := initCategoryInfo(tx); err != nil {
+		return err
+	}
+	if err := initTags(tx); err != nil {
+		return err
+	}
+	if err := initMeta(tx); err != nil {
+		return err
+	}
+	if err := initRevisions(tx); err != nil {
+		return err
+	}
+	if err := initLists(tx); err != nil {
+		return err
+	}
+	if err := initSets(tx); err != nil {
+		return err
+	}
+	if err := initHashes(tx); err != nil {
+		return err
+	}
+	if err := initQueues(tx); err != nil {
+		return err
+	}
+	if err := initStreams(tx); err != nil {
+		return err
+	}
+	if err := initIndexes(tx); err != nil {
+		return err
+	}
+	if err := db.initTypes(tx); err != nil {
+		return err
+	}
+	if err := initChecksums(tx); err != nil {
+		return err
+	}
+	return db.initSearch(tx)
 }
 
 // Close closes the database.
@@ -124,137 +299,562 @@ func (db *KVStore) Close() error {
 	if atomic.LoadUint32(&db.state) < 256 {
 		return nil
 	}
+	db.StopMaintenance()
+	db.waitAsync()
+	flushErr := db.stopWriteBehind()
+	db.removeOrphans()
 	atomic.StoreUint32(&db.state, 2)
-	err := db.sqx.Close()
+	cacheErr := db.cache.close()
+	err := db.closeWriter()
+	if !db.shared {
+		err = errors.Join(err, db.sqx.Close())
+	}
+	db.closeMemory()
 	if err != nil {
 		atomic.StoreUint32(&db.state, 3)
 	}
-	return err
+	db.reset()
+	return errors.Join(flushErr, cacheErr, err)
+}
+
+// reset clears the state kept for the database that has been closed, so that the store can be opened again.
+func (db *KVStore) reset() {
+	db.journal.mutex.Lock()
+	db.journal.undo, db.journal.redo = nil, nil
+	db.journal.mutex.Unlock()
+	db.indexes.mutex.Lock()
+	db.indexes.extractors = nil
+	db.indexes.mutex.Unlock()
+	db.checksum.mutex.Lock()
+	db.checksum.revision, db.checksum.sum = 0, ""
+	db.checksum.mutex.Unlock()
 }
 
 // SetDefault sets a default value for the given key, as well as info and category.
-func (db *KVStore) SetDefault(key string, value any, info KeyInfo) error {
+func (db *KVStore) SetDefault(key string, value any, info KeyInfo) (err error) {
+	defer db.observe(opSetDefault, key, 1, time.Now(), &err)
+	if atomic.LoadUint32(&db.state) < 256 {
+		return keyError("set default", key, NotOpenErr)
+	}
+	defer db.cache.remove(key)
+	return keyError("set default", key, db.inTx(func(tx *sqlx.Tx) error {
+		return db.setDefault(tx, key, value, info)
+	}))
+}
+
+// DefaultSpec is a default value together with its key info, see SetDefaults.
+type DefaultSpec struct {
+	Value any
+	Info  KeyInfo
+}
+
+// SetDefaults sets the defaults and key infos for all keys in the map in one transaction. It is intended
+// to be called at application startup with the full set of preferences. Rows whose default and key info
+// have not changed are not written.
+func (db *KVStore) SetDefaults(defaults map[string]DefaultSpec) (err error) {
+	defer db.observe(opSetDefaults, "", len(defaults), time.Now(), &err)
 	if atomic.LoadUint32(&db.state) < 256 {
 		return NotOpenErr
 	}
-	original, err := MarshalBinary(value)
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	defer db.cache.clear()
+	for k, spec := range defaults {
+		if err := db.setDefault(tx, k, spec.Value, spec.Info); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// setDefault writes the default and key info for a key unless they are unchanged.
+func (db *KVStore) setDefault(ex sqlx.Ext, key string, value any, info KeyInfo) error {
+	if err := checkKind(ex, key, "value"); err != nil {
+		return err
+	}
+	original, err := db.encode(value)
+	if err != nil {
+		return err
+	}
+	if err := db.checkDefaultQuota(ex, key, info.Category, len(original)); err != nil {
+		return err
+	}
+	if db.opts.encryption.encrypts(info.Category) {
+		if original, err = db.opts.encryption.seal(original, true); err != nil {
+			return err
+		}
+	}
+	extra, err := info.marshalExtra()
+	if err != nil {
+		return err
+	}
+	ref, err := db.dedup(ex, original)
 	if err != nil {
 		return err
 	}
-	_, err = db.sqx.Exec(`INSERT INTO kv(key,original,info,category) VALUES(?,?,?,?) ON CONFLICT(key) DO UPDATE SET original=?,info=?,category=?;`,
-		key, original, info.Description, info.Category, original, info.Description, info.Category)
+	var sum any
+	if ref != nil {
+		original = ref
+	} else {
+		sum = checksum(original)
+	}
+	now := db.now().UnixMilli()
+	_, err = ex.Exec(`INSERT INTO kv(key,original,original_ref,original_type,original_sum,info,category,extra,created_at,updated_at)
+VALUES(?,?,?,?,?,?,?,?,?,?)
+ON CONFLICT(key) DO UPDATE SET original=excluded.original,original_ref=excluded.original_ref,original_type=excluded.original_type,
+original_sum=excluded.original_sum,info=excluded.info,category=excluded.category,extra=excluded.extra,
+updated_at=CASE WHEN original IS excluded.original THEN updated_at ELSE excluded.updated_at END
+WHERE original IS NOT excluded.original OR info IS NOT excluded.info OR category IS NOT excluded.category OR extra IS NOT excluded.extra;`,
+		key, original, ref, nullString(typeName(value)), sum, info.Description, info.Category, extra, now, now)
 	return err
 }
 
 // Set sets the value for the given key, overwriting an existing value for the key if there is one.
-func (db *KVStore) Set(key string, value any) error {
+func (db *KVStore) Set(key string, value any) (err error) {
+	defer db.observe(opSet, key, 1, time.Now(), &err)
+	return keyError("set", key, db.set(key, value, false))
+}
+
+// SetNil sets an explicit nil value for the given key, which is distinct from the key being absent: Get and
+// GetWithSource return nil and no error, and the default is not returned until the key is reverted with
+// Revert. This is the same as Set(key, nil).
+func (db *KVStore) SetNil(key string) (err error) {
+	defer db.observe(opSet, key, 1, time.Now(), &err)
+	return keyError("set", key, db.set(key, nil, false))
+}
+
+// set sets the value for the given key, ignoring whether the key is locked if force is true.
+func (db *KVStore) set(key string, value any, force bool) error {
 	if atomic.LoadUint32(&db.state) < 256 {
 		return NotOpenErr
 	}
-	b, err := MarshalBinary(value)
+	value, err := db.beforeSet(key, value)
+	if err != nil {
+		return err
+	}
+	if isMessage(value) {
+		if err := db.setCodec(key, value, Proto, force); err != nil {
+			return err
+		}
+		db.afterSet(key, value)
+		return nil
+	}
+	if err := db.checkKey(db.sqx, key, value, force); err != nil {
+		return err
+	}
+	b, err := db.encode(value)
 	if err != nil {
 		return err
 	}
-	_, err = db.sqx.Exec(`INSERT INTO kv(key,value) VALUES(?,?) ON CONFLICT(key) DO UPDATE SET value=?;`,
-		key, b, b)
+	if db.opts.flushInterval > 0 {
+		// the quota of buffered writes cannot be checked in the transaction flushing them
+		if err := db.checkQuota(db.sqx, key, len(b)); err != nil {
+			return err
+		}
+	}
+	notify := db.hasListeners()
+	var old any
+	if notify {
+		old = db.current(db.sqx, key)
+	}
+	if !db.setBehind(key, b, typeName(value)) {
+		err = db.inTx(func(tx *sqlx.Tx) error {
+			if err := db.checkQuota(tx, key, len(b)); err != nil {
+				return err
+			}
+			return db.put(tx, key, b, typeName(value))
+		})
+		if err == nil {
+			err = db.evict(key)
+		}
+	}
+	db.cache.remove(key)
+	if err != nil {
+		return err
+	}
+	if notify {
+		if err := db.notify(change{key: key, old: old, new: value}); err != nil {
+			return err
+		}
+	}
+	db.afterSet(key, value)
+	return nil
+}
+
+// put writes the encoded value for the key together with its codec, the name of its type, its checksum and, if
+// the value is not stored in the value column itself, the name of its external file or its content hash. The
+// modification time now in milliseconds is recorded if the value changes, and also as creation time of new keys.
+func put(ex sqlx.Execer, key string, b []byte, codec, typ string, external, ref any, now int64) error {
+	var c any
+	if codec != codecGob {
+		c = codec
+	}
+	var value any = b
+	if len(b) == 0 {
+		// a NULL value means that no value is set, and the driver does not read back empty blobs reliably,
+		// so empty values are stored as empty text
+		value = ""
+	}
+	// values in external files and the content table are verified by the hash in the value column
+	var sum any
+	if external == nil && ref == nil {
+		sum = checksum(b)
+	}
+	_, err := ex.Exec(`INSERT INTO kv(key,value,codec,value_type,value_sum,external,value_ref,created_at,updated_at)
+VALUES(?,?,?,?,?,?,?,?,?)
+ON CONFLICT(key) DO UPDATE SET value=excluded.value,codec=excluded.codec,value_type=excluded.value_type,
+value_sum=excluded.value_sum,external=excluded.external,value_ref=excluded.value_ref,
+updated_at=CASE WHEN value IS excluded.value THEN updated_at ELSE excluded.updated_at END;`,
+		key, value, c, nullString(typ), sum, external, ref, now, now)
 	return err
 }
 
 // SetMany sets all pairs in the given map in one transaction.
-func (db *KVStore) SetMany(pairs map[string]any) error {
+func (db *KVStore) SetMany(pairs map[string]any) (err error) {
+	defer db.observe(opSetMany, "", len(pairs), time.Now(), &err)
 	if atomic.LoadUint32(&db.state) < 256 {
 		return NotOpenErr
 	}
-	tx, err := db.sqx.Beginx()
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	values := make(map[string]any, len(pairs))
+	for k, v := range pairs {
+		var err error
+		if values[k], err = db.beforeSet(k, v); err != nil {
+			return keyError("set", k, err)
+		}
+	}
+	tx, err := db.begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
-	for k, v := range pairs {
-		b, err := MarshalBinary(v)
-		if err != nil {
+	defer db.cache.remove(slices.Collect(maps.Keys(pairs))...)
+	notify := db.hasListeners()
+	var changes []change
+	for k, v := range values {
+		if err := db.checkConstraints(tx, k, v); err != nil {
 			return err
 		}
-		_, err = tx.Exec(`INSERT INTO kv(key,value) VALUES(?,?) ON CONFLICT(key) DO UPDATE SET value=?;`, k, b, b)
+		b, err := db.encode(v)
 		if err != nil {
 			return err
 		}
+		if err := db.checkQuota(tx, k, len(b)); err != nil {
+			return err
+		}
+		if notify {
+			changes = append(changes, change{key: k, old: db.current(tx, k), new: v})
+		}
+		if err := db.put(tx, k, b, typeName(v)); err != nil {
+			return err
+		}
 	}
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := db.notify(changes...); err != nil {
+		return err
+	}
+	for k, v := range values {
+		db.afterSet(k, v)
+	}
+	return db.evict(slices.Collect(maps.Keys(pairs))...)
 }
 
 // Get gets the value for the given key, the default if no value for the key is stored but a default is
-// present, and NotFoundErr if neither of them is present.
-func (db *KVStore) Get(key string) (any, error) {
+// present, and NotFoundErr if neither of them is present. A key explicitly set to nil, see SetNil, returns
+// nil and no error rather than its default. Command-line flags registered with RegisterFlags and environment
+// variables, see EnvOverlay, take precedence in this order. Missing keys are loaded by the loader registered
+// for them with RegisterLoader, if any.
+func (db *KVStore) Get(key string) (v any, err error) {
+	defer db.observe(opGet, key, 1, time.Now(), &err)
+	v, err = db.getOverridden(key)
+	if err != nil {
+		v, err = db.load(key, err)
+	}
+	return db.afterGet(key, v, err)
+}
+
+// getOverridden implements Get without calling the AfterGet hooks.
+func (db *KVStore) getOverridden(key string) (any, error) {
+	v, sliding, err := db.get(key)
+	if ov, source, err := db.override(key, v, err); source != SourceNone {
+		return ov, keyError("get", key, err)
+	}
+	if err != nil {
+		return v, keyError("get", key, err)
+	}
+	if sliding {
+		db.recordAccess(key)
+	} else {
+		db.touch(key)
+	}
+	return v, nil
+}
+
+// get returns the value for key without recording the access, and whether reading the key extends its lifetime.
+func (db *KVStore) get(key string) (any, bool, error) {
 	if atomic.LoadUint32(&db.state) < 256 {
-		return nil, NotOpenErr
+		return nil, false, NotOpenErr
 	}
-	var b []byte
-	err := db.sqx.Get(&b, `SELECT value FROM kv WHERE key=? LIMIT 1;`, key)
-	if err != nil || b == nil {
-		return db.getDefault(key)
+	if sv, ok := db.pendingValue(key); ok {
+		v, _, err := sv.decode()
+		return v, false, err
+	}
+	if v, ok := db.cache.get(key); ok {
+		db.cacheAccess(true)
+		return v, false, nil
 	}
-	return UnmarshalBinary(b)
+	db.cacheAccess(false)
+	gen := db.cache.gen()
+	sv, x, err := db.readValue(db.sqx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok, err := sv.decode()
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		return nil, false, NotFoundErr
+	}
+	// keys that expire are not cached, so that expiration does not have to be tracked by the cache
+	if !x.expires.Valid {
+		db.cache.add(gen, key, v, sv.size())
+	}
+	return v, x.sliding, nil
+}
+
+// Source indicates where a value returned by GetWithSource comes from.
+type Source int
+
+const (
+	SourceNone        Source = iota // there is neither a value nor a default
+	SourceValue                     // the value was set explicitly
+	SourceDefault                   // the value is the default
+	SourceEnvironment               // the value is overridden by an environment variable, see EnvOverlay
+	SourceFlag                      // the value is overridden by a command-line flag, see RegisterFlags
+)
+
+// String returns a human-readable name of the source.
+func (s Source) String() string {
+	switch s {
+	case SourceValue:
+		return "value"
+	case SourceDefault:
+		return "default"
+	case SourceEnvironment:
+		return "environment"
+	case SourceFlag:
+		return "flag"
+	}
+	return "none"
+}
+
+// GetWithSource is like Get but also returns whether the value was set explicitly or is the default.
+// This may be used to mark modified preferences in a user interface.
+func (db *KVStore) GetWithSource(key string) (any, Source, error) {
+	v, source, sliding, err := db.getWithSource(key)
+	if ov, source, err := db.override(key, v, err); source != SourceNone {
+		return ov, source, keyError("get", key, err)
+	}
+	if err != nil {
+		return v, source, keyError("get", key, err)
+	}
+	if sliding {
+		db.recordAccess(key)
+	} else {
+		db.touch(key)
+	}
+	return v, source, nil
+}
+
+// getWithSource returns the value for key and its source without recording the access, and whether reading
+// the key extends its lifetime.
+func (db *KVStore) getWithSource(key string) (any, Source, bool, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, SourceNone, false, NotOpenErr
+	}
+	if sv, ok := db.pendingValue(key); ok {
+		v, _, err := sv.decode()
+		return v, SourceValue, false, err
+	}
+	sv, x, err := db.readValue(db.sqx, key)
+	if err != nil {
+		return nil, SourceNone, false, err
+	}
+	source := SourceDefault
+	if sv.value != nil {
+		source = SourceValue
+	}
+	v, ok, err := sv.decode()
+	if !ok && err == nil {
+		return nil, SourceNone, false, NotFoundErr
+	}
+	return v, source, x.sliding, err
+}
+
+// Has returns true if a value or a default is stored for the given key, i.e., if Get would
+// succeed for the key. Unlike Get, the value is not decoded.
+func (db *KVStore) Has(key string) (bool, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return false, NotOpenErr
+	}
+	if _, ok := db.pendingValue(key); ok {
+		return true, nil
+	}
+	var x expiry
+	err := db.sqx.QueryRowx(`SELECT `+expiryColumns+` FROM kv `+expiryJoins+
+		` WHERE kv.key=? AND (value IS NOT NULL OR original IS NOT NULL);`, key).Scan(x.dest()...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil && !x.expired(db.now()), err
+}
+
+// HasDefault returns true if a default is stored for the given key.
+func (db *KVStore) HasDefault(key string) (bool, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return false, NotOpenErr
+	}
+	var found bool
+	err := db.sqx.Get(&found, `SELECT EXISTS(SELECT 1 FROM kv WHERE key=? AND original IS NOT NULL);`, key)
+	return found, err
 }
 
 // GetAll returns all key-value pairs as a map. If limit is 0 or negative, all key value pairs are returned.
 // Although this is usually not advisable, this method may be used in combination with SetMany to save and
 // load maps, i.e., use the key value store merely for persistence and keep the data in memory.
-func (db *KVStore) GetAll(limit int) (map[string]any, error) {
+func (db *KVStore) GetAll(limit int) (result map[string]any, err error) {
+	defer func(start time.Time) { db.observe(opGetAll, "", len(result), start, &err) }(time.Now())
+	result, err = db.getAll(limit)
+	if err == nil {
+		err = db.redact(result)
+	}
+	return result, err
+}
+
+// getAll implements GetAll without redacting sensitive values.
+func (db *KVStore) getAll(limit int) (map[string]any, error) {
 	if atomic.LoadUint32(&db.state) < 256 {
 		return nil, NotOpenErr
 	}
-	var rows *sqlx.Rows
-	var err error
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
 	if limit <= 0 {
-		rows, err = db.sqx.Queryx(`SELECT key,value,original FROM kv ORDER BY key ASC;`)
-	} else {
-		rows, err = db.sqx.Queryx(`SELECT key,value,original FROM kv ORDER BY key ASC LIMIT ?;`, limit)
+		limit = -1
 	}
+	rows, err := db.queryLive(db.sqx, `1`, `ORDER BY kv.key ASC LIMIT ?`, limit)
 	if err != nil {
 		return nil, err
 	}
-	if rows == nil {
-		return nil, NotFoundErr
-	}
+	defer rows.Close()
 	result := make(map[string]any)
+	var sliding []string
 	for rows.Next() {
-		var key string
-		var value, original []byte
-		err = rows.Scan(&key, &value, &original)
+		key, sv, slides, err := scanLive(rows)
+		if err != nil {
+			return result, err
+		}
+		v, ok, err := sv.decode()
 		if err != nil {
 			return result, err
 		}
-		if value != nil {
-			v, err2 := UnmarshalBinary(value)
+		if ok {
+			result[key] = v
+			if slides {
+				sliding = append(sliding, key)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+	rows.Close()
+	db.recordAccess(sliding...)
+	return result, nil
+}
+
+// GetMany returns the values, or defaults if no value is set, for the given keys in one transaction.
+// Keys for which neither a value nor a default is stored are not contained in the resulting map.
+func (db *KVStore) GetMany(keys []string) (_ map[string]any, err error) {
+	defer db.observe(opGetMany, "", len(keys), time.Now(), &err)
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	tx, err := db.sqx.BeginTxx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	result := make(map[string]any)
+	var sliding []string
+	for len(keys) > 0 {
+		chunk := keys[:min(len(keys), maxBatchVariables)]
+		keys = keys[len(chunk):]
+		cond, args, err := sqlx.In(`kv.key IN (?)`, chunk)
+		if err != nil {
+			return result, err
+		}
+		rows, err := db.queryLive(tx, cond, ``, args...)
+		if err != nil {
+			return result, err
+		}
+		for rows.Next() {
+			key, sv, slides, err := scanLive(rows)
 			if err != nil {
-				err = errors.Join(err, err2)
-			} else {
-				result[key] = v
+				rows.Close()
+				return result, err
 			}
-		} else if original != nil {
-			v, err2 := UnmarshalBinary(original)
+			v, ok, err := sv.decode()
 			if err != nil {
-				err = errors.Join(err, err2)
-			} else {
+				rows.Close()
+				return result, err
+			}
+			if ok {
 				result[key] = v
+				if slides {
+					sliding = append(sliding, key)
+				}
 			}
 		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return result, err
+		}
 	}
-	return result, err
+	if err := tx.Commit(); err != nil {
+		return result, err
+	}
+	if db.opts.trackAccess {
+		db.touch(slices.Collect(maps.Keys(result))...)
+	} else {
+		db.recordAccess(sliding...)
+	}
+	return result, nil
 }
 
-// getDefault obtains the default for the given key, ErrNotFound if there is none.
-func (db *KVStore) getDefault(key string) (any, error) {
+// GetDefault obtains the default for the given key, NotFoundErr if there is none.
+func (db *KVStore) GetDefault(key string) (any, error) {
 	if atomic.LoadUint32(&db.state) < 256 {
-		return nil, NotOpenErr
+		return nil, keyError("get default", key, NotOpenErr)
 	}
 	var b []byte
-	err := db.sqx.Get(&b, `SELECT original FROM kv WHERE key=? LIMIT 1;`, key)
+	err := db.sqx.Get(&b, `SELECT `+originalColumn+` FROM kv WHERE key=? LIMIT 1;`, key)
 	if errors.Is(err, sql.ErrNoRows) || b == nil {
-		return nil, NotFoundErr
+		return nil, keyError("get default", key, NotFoundErr)
 	}
-	return UnmarshalBinary(b)
+	v, err := UnmarshalBinary(b)
+	return v, keyError("get default", key, err)
 }
 
 // Info attempts to obtain information about the given key, returns false if none can be found.
@@ -264,53 +864,132 @@ func (db *KVStore) Info(key string) (KeyInfo, bool) {
 	if atomic.LoadUint32(&db.state) < 256 {
 		return info, false
 	}
-	row := db.sqx.QueryRowx(`SELECT info,category FROM kv WHERE key=? LIMIT 1;`, key)
+	row := db.sqx.QueryRowx(`SELECT info,category,extra FROM kv WHERE key=? LIMIT 1;`, key)
 	if row == nil {
 		return info, false
 	}
-	err := row.Scan(&info.Description, &info.Category)
+	var extra []byte
+	err := row.Scan(&info.Description, &info.Category, &extra)
 	if err != nil {
 		return info, false
 	}
+	if err := info.unmarshalExtra(extra); err != nil {
+		return info, false
+	}
 	return info, true
 }
 
-// Revert reverts the value for the given key to its default. If no default has been set, NoDefaultErr is returned.
-func (db *KVStore) Revert(key string) error {
+// Revert reverts the value for the given key to its default by removing the value, so that GetWithSource reports
+// SourceDefault and later changes of the default take effect.
+func (db *KVStore) Revert(key string) (err error) {
+	defer db.observe(opRevert, key, 1, time.Now(), &err)
+	return keyError("revert", key, db.revert(key))
+}
+
+// revertColumns are the assignments removing the value of a key, which reverts it to its default. They take the
+// modification time in milliseconds as parameter, which is recorded if the key had a value.
+const revertColumns = `value=NULL,value_ref=NULL,codec=NULL,value_type=NULL,value_sum=NULL,external=NULL,
+updated_at=CASE WHEN value IS NULL THEN updated_at ELSE ? END`
+
+// revert implements Revert.
+func (db *KVStore) revert(key string) error {
 	if atomic.LoadUint32(&db.state) < 256 {
 		return NotOpenErr
 	}
-	_, err := db.sqx.Exec(`UPDATE kv SET value=original WHERE key=?;`, key)
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	defer db.cache.remove(key)
+	notify := db.hasListeners()
+	var old any
+	if notify {
+		old = db.current(db.sqx, key)
+	}
+	_, err := db.exec(`UPDATE kv SET `+revertColumns+` WHERE key=?;`, db.now().UnixMilli(), key)
 	if err != nil {
 		return NoDefaultErr
 	}
+	if notify {
+		if err := db.notify(change{key: key, old: old, new: db.current(db.sqx, key)}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // Delete removes the key and value from the key value store.
-func (db *KVStore) Delete(key string) error {
+func (db *KVStore) Delete(key string) (err error) {
+	defer db.observe(opDelete, key, 1, time.Now(), &err)
+	return keyError("delete", key, db.delete(key))
+}
+
+// delete implements Delete.
+func (db *KVStore) delete(key string) error {
 	if atomic.LoadUint32(&db.state) < 256 {
 		return NotOpenErr
 	}
-	_, err := db.sqx.Exec(`DELETE FROM kv WHERE key=?;`, key)
-	return err
+	if err := db.beforeDelete(key); err != nil {
+		return err
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	defer db.cache.remove(key)
+	notify := db.hasListeners()
+	var old any
+	if notify {
+		old = db.current(db.sqx, key)
+	}
+	_, err := db.exec(`DELETE FROM kv WHERE key=?;`, key)
+	if err != nil {
+		return err
+	}
+	if notify {
+		if err := db.notify(change{key: key, old: old}); err != nil {
+			return err
+		}
+	}
+	db.removeOrphans()
+	return nil
 }
 
 // DeleteMany removes all given keys in one transaction.
-func (db *KVStore) DeleteMany(keys []string) error {
+func (db *KVStore) DeleteMany(keys []string) (err error) {
+	defer db.observe(opDeleteMany, "", len(keys), time.Now(), &err)
 	if atomic.LoadUint32(&db.state) < 256 {
 		return NotOpenErr
 	}
-	tx, err := db.sqx.Beginx()
+	for _, k := range keys {
+		if err := db.beforeDelete(k); err != nil {
+			return keyError("delete", k, err)
+		}
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	tx, err := db.begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
+	defer db.cache.remove(keys...)
+	notify := db.hasListeners()
+	var changes []change
 	for _, k := range keys {
+		if notify {
+			changes = append(changes, change{key: k, old: db.current(tx, k)})
+		}
 		_, err = tx.Exec(`DELETE FROM kv WHERE key=?;`, k)
 		if err != nil {
 			return err
 		}
 	}
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := db.notify(changes...); err != nil {
+		return err
+	}
+	db.removeOrphans()
+	return nil
 }
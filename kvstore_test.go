@@ -185,3 +185,215 @@ func generateRandomHex(n int) (string, error) {
 	}
 	return hex.EncodeToString(b), nil
 }
+
+// openTestStore opens a new key value store in a temporary directory that is
+// closed and removed when the test finishes.
+func openTestStore(t *testing.T) *KVStore {
+	t.Helper()
+	db := New()
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf(`failed to close database: %v`, err)
+		}
+	})
+	return db
+}
+
+func TestHas(t *testing.T) {
+	db := openTestStore(t)
+	if ok, err := db.Has("missing"); ok || err != nil {
+		t.Errorf(`expected missing key to be absent, got %v, %v`, ok, err)
+	}
+	if err := db.Set("value", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if ok, err := db.Has("value"); !ok || err != nil {
+		t.Errorf(`expected key to be present, got %v, %v`, ok, err)
+	}
+	if ok, err := db.HasDefault("value"); ok || err != nil {
+		t.Errorf(`expected key to have no default, got %v, %v`, ok, err)
+	}
+	if err := db.SetDefault("default", 2, KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if ok, err := db.Has("default"); !ok || err != nil {
+		t.Errorf(`expected key with default to be present, got %v, %v`, ok, err)
+	}
+	if ok, err := db.HasDefault("default"); !ok || err != nil {
+		t.Errorf(`expected key to have a default, got %v, %v`, ok, err)
+	}
+}
+
+func TestGetMany(t *testing.T) {
+	db := openTestStore(t)
+	pairs := make(map[string]any)
+	keys := make([]string, 0, 1200)
+	for i := 0; i < 1200; i++ {
+		key, _ := generateRandomHex(8)
+		pairs[key] = i
+		keys = append(keys, key)
+	}
+	if err := db.SetMany(pairs); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	if err := db.SetDefault("default", "value", KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	result, err := db.GetMany(append(keys, "default", "missing"))
+	if err != nil {
+		t.Fatalf(`get many failed: %v`, err)
+	}
+	if len(result) != len(keys)+1 {
+		t.Errorf(`expected %v results, got %v`, len(keys)+1, len(result))
+	}
+	for k, v := range pairs {
+		if result[k] != v {
+			t.Errorf(`expected %v for key %v, got %v`, v, k, result[k])
+			break
+		}
+	}
+	if result["default"] != "value" {
+		t.Errorf(`expected default value, got %v`, result["default"])
+	}
+	if _, ok := result["missing"]; ok {
+		t.Errorf(`missing key should not be in the result`)
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	db := openTestStore(t)
+	defaults := map[string]DefaultSpec{
+		"width":  {Value: 800, Info: KeyInfo{Description: "window width", Category: "display"}},
+		"height": {Value: 600, Info: KeyInfo{Description: "window height", Category: "display"}},
+	}
+	if err := db.SetDefaults(defaults); err != nil {
+		t.Fatalf(`failed to set defaults: %v`, err)
+	}
+	if err := db.Set("width", 1024); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	_, err := db.sqx.Exec(`CREATE TABLE updates(n INTEGER);
+CREATE TRIGGER count_updates AFTER UPDATE ON kv BEGIN INSERT INTO updates VALUES(1); END;`)
+	if err != nil {
+		t.Fatalf(`failed to create trigger: %v`, err)
+	}
+	if err := db.SetDefaults(defaults); err != nil {
+		t.Fatalf(`failed to set defaults again: %v`, err)
+	}
+	var updates int
+	if err := db.sqx.Get(&updates, `SELECT COUNT(*) FROM updates;`); err != nil {
+		t.Fatalf(`failed to count updates: %v`, err)
+	}
+	if updates != 0 {
+		t.Errorf(`unchanged defaults were written again`)
+	}
+	if v, _ := db.Get("width"); v != 1024 {
+		t.Errorf(`setting defaults changed value, got %v`, v)
+	}
+	if v, _ := db.Get("height"); v != 600 {
+		t.Errorf(`expected default 600, got %v`, v)
+	}
+	if info, ok := db.Info("height"); !ok || info.Description != "window height" || info.Category != "display" {
+		t.Errorf(`wrong key info after setting defaults: %v`, info)
+	}
+}
+
+func TestGetWithSource(t *testing.T) {
+	db := openTestStore(t)
+	if _, src, err := db.GetWithSource("missing"); src != SourceNone || !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr for missing key, got %v, %v`, src, err)
+	}
+	if err := db.SetDefault("theme", "light", KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if v, src, err := db.GetWithSource("theme"); v != "light" || src != SourceDefault || err != nil {
+		t.Errorf(`expected default, got %v, %v, %v`, v, src, err)
+	}
+	if err := db.Set("theme", "dark"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if v, src, err := db.GetWithSource("theme"); v != "dark" || src != SourceValue || err != nil {
+		t.Errorf(`expected explicit value, got %v, %v, %v`, v, src, err)
+	}
+	if v, err := db.GetDefault("theme"); v != "light" || err != nil {
+		t.Errorf(`expected default, got %v, %v`, v, err)
+	}
+	if _, err := db.GetDefault("missing"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr for missing default, got %v`, err)
+	}
+	if err := db.Revert("theme"); err != nil {
+		t.Fatalf(`failed to revert key: %v`, err)
+	}
+	if v, src, err := db.GetWithSource("theme"); v != "light" || src != SourceDefault || err != nil {
+		t.Errorf(`expected default after revert, got %v, %v, %v`, v, src, err)
+	}
+	if err := db.SetDefault("theme", "blue", KeyInfo{}); err != nil {
+		t.Fatalf(`failed to change default: %v`, err)
+	}
+	if v, src, err := db.GetWithSource("theme"); v != "blue" || src != SourceDefault || err != nil {
+		t.Errorf(`expected changed default after revert, got %v, %v, %v`, v, src, err)
+	}
+}
+
+func TestSetNil(t *testing.T) {
+	db := openTestStore(t)
+	defer db.Close()
+	if err := db.SetDefault("a", 1, KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.SetNil("a"); err != nil {
+		t.Fatalf(`failed to set nil: %v`, err)
+	}
+	if v, source, err := db.GetWithSource("a"); v != nil || source != SourceValue || err != nil {
+		t.Errorf(`expected explicit nil value, got %v, %v, %v`, v, source, err)
+	}
+	if ok, err := db.Has("a"); !ok || err != nil {
+		t.Errorf(`expected key with nil value to exist, got %v, %v`, ok, err)
+	}
+	if err := db.Revert("a"); err != nil {
+		t.Fatalf(`failed to revert key: %v`, err)
+	}
+	if v, err := db.Get("a"); v != 1 || err != nil {
+		t.Errorf(`expected default after Revert, got %v, %v`, v, err)
+	}
+	if err := db.SetDefault("b", "default", KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.SetRaw("b", nil); err != nil {
+		t.Fatalf(`failed to set raw value: %v`, err)
+	}
+	if v, source, err := db.GetWithSource("b"); len(v.([]byte)) != 0 || source != SourceValue || err != nil {
+		t.Errorf(`expected empty raw value instead of default, got %v, %v, %v`, v, source, err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	db := New(Cache(10, 0), Journal(10))
+	first, second := t.TempDir(), t.TempDir()
+	for i, dir := range []string{first, second, first} {
+		if err := db.Open(dir); err != nil {
+			t.Fatalf(`failed to open database: %v`, err)
+		}
+		if db.CanUndo() {
+			t.Errorf(`expected undo history to be reset by Close`)
+		}
+		v, err := db.Get("dir")
+		switch {
+		case i < 2 && !errors.Is(err, NotFoundErr):
+			t.Errorf(`expected empty database, got %v, %v`, v, err)
+		case i == 2 && v != first:
+			t.Errorf(`expected value of first database, got %v, %v`, v, err)
+		}
+		if i < 2 {
+			if err := db.Set("dir", dir); err != nil {
+				t.Fatalf(`failed to set key: %v`, err)
+			}
+		}
+		if err := db.Close(); err != nil {
+			t.Fatalf(`failed to close database: %v`, err)
+		}
+	}
+}
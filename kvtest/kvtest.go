@@ -0,0 +1,210 @@
+// Package kvtest provides a conformance test suite for implementations of kvstore.KeyValueStore, so that other
+// backends can prove that they match the semantics of the sqlite store, including defaults and Revert. Call
+// TestStore from a test of the backend:
+//
+//	func TestConformance(t *testing.T) {
+//		kvtest.TestStore(t, func(t *testing.T) kvstore.KeyValueStore {
+//			store := mybackend.New()
+//			if err := store.Open(t.TempDir()); err != nil {
+//				t.Fatal(err)
+//			}
+//			return store
+//		})
+//	}
+package kvtest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rasteric/kvstore"
+)
+
+// Factory returns a new, empty store that has been opened. The suite closes the store at the end of each test.
+type Factory func(t *testing.T) kvstore.KeyValueStore
+
+// TestStore runs the conformance tests as subtests of t, each on a new store returned by factory.
+func TestStore(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s kvstore.KeyValueStore)
+	}{
+		{"SetGet", testSetGet},
+		{"NotFound", testNotFound},
+		{"Defaults", testDefaults},
+		{"Revert", testRevert},
+		{"Info", testInfo},
+		{"SetMany", testSetMany},
+		{"GetAll", testGetAll},
+		{"Delete", testDelete},
+		{"DeleteMany", testDeleteMany},
+		{"Closed", testClosed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := factory(t)
+			defer s.Close()
+			test.fn(t, s)
+		})
+	}
+}
+
+// expectValue reports an error if Get does not return want for the key.
+func expectValue(t *testing.T, s kvstore.KeyValueStore, key string, want any) {
+	t.Helper()
+	v, err := s.Get(key)
+	if err != nil {
+		t.Errorf(`Get(%q): expected %v, got error %v`, key, want, err)
+	} else if !reflect.DeepEqual(v, want) {
+		t.Errorf(`Get(%q): expected %v (%T), got %v (%T)`, key, want, want, v, v)
+	}
+}
+
+// expectMissing reports an error if Get does not return an error wrapping kvstore.NotFoundErr for the key.
+func expectMissing(t *testing.T, s kvstore.KeyValueStore, key string) {
+	t.Helper()
+	if v, err := s.Get(key); !errors.Is(err, kvstore.NotFoundErr) {
+		t.Errorf(`Get(%q): expected NotFoundErr, got %v, %v`, key, v, err)
+	}
+}
+
+// must fails the test if err is not nil.
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testSetGet(t *testing.T, s kvstore.KeyValueStore) {
+	values := map[string]any{
+		"string": "hello",
+		"int":    42,
+		"float":  3.5,
+		"bool":   true,
+		"bytes":  []byte{1, 2, 3},
+		"empty":  "",
+		"":       "empty key",
+	}
+	for k, v := range values {
+		must(t, s.Set(k, v))
+	}
+	for k, v := range values {
+		expectValue(t, s, k, v)
+	}
+	must(t, s.Set("string", "world"))
+	expectValue(t, s, "string", "world")
+	must(t, s.Set("string", 7))
+	expectValue(t, s, "string", 7)
+}
+
+func testNotFound(t *testing.T, s kvstore.KeyValueStore) {
+	expectMissing(t, s, "missing")
+	if _, ok := s.Info("missing"); ok {
+		t.Errorf(`Info: expected no key info for a missing key`)
+	}
+}
+
+func testDefaults(t *testing.T, s kvstore.KeyValueStore) {
+	must(t, s.SetDefault("theme", "light", kvstore.KeyInfo{}))
+	expectValue(t, s, "theme", "light")
+	must(t, s.Set("theme", "dark"))
+	expectValue(t, s, "theme", "dark")
+	must(t, s.SetDefault("theme", "blue", kvstore.KeyInfo{}))
+	expectValue(t, s, "theme", "dark")
+}
+
+func testRevert(t *testing.T, s kvstore.KeyValueStore) {
+	must(t, s.SetDefault("theme", "light", kvstore.KeyInfo{}))
+	must(t, s.Set("theme", "dark"))
+	must(t, s.Revert("theme"))
+	expectValue(t, s, "theme", "light")
+	must(t, s.Revert("theme"))
+	expectValue(t, s, "theme", "light")
+	// a reverted key has no value of its own, so a later default takes effect
+	must(t, s.SetDefault("theme", "blue", kvstore.KeyInfo{}))
+	expectValue(t, s, "theme", "blue")
+	must(t, s.Set("size", 12))
+	// without a default, Revert may return NoDefaultErr but must not leave the value
+	if err := s.Revert("size"); err != nil && !errors.Is(err, kvstore.NoDefaultErr) {
+		t.Errorf(`Revert: expected nil or NoDefaultErr for a key without default, got %v`, err)
+	}
+	expectMissing(t, s, "size")
+}
+
+func testInfo(t *testing.T, s kvstore.KeyValueStore) {
+	info := kvstore.KeyInfo{Description: "the color theme", Category: "appearance"}
+	must(t, s.SetDefault("theme", "light", info))
+	got, ok := s.Info("theme")
+	if !ok || got.Description != info.Description || got.Category != info.Category {
+		t.Errorf(`Info: expected %+v, got %+v, %v`, info, got, ok)
+	}
+}
+
+func testSetMany(t *testing.T, s kvstore.KeyValueStore) {
+	must(t, s.Set("a", 0))
+	values := map[string]any{"a": 1, "b": "two", "c": 3.0}
+	must(t, s.SetMany(values))
+	for k, v := range values {
+		expectValue(t, s, k, v)
+	}
+	must(t, s.SetMany(map[string]any{}))
+}
+
+func testGetAll(t *testing.T, s kvstore.KeyValueStore) {
+	all, err := s.GetAll(0)
+	must(t, err)
+	if len(all) != 0 {
+		t.Errorf(`GetAll: expected no values in an empty store, got %v`, all)
+	}
+	must(t, s.SetDefault("a", 1, kvstore.KeyInfo{}))
+	must(t, s.Set("b", 2))
+	must(t, s.SetDefault("c", 0, kvstore.KeyInfo{}))
+	must(t, s.Set("c", 3))
+	all, err = s.GetAll(0)
+	must(t, err)
+	if want := map[string]any{"a": 1, "b": 2, "c": 3}; !reflect.DeepEqual(all, want) {
+		t.Errorf(`GetAll: expected values and defaults %v, got %v`, want, all)
+	}
+	all, err = s.GetAll(2)
+	must(t, err)
+	if len(all) != 2 {
+		t.Errorf(`GetAll: expected 2 values with limit 2, got %v`, all)
+	}
+}
+
+func testDelete(t *testing.T, s kvstore.KeyValueStore) {
+	must(t, s.SetDefault("theme", "light", kvstore.KeyInfo{Category: "appearance"}))
+	must(t, s.Set("theme", "dark"))
+	must(t, s.Delete("theme"))
+	expectMissing(t, s, "theme")
+	if _, ok := s.Info("theme"); ok {
+		t.Errorf(`Info: expected key info to be deleted with the key`)
+	}
+	must(t, s.Delete("missing"))
+}
+
+func testDeleteMany(t *testing.T, s kvstore.KeyValueStore) {
+	must(t, s.SetMany(map[string]any{"a": 1, "b": 2, "c": 3}))
+	must(t, s.SetDefault("d", 4, kvstore.KeyInfo{}))
+	must(t, s.DeleteMany([]string{"a", "d", "missing"}))
+	expectMissing(t, s, "a")
+	expectMissing(t, s, "d")
+	expectValue(t, s, "b", 2)
+	expectValue(t, s, "c", 3)
+}
+
+func testClosed(t *testing.T, s kvstore.KeyValueStore) {
+	must(t, s.Set("key", 1))
+	must(t, s.Close())
+	if err := s.Set("key", 2); !errors.Is(err, kvstore.NotOpenErr) {
+		t.Errorf(`Set: expected NotOpenErr after Close, got %v`, err)
+	}
+	if _, err := s.Get("key"); !errors.Is(err, kvstore.NotOpenErr) {
+		t.Errorf(`Get: expected NotOpenErr after Close, got %v`, err)
+	}
+	if _, ok := s.Info("key"); ok {
+		t.Errorf(`Info: expected no key info after Close`)
+	}
+}
@@ -0,0 +1,24 @@
+package kvtest
+
+import (
+	"testing"
+
+	"github.com/rasteric/kvstore"
+	"github.com/rasteric/kvstore/testkv"
+)
+
+func TestKVStore(t *testing.T) {
+	TestStore(t, func(t *testing.T) kvstore.KeyValueStore {
+		db := kvstore.New()
+		if err := db.Open(t.TempDir()); err != nil {
+			t.Fatalf(`open: %v`, err)
+		}
+		return db
+	})
+}
+
+func TestFake(t *testing.T) {
+	TestStore(t, func(t *testing.T) kvstore.KeyValueStore {
+		return testkv.New()
+	})
+}
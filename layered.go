@@ -0,0 +1,142 @@
+package kvstore
+
+import (
+	"errors"
+	"maps"
+	"slices"
+)
+
+// SourceGetter is implemented by key value stores that can tell explicitly set values from defaults.
+type SourceGetter interface {
+	GetWithSource(key string) (any, Source, error) // the value or default for key and where it comes from
+}
+
+// LayeredStore composes several key value stores into one, for example an application-wide store with read-only
+// defaults below a per-user store. Reads fall through the layers from the top layer to the bottom layer, and all
+// writes go to the top layer. A value set explicitly in any layer takes precedence over the defaults of all layers,
+// provided the layers implement SourceGetter; otherwise, whatever Get returns counts as a value.
+type LayeredStore struct {
+	layers []KeyValueStore
+}
+
+var _ KeyValueStore = (*LayeredStore)(nil)
+
+// NewLayeredStore returns a store with the given top layer, which receives all writes, above the given lower
+// layers in descending order of precedence. The lower layers are only read from.
+func NewLayeredStore(top KeyValueStore, lower ...KeyValueStore) *LayeredStore {
+	return &LayeredStore{layers: append([]KeyValueStore{top}, lower...)}
+}
+
+// Open opens the top layer at the given path. The lower layers must be opened by the application.
+func (s *LayeredStore) Open(path string) error {
+	return s.layers[0].Open(path)
+}
+
+// Close closes the top layer. The lower layers must be closed by the application.
+func (s *LayeredStore) Close() error {
+	return s.layers[0].Close()
+}
+
+// Set sets the value for the key in the top layer.
+func (s *LayeredStore) Set(key string, value any) error {
+	return s.layers[0].Set(key, value)
+}
+
+// Get returns the value for the key from the topmost layer in which it has been set explicitly, or else the
+// default from the topmost layer that has one. NotFoundErr is returned if no layer has a value or default.
+func (s *LayeredStore) Get(key string) (any, error) {
+	var def any
+	hasDefault := false
+	for _, layer := range s.layers {
+		if sg, ok := layer.(SourceGetter); ok {
+			v, source, err := sg.GetWithSource(key)
+			if err != nil && !errors.Is(err, NotFoundErr) {
+				return nil, err
+			}
+			switch source {
+			case SourceValue, SourceEnvironment, SourceFlag:
+				return v, nil
+			case SourceDefault:
+				if !hasDefault {
+					def, hasDefault = v, true
+				}
+			}
+			continue
+		}
+		v, err := layer.Get(key)
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, NotFoundErr) {
+			return nil, err
+		}
+	}
+	if hasDefault {
+		return def, nil
+	}
+	return nil, NotFoundErr
+}
+
+// SetMany sets all key-value pairs in the map in the top layer.
+func (s *LayeredStore) SetMany(pairs map[string]any) error {
+	return s.layers[0].SetMany(pairs)
+}
+
+// GetAll returns the key-value pairs of all layers as a map, with the value of each key chosen as by Get. If
+// limit is positive, only the limit first keys in ascending order are returned.
+func (s *LayeredStore) GetAll(limit int) (map[string]any, error) {
+	keys := make(map[string]struct{})
+	for _, layer := range s.layers {
+		pairs, err := layer.GetAll(0)
+		if err != nil {
+			return nil, err
+		}
+		for k := range pairs {
+			keys[k] = struct{}{}
+		}
+	}
+	sorted := slices.Sorted(maps.Keys(keys))
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	result := make(map[string]any, len(sorted))
+	for _, k := range sorted {
+		v, err := s.Get(k)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = v
+	}
+	return result, nil
+}
+
+// Revert reverts the key to its default in the top layer, so that values set in lower layers take effect
+// again.
+func (s *LayeredStore) Revert(key string) error {
+	return s.layers[0].Revert(key)
+}
+
+// Info returns the key information from the topmost layer that has it.
+func (s *LayeredStore) Info(key string) (KeyInfo, bool) {
+	for _, layer := range s.layers {
+		if info, ok := layer.Info(key); ok {
+			return info, true
+		}
+	}
+	return KeyInfo{}, false
+}
+
+// Delete removes the key from the top layer. Values and defaults of lower layers are not affected.
+func (s *LayeredStore) Delete(key string) error {
+	return s.layers[0].Delete(key)
+}
+
+// DeleteMany removes the keys from the top layer in one transaction.
+func (s *LayeredStore) DeleteMany(keys []string) error {
+	return s.layers[0].DeleteMany(keys)
+}
+
+// SetDefault sets a default and key info for the key in the top layer.
+func (s *LayeredStore) SetDefault(key string, value any, info KeyInfo) error {
+	return s.layers[0].SetDefault(key, value, info)
+}
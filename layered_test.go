@@ -0,0 +1,93 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLayeredStore(t *testing.T) {
+	system := New()
+	if err := system.Open(InMemory); err != nil {
+		t.Fatalf(`open system layer: %v`, err)
+	}
+	defer system.Close()
+	user := New()
+	store := NewLayeredStore(user, system)
+	if err := store.Open(InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer store.Close()
+
+	if err := system.Set("proxy", "proxy.example.com"); err != nil {
+		t.Fatalf(`set: %v`, err)
+	}
+	if err := system.SetDefault("theme", "light", KeyInfo{Description: "color theme", Category: "ui"}); err != nil {
+		t.Fatalf(`set default: %v`, err)
+	}
+	if err := store.SetDefault("proxy", "", KeyInfo{Category: "network"}); err != nil {
+		t.Fatalf(`set default: %v`, err)
+	}
+	if v, err := store.Get("proxy"); err != nil || v != "proxy.example.com" {
+		t.Errorf(`expected value of lower layer to take precedence over default, got %v, %v`, v, err)
+	}
+	if v, err := store.Get("theme"); err != nil || v != "light" {
+		t.Errorf(`expected default of lower layer, got %v, %v`, v, err)
+	}
+	if err := store.Set("theme", "dark"); err != nil {
+		t.Fatalf(`set: %v`, err)
+	}
+	if v, err := store.Get("theme"); err != nil || v != "dark" {
+		t.Errorf(`expected value of top layer, got %v, %v`, v, err)
+	}
+	if v, err := system.Get("theme"); err != nil || v != "light" {
+		t.Errorf(`expected lower layer to be unchanged, got %v, %v`, v, err)
+	}
+	if info, ok := store.Info("theme"); !ok || info.Category != "ui" {
+		t.Errorf(`expected info of lower layer, got %v, %v`, info, ok)
+	}
+	all, err := store.GetAll(0)
+	if err != nil || len(all) != 2 || all["theme"] != "dark" || all["proxy"] != "proxy.example.com" {
+		t.Errorf(`unexpected pairs %v, %v`, all, err)
+	}
+	if all, err := store.GetAll(1); err != nil || len(all) != 1 || all["proxy"] == nil {
+		t.Errorf(`expected first key only, got %v, %v`, all, err)
+	}
+	if err := store.Delete("theme"); err != nil {
+		t.Fatalf(`delete: %v`, err)
+	}
+	if v, err := store.Get("theme"); err != nil || v != "light" {
+		t.Errorf(`expected lower layer after delete, got %v, %v`, v, err)
+	}
+	if _, err := store.Get("missing"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+}
+
+func TestLayeredStoreRevert(t *testing.T) {
+	lower := New()
+	if err := lower.Open(InMemory); err != nil {
+		t.Fatalf(`open lower layer: %v`, err)
+	}
+	defer lower.Close()
+	top := New()
+	store := NewLayeredStore(top, lower)
+	if err := store.Open(InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer store.Close()
+	if err := store.SetDefault("theme", "light", KeyInfo{}); err != nil {
+		t.Fatalf(`set default: %v`, err)
+	}
+	if err := lower.Set("theme", "system-dark"); err != nil {
+		t.Fatalf(`set: %v`, err)
+	}
+	if err := store.Set("theme", "dark"); err != nil {
+		t.Fatalf(`set: %v`, err)
+	}
+	if err := store.Revert("theme"); err != nil {
+		t.Fatalf(`revert: %v`, err)
+	}
+	if v, err := store.Get("theme"); err != nil || v != "system-dark" {
+		t.Errorf(`expected value of lower layer after revert, got %v, %v`, v, err)
+	}
+}
@@ -0,0 +1,141 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Lists are stored separately from ordinary values, one row per element, so that pushing and popping elements does
+// not rewrite the whole list. Lists are not visible to Has, Get, Delete, GetAll, Rename, or the TTL methods, but a
+// key cannot hold a list and an ordinary value, set, hash, or streamed value at the same time; ListPush returns
+// KeyExistsErr for a key holding something else, and Set for a key holding a list. Elements must be gob
+// serializable like values.
+
+// initLists creates the table holding list elements.
+func initLists(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_list(
+  key TEXT NOT NULL,
+  pos INTEGER NOT NULL,
+  value BLOB,
+  PRIMARY KEY(key,pos)
+) WITHOUT ROWID;
+`)
+	return err
+}
+
+// ListPush appends the given values to the end of the list stored at key in one transaction, creating the list
+// if necessary.
+func (db *KVStore) ListPush(key string, values ...any) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := checkKind(tx, key, "list"); err != nil {
+		return err
+	}
+	var last int64
+	if err := tx.Get(&last, `SELECT COALESCE(MAX(pos),0) FROM kv_list WHERE key=?;`, key); err != nil {
+		return err
+	}
+	for _, v := range values {
+		b, err := db.encode(v)
+		if err != nil {
+			return err
+		}
+		last++
+		if _, err := tx.Exec(`INSERT INTO kv_list(key,pos,value) VALUES(?,?,?);`, key, last, b); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListPop removes and returns the last element of the list stored at key, NotFoundErr if the list is empty.
+func (db *KVStore) ListPop(key string) (any, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	var b []byte
+	var pos int64
+	err = tx.QueryRowx(`SELECT value,pos FROM kv_list WHERE key=? ORDER BY pos DESC LIMIT 1;`, key).Scan(&b, &pos)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, NotFoundErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	v, err := UnmarshalBinary(b)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM kv_list WHERE key=? AND pos=?;`, key, pos); err != nil {
+		return nil, err
+	}
+	return v, tx.Commit()
+}
+
+// ListRange returns the elements of the list stored at key from index start up to but not including index stop,
+// or up to the end of the list if stop is negative. Indices start at 0 with the first element pushed.
+func (db *KVStore) ListRange(key string, start, stop int) ([]any, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	limit := -1
+	if stop >= 0 {
+		limit = max(stop-start, 0)
+	}
+	rows, err := db.sqx.Queryx(`SELECT value FROM kv_list WHERE key=? ORDER BY pos LIMIT ? OFFSET ?;`,
+		key, limit, max(start, 0))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]any, 0)
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return result, err
+		}
+		v, err := UnmarshalBinary(b)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, v)
+	}
+	return result, rows.Err()
+}
+
+// ListLen returns the number of elements of the list stored at key, 0 if there is no such list.
+func (db *KVStore) ListLen(key string) (int, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return 0, NotOpenErr
+	}
+	var n int
+	err := db.sqx.Get(&n, `SELECT COUNT(*) FROM kv_list WHERE key=?;`, key)
+	return n, err
+}
+
+// ListTrim removes the oldest elements from the list stored at key so that at most the n last pushed elements
+// remain. ListTrim with n set to 0 removes the list. Pushing and trimming maintains a list of most recently used
+// items such as recent files.
+func (db *KVStore) ListTrim(key string, n int) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	_, err := db.exec(`DELETE FROM kv_list WHERE key=? AND pos NOT IN
+(SELECT pos FROM kv_list WHERE key=? ORDER BY pos DESC LIMIT ?);`, key, key, max(n, 0))
+	return err
+}
@@ -0,0 +1,40 @@
+package kvstore
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestList(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.ListPush("recent", "a.txt", "b.txt", "c.txt"); err != nil {
+		t.Fatalf(`failed to push: %v`, err)
+	}
+	if err := db.ListPush("recent", "d.txt"); err != nil {
+		t.Fatalf(`failed to push: %v`, err)
+	}
+	if n, err := db.ListLen("recent"); n != 4 || err != nil {
+		t.Errorf(`expected length 4, got %v, %v`, n, err)
+	}
+	items, err := db.ListRange("recent", 1, 3)
+	if err != nil || !reflect.DeepEqual(items, []any{"b.txt", "c.txt"}) {
+		t.Errorf(`expected [b.txt c.txt], got %v, %v`, items, err)
+	}
+	if v, err := db.ListPop("recent"); v != "d.txt" || err != nil {
+		t.Errorf(`expected d.txt, got %v, %v`, v, err)
+	}
+	if err := db.ListTrim("recent", 2); err != nil {
+		t.Fatalf(`failed to trim: %v`, err)
+	}
+	items, err = db.ListRange("recent", 0, -1)
+	if err != nil || !reflect.DeepEqual(items, []any{"b.txt", "c.txt"}) {
+		t.Errorf(`expected [b.txt c.txt] after trim, got %v, %v`, items, err)
+	}
+	if err := db.ListTrim("recent", 0); err != nil {
+		t.Fatalf(`failed to trim: %v`, err)
+	}
+	if _, err := db.ListPop("recent"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr for empty list, got %v`, err)
+	}
+}
@@ -0,0 +1,70 @@
+package kvstore
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Loader loads the value of a key from an external source, e.g. a remote configuration service. It returns
+// NotFoundErr if the source has no value for the key.
+type Loader func(key string) (any, error)
+
+// loaders holds the loaders registered in this process.
+type loaders struct {
+	mutex    sync.RWMutex
+	prefixes map[string]Loader
+}
+
+// RegisterLoader registers a loader for all keys starting with the given prefix, turning the store into a
+// persistent read-through cache for them: if Get finds neither a value nor a default for such a key, the loader
+// is called, its value is set for the key as if by Set, and returned. If the loader or setting the value fails,
+// Get returns the error. If several prefixes match a key, the loader of the longest one is used. Registering a
+// loader for the same prefix again replaces it, and a nil loader removes it. Concurrent Get calls for the same
+// missing key may each call the loader.
+func (db *KVStore) RegisterLoader(prefix string, load Loader) {
+	db.loaders.mutex.Lock()
+	defer db.loaders.mutex.Unlock()
+	if load == nil {
+		delete(db.loaders.prefixes, prefix)
+		return
+	}
+	if db.loaders.prefixes == nil {
+		db.loaders.prefixes = make(map[string]Loader)
+	}
+	db.loaders.prefixes[prefix] = load
+}
+
+// loader returns the loader registered for the longest prefix of the key, nil if there is none.
+func (db *KVStore) loader(key string) Loader {
+	db.loaders.mutex.RLock()
+	defer db.loaders.mutex.RUnlock()
+	var load Loader
+	longest := -1
+	for prefix, l := range db.loaders.prefixes {
+		if len(prefix) > longest && strings.HasPrefix(key, prefix) {
+			load, longest = l, len(prefix)
+		}
+	}
+	return load
+}
+
+// load calls the loader for a key that was not found and sets the loaded value. It returns the given
+// error if the error is not NotFoundErr or no loader is registered for the key.
+func (db *KVStore) load(key string, err error) (any, error) {
+	if !errors.Is(err, NotFoundErr) {
+		return nil, err
+	}
+	load := db.loader(key)
+	if load == nil {
+		return nil, err
+	}
+	v, err := load(key)
+	if err != nil {
+		return nil, keyError("load", key, err)
+	}
+	if err := db.Set(key, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,36 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegisterLoader(t *testing.T) {
+	db := openTestStore(t)
+	calls := 0
+	db.RegisterLoader("remote.", func(key string) (any, error) {
+		calls++
+		return "loaded " + key, nil
+	})
+	db.RegisterLoader("remote.missing.", func(key string) (any, error) {
+		return nil, NotFoundErr
+	})
+	for range 2 {
+		if v, err := db.Get("remote.a"); err != nil || v != "loaded remote.a" {
+			t.Errorf(`expected loaded value, got %v, %v`, v, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf(`expected loaded value to be stored, loader was called %v times`, calls)
+	}
+	if _, err := db.Get("remote.missing.b"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr from longest prefix loader, got %v`, err)
+	}
+	if _, err := db.Get("local"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr for key without loader, got %v`, err)
+	}
+	db.RegisterLoader("remote.", nil)
+	if _, err := db.Get("remote.c"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr after removing loader, got %v`, err)
+	}
+}
@@ -0,0 +1,64 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var KeyLockedErr = errors.New(`key is locked`)
+
+// Lock locks the given key, so that setting its value fails with KeyLockedErr until it is unlocked. This allows
+// administrators to pin preferences against accidental change. Locking is recorded in the key's KeyInfo and can
+// also be set with SetDefault.
+func (db *KVStore) Lock(key string) error {
+	return db.setLocked(key, true)
+}
+
+// Unlock unlocks a key locked with Lock.
+func (db *KVStore) Unlock(key string) error {
+	return db.setLocked(key, false)
+}
+
+// ForceSet sets the value for the given key like Set, even if the key is locked.
+func (db *KVStore) ForceSet(key string, value any) (err error) {
+	defer db.observe(opSet, key, 1, time.Now(), &err)
+	return keyError("set", key, db.set(key, value, true))
+}
+
+// setLocked sets the Locked flag of a key's info.
+func (db *KVStore) setLocked(key string, locked bool) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var b []byte
+	err = tx.Get(&b, `SELECT extra FROM kv WHERE key=?;`, key)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	var info KeyInfo
+	if err := info.unmarshalExtra(b); err != nil {
+		return err
+	}
+	info.Locked = locked
+	extra, err := info.marshalExtra()
+	if err != nil {
+		return err
+	}
+	now := db.now().UnixMilli()
+	_, err = tx.Exec(`INSERT INTO kv(key,extra,created_at,updated_at) VALUES(?,?,?,?)
+ON CONFLICT(key) DO UPDATE SET extra=excluded.extra;`, key, extra, now, now)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,37 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.SetDefault("pinned", 1, KeyInfo{Description: "pinned", Locked: true}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.Set("pinned", 2); !errors.Is(err, KeyLockedErr) {
+		t.Errorf(`expected KeyLockedErr, got %v`, err)
+	}
+	if err := db.SetMany(map[string]any{"pinned": 2, "other": 3}); !errors.Is(err, KeyLockedErr) {
+		t.Errorf(`expected KeyLockedErr, got %v`, err)
+	}
+	if err := db.ForceSet("pinned", 4); err != nil {
+		t.Fatalf(`failed to force set: %v`, err)
+	}
+	if err := db.Unlock("pinned"); err != nil {
+		t.Fatalf(`failed to unlock key: %v`, err)
+	}
+	if err := db.Set("pinned", 5); err != nil {
+		t.Fatalf(`failed to set unlocked key: %v`, err)
+	}
+	if info, ok := db.Info("pinned"); !ok || info.Locked || info.Description != "pinned" {
+		t.Errorf(`unexpected key info after unlocking: %+v`, info)
+	}
+	if err := db.Lock("new"); err != nil {
+		t.Fatalf(`failed to lock key: %v`, err)
+	}
+	if err := db.Set("new", 1); !errors.Is(err, KeyLockedErr) {
+		t.Errorf(`expected KeyLockedErr, got %v`, err)
+	}
+}
@@ -0,0 +1,106 @@
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+var IntegrityErr = errors.New(`database integrity check failed`)
+var CheckpointBusyErr = errors.New(`WAL checkpoint could not be completed because the database is busy`)
+
+// Vacuum rebuilds the database file, repacking it into a minimal amount of disk space.
+// This may take a while for large databases and requires as much free disk space as the database occupies.
+func (db *KVStore) Vacuum() error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	_, err := db.exec(`VACUUM;`)
+	return err
+}
+
+// Backup writes a consistent copy of the database to the file at path, which is replaced if it exists. The copy
+// is written to a temporary file next to path first, so that an existing backup is not lost if writing fails.
+// Values stored as files with ExternalValues are not part of the copy.
+func (db *KVStore) Backup(path string) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if _, err := db.exec(`VACUUM INTO ?;`, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// WALCheckpoint transfers all transactions in the write-ahead log into the database and truncates
+// the log. CheckpointBusyErr is returned if the checkpoint could not be completed because of
+// concurrent readers or writers, in which case it is safe to try again later.
+func (db *KVStore) WALCheckpoint() error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	var busy, log, checkpointed int
+	err := db.sqx.QueryRowx(`PRAGMA wal_checkpoint(TRUNCATE);`).Scan(&busy, &log, &checkpointed)
+	if err != nil {
+		return err
+	}
+	if busy != 0 {
+		return CheckpointBusyErr
+	}
+	return nil
+}
+
+// IntegrityCheck runs sqlite's integrity check and verifies that every stored value and default
+// can be decoded. If problems are found, an error wrapping IntegrityErr is returned for each of them,
+// combined with errors.Join.
+func (db *KVStore) IntegrityCheck() error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	var messages []string
+	err := db.sqx.Select(&messages, `PRAGMA integrity_check;`)
+	if err != nil {
+		return err
+	}
+	var result error
+	for _, msg := range messages {
+		if msg != "ok" {
+			result = errors.Join(result, fmt.Errorf("%w: %s", IntegrityErr, msg))
+		}
+	}
+	rows, err := db.sqx.Queryx(`SELECT key,` + db.valueColumns() + ` FROM kv;`)
+	if err != nil {
+		return errors.Join(result, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var sv storedValue
+		if err := rows.Scan(append([]any{&key}, sv.dest()...)...); err != nil {
+			return errors.Join(result, err)
+		}
+		if sv.value != nil {
+			if _, err := sv.decodeValue(); err != nil {
+				result = errors.Join(result, fmt.Errorf("%w: value of key %q cannot be decoded: %w", IntegrityErr, key, err))
+			}
+		}
+		if sv.original != nil {
+			if _, err := UnmarshalBinary(sv.original); err != nil {
+				result = errors.Join(result, fmt.Errorf("%w: default of key %q cannot be decoded: %w", IntegrityErr, key, err))
+			}
+		}
+	}
+	return errors.Join(result, rows.Err())
+}
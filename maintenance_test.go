@@ -0,0 +1,32 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMaintenance(t *testing.T) {
+	db := openTestStore(t)
+	for i := 0; i < 100; i++ {
+		key, _ := generateRandomHex(8)
+		if err := db.Set(key, i); err != nil {
+			t.Errorf(`failed to set key: %v`, err)
+		}
+	}
+	if err := db.IntegrityCheck(); err != nil {
+		t.Errorf(`integrity check failed on healthy database: %v`, err)
+	}
+	if err := db.WALCheckpoint(); err != nil {
+		t.Errorf(`WAL checkpoint failed: %v`, err)
+	}
+	if err := db.Vacuum(); err != nil {
+		t.Errorf(`vacuum failed: %v`, err)
+	}
+	_, err := db.sqx.Exec(`INSERT INTO kv(key,value) VALUES(?,?);`, "broken", []byte{1, 2, 3})
+	if err != nil {
+		t.Fatalf(`failed to insert broken value: %v`, err)
+	}
+	if err := db.IntegrityCheck(); !errors.Is(err, IntegrityErr) {
+		t.Errorf(`expected IntegrityErr for undecodable value, got %v`, err)
+	}
+}
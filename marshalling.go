@@ -2,12 +2,161 @@ package kvstore
 
 import (
 	"bytes"
+	"encoding"
+	"encoding/binary"
 	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
 )
 
+var NotMarshalerErr = errors.New(`type does not implement encoding.BinaryMarshaler or encoding.TextMarshaler symmetrically`)
+var UnknownMarshalerErr = errors.New(`no type registered with RegisterMarshaler under the given name`)
+
+func init() {
+	gob.Register(time.Time{})
+}
+
+// Values of types registered with RegisterMarshaler are encoded as a zero byte, which cannot start a gob stream,
+// followed by the kind of marshaler, the length of the registered name as uvarint, the name, and the data
+// returned by the marshaler.
+const (
+	marshalerMark   = 0x00
+	marshalerBinary = 'b'
+	marshalerText   = 't'
+)
+
+// marshalers holds the types registered with RegisterMarshaler by name and the names by type.
+var marshalers = struct {
+	sync.RWMutex
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}{types: make(map[string]reflect.Type), names: make(map[reflect.Type]string)}
+
+// RegisterMarshaler registers the type of prototype under the given name, so that values of the type are encoded
+// with their own MarshalBinary method instead of gob, or with MarshalText if the type only implements
+// encoding.TextMarshaler. This gives types with a canonical encoding, such as UUIDs or decimals, a stable
+// representation that does not depend on their Go definition or package path. The encoding is decoded with
+// UnmarshalBinary or UnmarshalText of a new value of the type, which must therefore be implemented by the
+// pointer type; NotMarshalerErr is returned otherwise. The type must be registered under the same name before
+// values are read in another process, which returns an error wrapping UnknownMarshalerErr otherwise.
+func RegisterMarshaler(name string, prototype any) error {
+	t := reflect.TypeOf(prototype)
+	if t == nil || name == "" {
+		return NotMarshalerErr
+	}
+	if _, ok := marshalerKind(t); !ok {
+		return fmt.Errorf("%w: %v", NotMarshalerErr, t)
+	}
+	marshalers.Lock()
+	defer marshalers.Unlock()
+	if old, ok := marshalers.types[name]; ok {
+		delete(marshalers.names, old)
+	}
+	marshalers.types[name] = t
+	marshalers.names[t] = name
+	return nil
+}
+
+// marshalerKind returns the kind of marshaler used for values of type t, and false if t does not implement
+// a marshaler together with the corresponding unmarshaler.
+func marshalerKind(t reflect.Type) (byte, bool) {
+	target := reflect.PointerTo(t)
+	if t.Kind() == reflect.Pointer {
+		target = t
+	}
+	binaryMarshaler := reflect.TypeFor[encoding.BinaryMarshaler]()
+	textMarshaler := reflect.TypeFor[encoding.TextMarshaler]()
+	switch {
+	case t.Implements(binaryMarshaler) && target.Implements(reflect.TypeFor[encoding.BinaryUnmarshaler]()):
+		return marshalerBinary, true
+	case t.Implements(textMarshaler) && target.Implements(reflect.TypeFor[encoding.TextUnmarshaler]()):
+		return marshalerText, true
+	}
+	return 0, false
+}
+
+// marshal encodes a value of a type registered with RegisterMarshaler, and returns false for other values.
+func marshal(v any) ([]byte, bool, error) {
+	if v == nil {
+		return nil, false, nil
+	}
+	t := reflect.TypeOf(v)
+	marshalers.RLock()
+	name, ok := marshalers.names[t]
+	marshalers.RUnlock()
+	if !ok {
+		return nil, false, nil
+	}
+	kind, _ := marshalerKind(t)
+	var data []byte
+	var err error
+	if kind == marshalerBinary {
+		data, err = v.(encoding.BinaryMarshaler).MarshalBinary()
+	} else {
+		data, err = v.(encoding.TextMarshaler).MarshalText()
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	b := make([]byte, 0, 2+binary.MaxVarintLen64+len(name)+len(data))
+	b = append(b, marshalerMark, kind)
+	b = binary.AppendUvarint(b, uint64(len(name)))
+	b = append(b, name...)
+	return append(b, data...), true, nil
+}
+
+// unmarshal decodes a value encoded by marshal.
+func unmarshal(b []byte) (any, error) {
+	if len(b) < 3 {
+		return nil, fmt.Errorf("%w: truncated value", IntegrityErr)
+	}
+	kind := b[1]
+	n, size := binary.Uvarint(b[2:])
+	if size <= 0 || uint64(len(b)-2-size) < n {
+		return nil, fmt.Errorf("%w: truncated value", IntegrityErr)
+	}
+	name := string(b[2+size : 2+size+int(n)])
+	data := b[2+size+int(n):]
+	marshalers.RLock()
+	t, ok := marshalers.types[name]
+	marshalers.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", UnknownMarshalerErr, name)
+	}
+	var p reflect.Value
+	if t.Kind() == reflect.Pointer {
+		p = reflect.New(t.Elem())
+	} else {
+		p = reflect.New(t)
+	}
+	var err error
+	switch kind {
+	case marshalerBinary:
+		err = p.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+	case marshalerText:
+		err = p.Interface().(encoding.TextUnmarshaler).UnmarshalText(data)
+	default:
+		err = fmt.Errorf("%w: unknown marshaler kind %q", IntegrityErr, kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Kind() == reflect.Pointer {
+		return p.Interface(), nil
+	}
+	return p.Elem().Interface(), nil
+}
+
 // MarshalBinary uses gob encoding to marshal a value to a byte slice. To encode
-// structs, use gob.Register(yourstruct{}) to register them.
+// structs, use gob.Register(yourstruct{}) to register them. Values of types registered
+// with RegisterMarshaler are encoded with their own marshaler instead.
 func MarshalBinary(v any) ([]byte, error) {
+	if b, ok, err := marshal(v); ok {
+		return b, err
+	}
 	var buf bytes.Buffer
 	enc := gob.NewEncoder(&buf)
 	err := enc.Encode(&v)
@@ -18,8 +167,19 @@ func MarshalBinary(v any) ([]byte, error) {
 }
 
 // UnmarshalBinary assumes that a gob encoded byte slice is given and nmarshals it into a variable
-// of the empty interface type, returns an error if the data is malformed.
+// of the empty interface type, returns an error if the data is malformed. Values encoded with the
+// marshaler of a type registered with RegisterMarshaler are decoded with its unmarshaler.
 func UnmarshalBinary(b []byte) (any, error) {
+	if isEncrypted(b) {
+		plain, err := decrypt(b)
+		if err != nil {
+			return nil, err
+		}
+		return UnmarshalBinary(plain)
+	}
+	if len(b) > 0 && b[0] == marshalerMark {
+		return unmarshal(b)
+	}
 	dec := gob.NewDecoder(bytes.NewReader(b))
 	var v any
 	err := dec.Decode(&v)
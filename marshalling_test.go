@@ -0,0 +1,76 @@
+package kvstore
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testUUID has a canonical text encoding.
+type testUUID [16]byte
+
+func (u testUUID) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(u[:])), nil
+}
+
+func (u *testUUID) UnmarshalText(b []byte) error {
+	_, err := hex.Decode(u[:], b)
+	return err
+}
+
+// testDecimal has a canonical binary encoding.
+type testDecimal struct {
+	units int64
+	scale uint8
+}
+
+func (d testDecimal) MarshalBinary() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d:%d", d.units, d.scale)), nil
+}
+
+func (d *testDecimal) UnmarshalBinary(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "%d:%d", &d.units, &d.scale)
+	return err
+}
+
+func TestRegisterMarshaler(t *testing.T) {
+	if err := RegisterMarshaler("test.uuid", testUUID{}); err != nil {
+		t.Fatalf(`register: %v`, err)
+	}
+	if err := RegisterMarshaler("test.decimal", testDecimal{}); err != nil {
+		t.Fatalf(`register: %v`, err)
+	}
+	if err := RegisterMarshaler("test.int", 1); !errors.Is(err, NotMarshalerErr) {
+		t.Errorf(`expected NotMarshalerErr, got %v`, err)
+	}
+	uuid := testUUID{1, 2, 3, 15: 255}
+	b, err := MarshalBinary(uuid)
+	if err != nil {
+		t.Fatalf(`marshal: %v`, err)
+	}
+	if want := "\x00t\x09test.uuid" + hex.EncodeToString(uuid[:]); string(b) != want {
+		t.Errorf(`expected canonical encoding %q, got %q`, want, b)
+	}
+
+	db := New()
+	if err := db.Open(InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer db.Close()
+	if err := db.Set("id", uuid); err != nil {
+		t.Fatalf(`set: %v`, err)
+	}
+	if err := db.SetDefault("price", testDecimal{units: 1999, scale: 2}, KeyInfo{}); err != nil {
+		t.Fatalf(`set default: %v`, err)
+	}
+	if v, err := db.Get("id"); err != nil || v != uuid {
+		t.Errorf(`expected %v, got %v, %v`, uuid, v, err)
+	}
+	if v, err := db.Get("price"); err != nil || v != (testDecimal{units: 1999, scale: 2}) {
+		t.Errorf(`expected decimal, got %v, %v`, v, err)
+	}
+	if _, err := UnmarshalBinary([]byte("\x00b\x07unknownxyz")); !errors.Is(err, UnknownMarshalerErr) {
+		t.Errorf(`expected UnknownMarshalerErr, got %v`, err)
+	}
+}
@@ -0,0 +1,48 @@
+package kvstore
+
+import (
+	"errors"
+	"regexp"
+	"sync/atomic"
+)
+
+var UnknownSyntaxErr = errors.New(`unknown pattern syntax`)
+
+// MatchSyntax specifies how patterns passed to KeysMatching are interpreted.
+type MatchSyntax int
+
+const (
+	MatchGlob   MatchSyntax = iota // Unix glob syntax with *, ?, and [...], case-sensitive
+	MatchLike                      // SQL LIKE syntax with % and _, case-insensitive for ASCII characters
+	MatchRegexp                    // regular expression in the syntax of Go's regexp package
+)
+
+// KeysMatching returns all keys in ascending order that match the pattern in the given syntax. For example,
+// the glob pattern "plugin.*.enabled" matches the key "plugin.spellcheck.enabled". Regular expressions match
+// if they match any part of the key, use ^ and $ to match the whole key.
+func (db *KVStore) KeysMatching(pattern string, syntax MatchSyntax) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	var op string
+	switch syntax {
+	case MatchGlob:
+		op = `GLOB`
+	case MatchLike:
+		op = `LIKE`
+	case MatchRegexp:
+		op = `REGEXP`
+	default:
+		return nil, UnknownSyntaxErr
+	}
+	return db.liveKeys(`kv.key `+op+` ?`, `ORDER BY kv.key ASC`, pattern)
+}
+
+// matchRegexp implements the SQL function regexp, which sqlite calls for the REGEXP operator with the pattern as
+// first argument. Each driver registers it on new connections.
+func matchRegexp(pattern, s string) (bool, error) {
+	return regexp.MatchString(pattern, s)
+}
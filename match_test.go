@@ -0,0 +1,39 @@
+package kvstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeysMatching(t *testing.T) {
+	db := openTestStore(t)
+	for _, key := range []string{"plugin.spell.enabled", "plugin.Sync.enabled", "plugin.sync.interval", "theme"} {
+		if err := db.Set(key, true); err != nil {
+			t.Fatalf(`failed to set key: %v`, err)
+		}
+	}
+	tests := []struct {
+		pattern  string
+		syntax   MatchSyntax
+		expected string
+	}{
+		{"plugin.*.enabled", MatchGlob, "plugin.Sync.enabled,plugin.spell.enabled"},
+		{"plugin.s%", MatchLike, "plugin.Sync.enabled,plugin.spell.enabled,plugin.sync.interval"},
+		{`^plugin\.[a-z]+\.enabled$`, MatchRegexp, "plugin.spell.enabled"},
+		{"nothing*", MatchGlob, ""},
+	}
+	for _, test := range tests {
+		keys, err := db.KeysMatching(test.pattern, test.syntax)
+		if err != nil {
+			t.Errorf(`failed to match %v: %v`, test.pattern, err)
+			continue
+		}
+		if got := strings.Join(keys, ","); got != test.expected {
+			t.Errorf(`pattern %v: expected %v, got %v`, test.pattern, test.expected, got)
+		}
+	}
+	if _, err := db.KeysMatching("x", MatchSyntax(99)); !errors.Is(err, UnknownSyntaxErr) {
+		t.Errorf(`expected UnknownSyntaxErr, got %v`, err)
+	}
+}
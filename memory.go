@@ -0,0 +1,39 @@
+package kvstore
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// InMemory is passed to Open instead of a directory to create a store that is held in memory only, with the
+// same features as a store on disk except values kept in external files. The data is lost when the store is
+// closed. Each store opened with InMemory has a database of its own.
+const InMemory = ":memory:"
+
+// memoryStores counts the in-memory databases created, so that each store gets a unique one.
+var memoryStores atomic.Uint64
+
+// memoryDataSourceName creates a new in-memory database shared by all connections of the store and returns its
+// sqlite URI.
+func (db *KVStore) memoryDataSourceName() (string, error) {
+	if db.opts.externalThreshold > 0 {
+		return "", fmt.Errorf("%w: ExternalValues", UnsupportedErr)
+	}
+	name := fmt.Sprintf("kvstore-%d", memoryStores.Add(1))
+	dsn, release, err := openMemory(name, db.opts.retry.busyTimeout())
+	if err != nil {
+		return "", err
+	}
+	db.path = ""
+	db.memory, db.releaseMemory = name, release
+	return dsn, nil
+}
+
+// closeMemory releases the in-memory database of the store, if any.
+func (db *KVStore) closeMemory() {
+	if db.memory == "" {
+		return
+	}
+	db.releaseMemory()
+	db.memory, db.releaseMemory = "", nil
+}
@@ -0,0 +1,39 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInMemory(t *testing.T) {
+	db := New(Deduplicate(1))
+	other := New()
+	for _, store := range []*KVStore{db, other} {
+		if err := store.Open(InMemory); err != nil {
+			t.Fatalf(`failed to open database: %v`, err)
+		}
+		defer store.Close()
+	}
+	if err := db.Set("a", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if v, err := db.Get("a"); v != 1 || err != nil {
+		t.Errorf(`expected 1, got %v, %v`, v, err)
+	}
+	if _, err := other.Get("a"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected in-memory stores to be separate, got %v`, err)
+	}
+	if stats, err := db.Stats(); err != nil || stats.FileSize <= 0 {
+		t.Errorf(`expected size of database in memory, got %v, %v`, stats.FileSize, err)
+	}
+	db.Close()
+	if err := db.Open(InMemory); err != nil {
+		t.Fatalf(`failed to reopen database: %v`, err)
+	}
+	if _, err := db.Get("a"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected data to be lost on Close, got %v`, err)
+	}
+	if err := New(ExternalValues(10)).Open(InMemory); !errors.Is(err, UnsupportedErr) {
+		t.Errorf(`expected UnsupportedErr, got %v`, err)
+	}
+}
@@ -0,0 +1,254 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Conflict describes a key that has different values in the two stores being merged.
+type Conflict struct {
+	Key            string
+	Ours           any       // the value in the store merged into
+	Theirs         any       // the value in the source store
+	OursModified   time.Time // last modification of our value, zero if unknown
+	TheirsModified time.Time // last modification of their value, zero if unknown
+	Base           any       // the value in the common base of a three-way merge, see Merge3
+	Resolved       any       // the value chosen by the policy, only set in conflicts returned by Merge3
+}
+
+// MergePolicy resolves a merge conflict by returning the value that should be stored for the key.
+type MergePolicy func(c Conflict) (any, error)
+
+// ModTimer is implemented by key value stores that keep track of when keys were last modified.
+type ModTimer interface {
+	ModTime(key string) (time.Time, error) // the time the key was last modified
+}
+
+var (
+	// MergeOurs keeps the existing values in case of conflicts.
+	MergeOurs MergePolicy = func(c Conflict) (any, error) { return c.Ours, nil }
+	// MergeTheirs overwrites existing values with values from the source store.
+	MergeTheirs MergePolicy = func(c Conflict) (any, error) { return c.Theirs, nil }
+	// MergeNewest keeps the value that was modified last. If modification times are unknown, existing values are kept.
+	MergeNewest MergePolicy = func(c Conflict) (any, error) {
+		if c.TheirsModified.After(c.OursModified) {
+			return c.Theirs, nil
+		}
+		return c.Ours, nil
+	}
+)
+
+// Merge copies all values set explicitly in src into the store in one transaction. Keys that have a value in both
+// stores that differs are resolved with the given policy, which may be one of MergeOurs, MergeTheirs,
+// MergeNewest, or a custom function. Defaults are neither copied nor compared; if src does not implement
+// SourceGetter, everything returned by its GetAll counts as a value. Modification times are only available if
+// the stores implement ModTimer. Sensitive values of a KVStore opened with RedactSensitive are merged as they are,
+// whereas values redacted by other stores are skipped so that Redacted never overwrites an actual value.
+func (db *KVStore) Merge(src KeyValueStore, policy MergePolicy) error {
+	theirs, err := readValues(src)
+	if err != nil {
+		return err
+	}
+	for k, v := range theirs {
+		if v == Redacted {
+			delete(theirs, k)
+		}
+	}
+	return db.Update(func(tx Tx) error {
+		t := tx.(*txStore)
+		ours, err := t.values()
+		if err != nil {
+			return err
+		}
+		for _, k := range slices.Sorted(maps.Keys(theirs)) {
+			v := theirs[k]
+			existing, ok := ours[k]
+			if ok && reflect.DeepEqual(existing, v) {
+				continue
+			}
+			if ok {
+				c := Conflict{Key: k, Ours: existing, Theirs: v}
+				if c.OursModified, err = t.modTime(k); err != nil {
+					return err
+				}
+				if c.TheirsModified, err = modTime(src, k); err != nil {
+					return err
+				}
+				if v, err = policy(c); err != nil {
+					return err
+				}
+				if reflect.DeepEqual(v, existing) {
+					continue
+				}
+			}
+			if err := tx.Set(k, v); err != nil {
+				return keyError("set", k, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Merge3 merges the changes made in src since the common snapshot base into the store in one transaction, for
+// example when two devices have modified their preferences independently since they were last synchronized. Keys
+// changed only in src are taken from src, keys changed only in the store are kept, and keys changed differently
+// in both are resolved with the given policy. Like Merge, Merge3 only considers values set explicitly. Keys
+// without a value are represented by nil in conflicts, and a policy removes the value of a key by returning nil,
+// which reverts the key to its default like Revert; as a consequence, keys set to nil count as having no value.
+// Merge3 returns the conflicts together with their resolution in ascending order of their keys. As with Merge,
+// modification times are only available if the stores implement ModTimer. Keys whose value is redacted in base
+// or src are left unchanged.
+func (db *KVStore) Merge3(base, src KeyValueStore, policy MergePolicy) ([]Conflict, error) {
+	before, err := readValues(base)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := readValues(src)
+	if err != nil {
+		return nil, err
+	}
+	skip := make(map[string]bool)
+	for _, m := range []map[string]any{before, theirs} {
+		for k, v := range m {
+			if v == Redacted {
+				skip[k] = true
+			}
+		}
+	}
+	var conflicts []Conflict
+	err = db.Update(func(tx Tx) error {
+		t := tx.(*txStore)
+		ours, err := t.values()
+		if err != nil {
+			return err
+		}
+		keys := make(map[string]struct{})
+		for _, m := range []map[string]any{before, ours, theirs} {
+			for k := range m {
+				if !skip[k] {
+					keys[k] = struct{}{}
+				}
+			}
+		}
+		for _, k := range slices.Sorted(maps.Keys(keys)) {
+			b, o, v := before[k], ours[k], theirs[k]
+			if reflect.DeepEqual(o, v) || reflect.DeepEqual(v, b) {
+				continue
+			}
+			if !reflect.DeepEqual(o, b) {
+				c := Conflict{Key: k, Ours: o, Theirs: v, Base: b}
+				if c.OursModified, err = t.modTime(k); err != nil {
+					return err
+				}
+				if c.TheirsModified, err = modTime(src, k); err != nil {
+					return err
+				}
+				if c.Resolved, err = policy(c); err != nil {
+					return err
+				}
+				conflicts = append(conflicts, c)
+				if reflect.DeepEqual(c.Resolved, o) {
+					continue
+				}
+				v = c.Resolved
+			}
+			if v == nil {
+				err = keyError("revert", k, tx.Revert(k))
+			} else {
+				err = keyError("set", k, tx.Set(k, v))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return conflicts, err
+}
+
+// readValues returns the values set explicitly in the store. Defaults are left out if the store implements
+// SourceGetter, and the actual values of sensitive keys are returned if the store is a KVStore, like readAll.
+func readValues(s KeyValueStore) (map[string]any, error) {
+	if db, ok := s.(*KVStore); ok {
+		if atomic.LoadUint32(&db.state) < 256 {
+			return nil, NotOpenErr
+		}
+		if err := db.flushPending(); err != nil {
+			return nil, err
+		}
+		return db.values(db.sqx)
+	}
+	pairs, err := s.GetAll(0)
+	if err != nil {
+		return nil, err
+	}
+	g, ok := s.(SourceGetter)
+	if !ok {
+		return pairs, nil
+	}
+	for k := range pairs {
+		_, source, err := g.GetWithSource(k)
+		if err != nil && !errors.Is(err, NotFoundErr) {
+			return nil, err
+		}
+		if err != nil || source != SourceValue {
+			delete(pairs, k)
+		}
+	}
+	return pairs, nil
+}
+
+// values returns the keys that have a value, which has not expired, together with their values.
+func (db *KVStore) values(q sqlx.Queryer) (map[string]any, error) {
+	rows, err := db.queryLive(q, `kv.value IS NOT NULL`, ``)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make(map[string]any)
+	for rows.Next() {
+		key, sv, _, err := scanLive(rows)
+		if err != nil {
+			return nil, err
+		}
+		if result[key], _, err = sv.decode(); err != nil {
+			return nil, err
+		}
+	}
+	return result, rows.Err()
+}
+
+// values returns the keys that have a value in the transaction together with their values.
+func (t *txStore) values() (map[string]any, error) {
+	return t.db.values(t.tx)
+}
+
+// modTime returns the modification time of the key in the transaction, the zero time if there is no key.
+func (t *txStore) modTime(key string) (time.Time, error) {
+	var ms sql.NullInt64
+	err := t.tx.Get(&ms, `SELECT updated_at FROM kv WHERE key=?;`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return millisTime(ms), err
+}
+
+// modTime returns the modification time of the key if the store implements ModTimer, the zero time otherwise or
+// if the key does not exist.
+func modTime(store any, key string) (time.Time, error) {
+	if m, ok := store.(ModTimer); ok {
+		t, err := m.ModTime(key)
+		if errors.Is(err, NotFoundErr) {
+			return time.Time{}, nil
+		}
+		return t, err
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,133 @@
+package kvstore
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	db := openTestStore(t)
+	other := openTestStore(t)
+	if err := db.SetMany(map[string]any{"a": 1, "b": 2}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	if err := other.SetMany(map[string]any{"b": 3, "c": 4}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	if err := db.Merge(other, MergeOurs); err != nil {
+		t.Fatalf(`failed to merge: %v`, err)
+	}
+	if all, _ := db.GetAll(0); len(all) != 3 || all["b"] != 2 || all["c"] != 4 {
+		t.Errorf(`wrong result after merge with MergeOurs: %v`, all)
+	}
+	if err := db.Merge(other, MergeTheirs); err != nil {
+		t.Fatalf(`failed to merge: %v`, err)
+	}
+	if v, _ := db.Get("b"); v != 3 {
+		t.Errorf(`expected their value after merge with MergeTheirs, got %v`, v)
+	}
+	if err := other.Set("b", 5); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	var conflicts []Conflict
+	err := db.Merge(other, func(c Conflict) (any, error) {
+		conflicts = append(conflicts, c)
+		return c.Ours.(int) + c.Theirs.(int), nil
+	})
+	if err != nil {
+		t.Fatalf(`failed to merge: %v`, err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Key != "b" {
+		t.Errorf(`expected one conflict for key b, got %v`, conflicts)
+	}
+	if v, _ := db.Get("b"); v != 8 {
+		t.Errorf(`expected resolved value 8, got %v`, v)
+	}
+	mergeErr := errors.New("refused")
+	if err := other.Set("a", 10); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Merge(other, func(c Conflict) (any, error) { return nil, mergeErr }); !errors.Is(err, mergeErr) {
+		t.Errorf(`expected policy error to be returned, got %v`, err)
+	}
+	if v, _ := db.Get("a"); v != 1 {
+		t.Errorf(`failed merge changed value, got %v`, v)
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	base := openTestStore(t)
+	ours := openTestStore(t)
+	theirs := openTestStore(t)
+	snapshot := map[string]any{"same": 1, "ours": 1, "theirs": 1, "both": 1, "deleted": 1, "conflict": 1}
+	for _, db := range []*KVStore{base, ours, theirs} {
+		if err := db.SetMany(snapshot); err != nil {
+			t.Fatalf(`failed to set keys: %v`, err)
+		}
+	}
+	if err := ours.SetMany(map[string]any{"ours": 2, "both": 3, "conflict": 2, "new": "a"}); err != nil {
+		t.Fatalf(`failed to set keys: %v`, err)
+	}
+	if err := theirs.SetMany(map[string]any{"theirs": 2, "both": 3, "conflict": 3}); err != nil {
+		t.Fatalf(`failed to set keys: %v`, err)
+	}
+	if err := theirs.Delete("deleted"); err != nil {
+		t.Fatalf(`failed to delete key: %v`, err)
+	}
+	conflicts, err := ours.Merge3(base, theirs, MergeTheirs)
+	if err != nil {
+		t.Fatalf(`failed to merge: %v`, err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Key != "conflict" || conflicts[0].Base != 1 || conflicts[0].Ours != 2 ||
+		conflicts[0].Theirs != 3 || conflicts[0].Resolved != 3 {
+		t.Errorf(`wrong conflicts: %+v`, conflicts)
+	}
+	all, err := ours.GetAll(0)
+	if err != nil {
+		t.Fatalf(`failed to get all keys: %v`, err)
+	}
+	want := map[string]any{"same": 1, "ours": 2, "theirs": 2, "both": 3, "conflict": 3, "new": "a"}
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf(`expected %v after merge, got %v`, want, all)
+	}
+}
+
+func TestMergeDefaults(t *testing.T) {
+	db := openTestStore(t)
+	other := openTestStore(t)
+	if err := db.SetDefault("color", "blue", KeyInfo{Category: "ui"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := other.SetDefault("color", "red", KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := other.Set("size", 12); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Merge(other, MergeTheirs); err != nil {
+		t.Fatalf(`failed to merge: %v`, err)
+	}
+	if v, source, err := db.GetWithSource("color"); v != "blue" || source != SourceDefault || err != nil {
+		t.Errorf(`expected their default not to be copied, got %v, %v, %v`, v, source, err)
+	}
+	if v, _ := db.Get("size"); v != 12 {
+		t.Errorf(`expected their value to be copied, got %v`, v)
+	}
+	base := openTestStore(t)
+	if err := base.Set("color", "green"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Set("color", "green"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if _, err := db.Merge3(base, other, MergeTheirs); err != nil {
+		t.Fatalf(`failed to merge: %v`, err)
+	}
+	if v, source, err := db.GetWithSource("color"); v != "blue" || source != SourceDefault || err != nil {
+		t.Errorf(`expected value removed in src to revert to the default, got %v, %v, %v`, v, source, err)
+	}
+	if info, ok := db.Info("color"); !ok || info.Category != "ui" {
+		t.Errorf(`expected key info to be kept, got %+v, %v`, info, ok)
+	}
+}
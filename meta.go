@@ -0,0 +1,84 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Store-level metadata such as the version of the application that created the store, a device ID, or the time
+// of the last synchronization is kept in a separate table, so that it does not appear among the keys. Metadata
+// values must be gob serializable and are not covered by change listeners, the journal, or checksums.
+
+// initMeta creates the table holding store-level metadata.
+func initMeta(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS kv_meta(name TEXT PRIMARY KEY NOT NULL, value BLOB);`)
+	return err
+}
+
+// SetMeta sets the store-level metadata with the given name to value.
+func (db *KVStore) SetMeta(name string, value any) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	b, err := db.encode(value)
+	if err != nil {
+		return err
+	}
+	_, err = db.exec(`INSERT INTO kv_meta(name,value) VALUES(?,?) ON CONFLICT(name) DO UPDATE SET value=excluded.value;`,
+		name, b)
+	return err
+}
+
+// GetMeta returns the store-level metadata with the given name, NotFoundErr if there is none.
+func (db *KVStore) GetMeta(name string) (any, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	var b []byte
+	err := db.sqx.Get(&b, `SELECT value FROM kv_meta WHERE name=?;`, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, NotFoundErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalBinary(b)
+}
+
+// DeleteMeta removes the store-level metadata with the given name. It does nothing if there is none.
+func (db *KVStore) DeleteMeta(name string) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	_, err := db.exec(`DELETE FROM kv_meta WHERE name=?;`, name)
+	return err
+}
+
+// Meta returns all store-level metadata as a map from names to values.
+func (db *KVStore) Meta() (map[string]any, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	rows, err := db.sqx.Queryx(`SELECT name,value FROM kv_meta;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make(map[string]any)
+	for rows.Next() {
+		var name string
+		var b []byte
+		if err := rows.Scan(&name, &b); err != nil {
+			return result, err
+		}
+		v, err := UnmarshalBinary(b)
+		if err != nil {
+			return result, err
+		}
+		result[name] = v
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,36 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMeta(t *testing.T) {
+	db := openTestStore(t)
+	if _, err := db.GetMeta("device"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+	synced := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := db.SetMeta("device", "laptop"); err != nil {
+		t.Fatalf(`failed to set metadata: %v`, err)
+	}
+	if err := db.SetMeta("synced", synced); err != nil {
+		t.Fatalf(`failed to set metadata: %v`, err)
+	}
+	if v, err := db.GetMeta("synced"); err != nil || !v.(time.Time).Equal(synced) {
+		t.Errorf(`expected %v, got %v, %v`, synced, v, err)
+	}
+	if ok, _ := db.Has("device"); ok {
+		t.Errorf(`expected metadata not to appear among keys`)
+	}
+	if m, err := db.Meta(); err != nil || len(m) != 2 || m["device"] != "laptop" {
+		t.Errorf(`wrong metadata: %v, %v`, m, err)
+	}
+	if err := db.DeleteMeta("device"); err != nil {
+		t.Fatalf(`failed to delete metadata: %v`, err)
+	}
+	if _, err := db.GetMeta("device"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr after delete, got %v`, err)
+	}
+}
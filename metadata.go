@@ -0,0 +1,89 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Metadata provides information about a key together with the times it was created and last modified.
+type Metadata struct {
+	Info     KeyInfo
+	Created  time.Time // zero for keys created by versions that did not record timestamps
+	Modified time.Time // the last time the value or default changed
+}
+
+// initTimestamps adds the timestamp columns, which are written by the statements changing values and defaults
+// with the time of the clock of the store. The triggers and the clock table of earlier versions are removed.
+func initTimestamps(tx *sqlx.Tx) error {
+	if err := addColumn(tx, "kv", "created_at", "INTEGER"); err != nil {
+		return err
+	}
+	if err := addColumn(tx, "kv", "updated_at", "INTEGER"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+CREATE INDEX IF NOT EXISTS kv_updated_at ON kv(updated_at);
+DROP TRIGGER IF EXISTS kv_created;
+DROP TRIGGER IF EXISTS kv_updated;
+DROP TABLE IF EXISTS kv_clock;
+`)
+	return err
+}
+
+// millisTime converts a nullable timestamp column to a time, which is zero if the column is NULL.
+func millisTime(ms sql.NullInt64) time.Time {
+	if !ms.Valid {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms.Int64)
+}
+
+// Metadata returns the key info and timestamps of a key, NotFoundErr if there is no key.
+func (db *KVStore) Metadata(key string) (Metadata, error) {
+	var m Metadata
+	if atomic.LoadUint32(&db.state) < 256 {
+		return m, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return m, err
+	}
+	var extra []byte
+	var created, updated sql.NullInt64
+	var description, category sql.NullString
+	err := db.sqx.QueryRowx(`SELECT info,category,extra,created_at,updated_at FROM kv WHERE key=?;`, key).
+		Scan(&description, &category, &extra, &created, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return m, NotFoundErr
+	}
+	if err != nil {
+		return m, err
+	}
+	m.Info.Description, m.Info.Category = description.String, category.String
+	if err := m.Info.unmarshalExtra(extra); err != nil {
+		return m, err
+	}
+	m.Created, m.Modified = millisTime(created), millisTime(updated)
+	return m, nil
+}
+
+// ModTime returns the time the key was last modified, NotFoundErr if there is no key. This implements ModTimer,
+// so MergeNewest can resolve conflicts between two stores.
+func (db *KVStore) ModTime(key string) (time.Time, error) {
+	m, err := db.Metadata(key)
+	return m.Modified, err
+}
+
+// ModifiedSince returns the keys modified at or after the given time, most recently modified first.
+func (db *KVStore) ModifiedSince(t time.Time) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	return db.liveKeys(`kv.updated_at>=?`, `ORDER BY kv.updated_at DESC,kv.key`, t.UnixMilli())
+}
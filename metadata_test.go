@@ -0,0 +1,47 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMetadata(t *testing.T) {
+	db := openTestStore(t)
+	start := time.Now().Add(-time.Second)
+	if err := db.SetDefault("a", 1, KeyInfo{Description: "first", Category: "tests"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	m, err := db.Metadata("a")
+	if err != nil {
+		t.Fatalf(`failed to get metadata: %v`, err)
+	}
+	if m.Info.Description != "first" || m.Created.Before(start) || !m.Modified.Equal(m.Created) {
+		t.Errorf(`unexpected metadata after creation: %+v`, m)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := db.Set("b", 2); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := db.Set("a", 3); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	m2, err := db.ModTime("a")
+	if err != nil {
+		t.Fatalf(`failed to get modification time: %v`, err)
+	}
+	if !m2.After(m.Modified) {
+		t.Errorf(`modification time was not updated: %v <= %v`, m2, m.Modified)
+	}
+	keys, err := db.ModifiedSince(start)
+	if err != nil {
+		t.Fatalf(`failed to get modified keys: %v`, err)
+	}
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Errorf(`expected [a b], got %v`, keys)
+	}
+	if _, err := db.Metadata("missing"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+}
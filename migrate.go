@@ -0,0 +1,138 @@
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var MigrationCycleErr = errors.New(`migrations form a cycle`)
+
+// migrations holds the registered migrations by the type they migrate from.
+var migrations = struct {
+	sync.RWMutex
+	byType map[reflect.Type]func(any) (any, error)
+}{byType: make(map[reflect.Type]func(any) (any, error))}
+
+// RegisterMigration registers a function that migrates values of type From to type To, for example when a
+// settings struct is replaced by a new version:
+//
+//	kvstore.RegisterMigration(func(old SettingsV1) (SettingsV2, error) {
+//		return SettingsV2{Theme: old.Theme, FontSize: 12}, nil
+//	})
+//
+// Values and defaults of type From are migrated lazily whenever they are decoded, so Get returns a SettingsV2
+// for a stored SettingsV1. Migrations are chained, so a SettingsV1 is migrated to SettingsV3 if there is a
+// migration from SettingsV2 to SettingsV3, too. The old type must still be registered with gob so that stored
+// values can be decoded. Migrated values are not written back until they are set again, use MigrateAll to
+// rewrite all stored values of old types. Registering a migration for the same type again replaces it.
+func RegisterMigration[From, To any](migrate func(From) (To, error)) {
+	migrations.Lock()
+	defer migrations.Unlock()
+	migrations.byType[reflect.TypeFor[From]()] = func(v any) (any, error) { return migrate(v.(From)) }
+}
+
+// migrate applies all registered migrations to v and returns the migrated value and true, or v and false if
+// there is no migration for its type.
+func migrate(v any) (any, bool, error) {
+	migrations.RLock()
+	defer migrations.RUnlock()
+	if len(migrations.byType) == 0 || v == nil {
+		return v, false, nil
+	}
+	migrated := false
+	for range len(migrations.byType) + 1 {
+		fn, ok := migrations.byType[reflect.TypeOf(v)]
+		if !ok {
+			return v, migrated, nil
+		}
+		from := v
+		var err error
+		if v, err = fn(v); err != nil {
+			return from, false, fmt.Errorf("failed to migrate %T: %w", from, err)
+		}
+		migrated = true
+	}
+	return v, false, fmt.Errorf("%w: %T", MigrationCycleErr, v)
+}
+
+// MigrateAll rewrites all stored values to which a registered migration applies with their migrated value
+// in one transaction and returns the number of rewritten values. Defaults are not rewritten, as they are
+// usually set again by the application on startup. Values stored with the JSON codec or with SetRaw are
+// left as they are.
+func (db *KVStore) MigrateAll() (int, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return 0, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return 0, err
+	}
+	var keys []string
+	err := db.inTx(func(tx *sqlx.Tx) error {
+		rows, err := tx.Queryx(`SELECT key,` + db.valueColumns() + ` FROM kv WHERE kv.value IS NOT NULL AND
+(kv.codec IS NULL OR kv.codec NOT IN ('` + codecJSON + `','` + codecRaw + `','` + encryptedCodec + codecJSON + `','` +
+			encryptedCodec + codecRaw + `'));`)
+		if err != nil {
+			return err
+		}
+		var values []any
+		for rows.Next() {
+			var key string
+			var sv storedValue
+			if err := rows.Scan(append([]any{&key}, sv.dest()...)...); err != nil {
+				rows.Close()
+				return err
+			}
+			old, err := sv.decodeValue()
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("%w: %s", err, key)
+			}
+			v, ok, err := migrate(old)
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("%w: %s", err, key)
+			}
+			if ok {
+				keys = append(keys, key)
+				values = append(values, v)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for i, key := range keys {
+			if err := db.putMigrated(tx, key, values[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	// values returned by Get do not change, so listeners are not notified
+	db.cache.remove(keys...)
+	return len(keys), nil
+}
+
+// putMigrated stores a migrated value for the key with the codec Set would use for it.
+func (db *KVStore) putMigrated(tx *sqlx.Tx, key string, v any) error {
+	if isMessage(v) {
+		b, err := Proto.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return db.putCodec(tx, key, b, Proto.Name(), typeName(v))
+	}
+	b, err := MarshalBinary(v)
+	if err != nil {
+		return err
+	}
+	return db.put(tx, key, b, typeName(v))
+}
@@ -0,0 +1,74 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+type settingsV1 struct {
+	Theme string
+}
+
+type settingsV2 struct {
+	Theme    string
+	FontSize int
+}
+
+type settingsV3 struct {
+	Theme    string
+	FontSize int
+	Dark     bool
+}
+
+func TestMigration(t *testing.T) {
+	db := openTestStore(t)
+	defer db.Close()
+	if err := db.RegisterTypes(settingsV1{}, settingsV2{}, settingsV3{}); err != nil {
+		t.Fatalf(`failed to register types: %v`, err)
+	}
+	if err := db.Set("settings", settingsV1{Theme: "dark"}); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.SetDefault("other", settingsV1{Theme: "light"}, KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	RegisterMigration(func(old settingsV1) (settingsV2, error) {
+		return settingsV2{Theme: old.Theme, FontSize: 12}, nil
+	})
+	RegisterMigration(func(old settingsV2) (settingsV3, error) {
+		return settingsV3{Theme: old.Theme, FontSize: old.FontSize, Dark: old.Theme == "dark"}, nil
+	})
+	defer func() {
+		migrations.Lock()
+		clear(migrations.byType)
+		migrations.Unlock()
+	}()
+	want := settingsV3{Theme: "dark", FontSize: 12, Dark: true}
+	if v, err := db.Get("settings"); err != nil || v != want {
+		t.Errorf(`expected migrated value %v, got %v, %v`, want, v, err)
+	}
+	if v, source, err := db.GetWithSource("other"); err != nil || v != (settingsV3{Theme: "light", FontSize: 12}) ||
+		source != SourceDefault {
+		t.Errorf(`expected migrated default, got %v, %v, %v`, v, source, err)
+	}
+	if typ, _ := db.TypeOf("settings"); typ != "kvstore.settingsV1" {
+		t.Errorf(`expected stored value not to be rewritten by Get, got type %q`, typ)
+	}
+	n, err := db.MigrateAll()
+	if err != nil || n != 1 {
+		t.Fatalf(`expected one migrated value, got %d, %v`, n, err)
+	}
+	if typ, _ := db.TypeOf("settings"); typ != "kvstore.settingsV3" {
+		t.Errorf(`expected stored value to be rewritten by MigrateAll, got type %q`, typ)
+	}
+	if n, err := db.MigrateAll(); err != nil || n != 0 {
+		t.Errorf(`expected no further migrations, got %d, %v`, n, err)
+	}
+	RegisterMigration(func(old settingsV3) (settingsV1, error) {
+		return settingsV1{Theme: old.Theme}, nil
+	})
+	db.cache.remove("settings")
+	if _, err := db.Get("settings"); !errors.Is(err, MigrationCycleErr) {
+		t.Errorf(`expected MigrationCycleErr, got %v`, err)
+	}
+}
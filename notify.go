@@ -0,0 +1,105 @@
+package kvstore
+
+import (
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ChangeFunc is called with the key, the old value, and the new value after a key has been changed.
+// Old and new values are the values Get returns before and after the change, nil if there is none.
+type ChangeFunc func(key string, old, new any)
+
+// ListenerID identifies a change listener registered with OnChange.
+type ListenerID uint64
+
+// listeners holds the registered change listeners in the order of registration.
+type listeners struct {
+	mutex  sync.RWMutex
+	nextID ListenerID
+	funcs  []listener
+}
+
+type listener struct {
+	id ListenerID
+	fn ChangeFunc
+}
+
+// change is a pending change notification.
+type change struct {
+	key      string
+	old, new any
+}
+
+// OnChange registers a function that is called after a successful Set, SetMany, Delete, DeleteMany, or Revert
+// operation for each changed key. Functions are called synchronously in the order of registration from the
+// goroutine that made the change. The returned ID can be used to remove the listener with RemoveChangeListener.
+func (db *KVStore) OnChange(fn ChangeFunc) ListenerID {
+	db.listeners.mutex.Lock()
+	defer db.listeners.mutex.Unlock()
+	db.listeners.nextID++
+	db.listeners.funcs = append(db.listeners.funcs, listener{id: db.listeners.nextID, fn: fn})
+	return db.listeners.nextID
+}
+
+// RemoveChangeListener removes the change listener with the given ID. It does nothing if there is no
+// such listener.
+func (db *KVStore) RemoveChangeListener(id ListenerID) {
+	db.listeners.mutex.Lock()
+	defer db.listeners.mutex.Unlock()
+	for i := range db.listeners.funcs {
+		if db.listeners.funcs[i].id == id {
+			db.listeners.funcs = append(db.listeners.funcs[:i], db.listeners.funcs[i+1:]...)
+			return
+		}
+	}
+}
+
+// hasListeners returns true if at least one change listener is registered, the journal is enabled, or a
+// replica is configured.
+// Old values are only looked up when this is the case.
+func (db *KVStore) hasListeners() bool {
+	if db.opts.journalDepth > 0 || db.opts.replicator != nil {
+		return true
+	}
+	db.listeners.mutex.RLock()
+	defer db.listeners.mutex.RUnlock()
+	return len(db.listeners.funcs) > 0
+}
+
+// notify records the given changes as one mutation in the journal, calls all registered listeners for them,
+// and forwards them to the replica. An error is only returned if replication fails with policy ReplicationFail.
+func (db *KVStore) notify(changes ...change) error {
+	db.record(changes)
+	db.dispatch(changes...)
+	return db.replicate(changes...)
+}
+
+// dispatch calls all registered listeners for the given changes.
+func (db *KVStore) dispatch(changes ...change) {
+	db.listeners.mutex.RLock()
+	funcs := make([]listener, len(db.listeners.funcs))
+	copy(funcs, db.listeners.funcs)
+	db.listeners.mutex.RUnlock()
+	for _, c := range changes {
+		for _, l := range funcs {
+			l.fn(c.key, c.old, c.new)
+		}
+	}
+}
+
+// current returns the value Get would return for the key using the given queryer, nil if there is
+// none or it cannot be decoded.
+func (db *KVStore) current(q sqlx.Queryer, key string) any {
+	if sv, ok := db.pendingValue(key); ok {
+		v, _, _ := sv.decode()
+		return v
+	}
+	var sv storedValue
+	err := q.QueryRowx(`SELECT `+db.valueColumns()+` FROM kv WHERE key=? LIMIT 1;`, key).Scan(sv.dest()...)
+	if err != nil {
+		return nil
+	}
+	v, _, _ := sv.decode()
+	return v
+}
@@ -0,0 +1,47 @@
+package kvstore
+
+import "testing"
+
+func TestOnChange(t *testing.T) {
+	db := openTestStore(t)
+	var changes []change
+	id := db.OnChange(func(key string, old, new any) {
+		changes = append(changes, change{key: key, old: old, new: new})
+	})
+	if err := db.SetDefault("theme", "light", KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.Set("theme", "dark"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Revert("theme"); err != nil {
+		t.Fatalf(`failed to revert key: %v`, err)
+	}
+	if err := db.Delete("theme"); err != nil {
+		t.Fatalf(`failed to delete key: %v`, err)
+	}
+	if err := db.SetMany(map[string]any{"a": 1}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	expected := []change{
+		{"theme", "light", "dark"},
+		{"theme", "dark", "light"},
+		{"theme", "light", nil},
+		{"a", nil, 1},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf(`expected %v changes, got %v`, len(expected), changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf(`expected change %v, got %v`, expected[i], changes[i])
+		}
+	}
+	db.RemoveChangeListener(id)
+	if err := db.DeleteMany([]string{"a"}); err != nil {
+		t.Fatalf(`failed to delete many: %v`, err)
+	}
+	if len(changes) != len(expected) {
+		t.Errorf(`listener was called after it was removed`)
+	}
+}
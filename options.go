@@ -0,0 +1,49 @@
+package kvstore
+
+import "time"
+
+// Option configures a key value store, see New.
+type Option func(*options)
+
+// options holds the configuration of a key value store.
+type options struct {
+	multiProcess      bool
+	flushInterval     time.Duration // write-behind mode is enabled if positive
+	maxBatch          int
+	cacheEntries      int
+	cacheBytes        int64
+	fullText          bool
+	trackAccess       bool
+	maxEntries        int
+	maxBytes          int64
+	eviction          EvictionPolicy
+	journalDepth      int
+	externalThreshold int
+	dedupThreshold    int
+	maxOpenConns      int
+	maxIdleConns      int
+	singleWriter      bool
+	retry             *RetryPolicy
+	table             string
+	vfs               string
+	envPrefix         string
+	strictEncoding    bool
+	quotas            map[string]categoryQuota
+	replicator        Replicator
+	replicationPolicy ReplicationPolicy
+	encryption        *encryption
+	redactSensitive   bool
+	retention         []RetentionRule
+	slowThreshold     time.Duration
+	slowReport        func(SlowOp)
+}
+
+// MultiProcess configures the store to be shared safely between several processes opening the same
+// database. Operations that fail because another process holds a lock on the database are retried with
+// exponential backoff after sqlite's own busy timeout has expired, and in-process caches are invalidated
+// when another process changes the database.
+func MultiProcess() Option {
+	return func(o *options) {
+		o.multiProcess = true
+	}
+}
@@ -0,0 +1,77 @@
+package kvstore
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// By default, the connection pool of database/sql is used with its defaults: any number of open connections
+// and at most two idle connections. Reads run concurrently on separate connections, while write transactions
+// take sqlite's write lock when they begin and wait for each other using sqlite's busy timeout. Under heavy
+// concurrent write load, the busy timeout may expire, which SingleWriter avoids. See BenchmarkSetParallel.
+
+// ConnectionPool limits the number of open and idle connections of the connection pool. A value of zero or
+// less leaves the corresponding default of database/sql unchanged.
+func ConnectionPool(maxOpen, maxIdle int) Option {
+	return func(o *options) {
+		o.maxOpenConns = maxOpen
+		o.maxIdleConns = maxIdle
+	}
+}
+
+// SingleWriter configures the store to perform all writes on one dedicated connection, so that writes from
+// concurrent goroutines are queued in the order they arrive instead of competing for sqlite's write lock.
+// This avoids busy errors under heavy concurrent write load within one process, at the cost of write
+// concurrency with transactions that do not write. Reads still use the connection pool.
+func SingleWriter() Option {
+	return func(o *options) {
+		o.singleWriter = true
+	}
+}
+
+// openWriter configures the connection pool and opens the writer connection in single-writer mode.
+func (db *KVStore) openWriter(dsn string) error {
+	if db.opts.maxOpenConns > 0 {
+		db.sq.SetMaxOpenConns(db.opts.maxOpenConns)
+	}
+	if db.opts.maxIdleConns > 0 {
+		db.sq.SetMaxIdleConns(db.opts.maxIdleConns)
+	}
+	if db.unlocked() {
+		// without file locking, all reads and writes use the same connection
+		db.sq.SetMaxOpenConns(1)
+		db.sq.SetMaxIdleConns(1)
+		return nil
+	}
+	if !db.opts.singleWriter {
+		return nil
+	}
+	sq, err := db.openDB(dsn)
+	if err != nil {
+		return err
+	}
+	// requests for the only connection are queued by database/sql
+	sq.SetMaxOpenConns(1)
+	sq.SetMaxIdleConns(1)
+	sq.SetConnMaxLifetime(0)
+	sq.SetConnMaxIdleTime(0)
+	db.writer = sqlx.NewDb(sq, "sqlite3")
+	return nil
+}
+
+// closeWriter closes the writer connection in single-writer mode.
+func (db *KVStore) closeWriter() error {
+	if db.writer == nil {
+		return nil
+	}
+	err := db.writer.Close()
+	db.writer = nil
+	return err
+}
+
+// writeDB returns the database handle used for writes.
+func (db *KVStore) writeDB() *sqlx.DB {
+	if db.writer != nil {
+		return db.writer
+	}
+	return db.sqx
+}
@@ -0,0 +1,73 @@
+package kvstore
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSingleWriter(t *testing.T) {
+	db := New(SingleWriter(), ConnectionPool(4, 2))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := db.Set(fmt.Sprintf("key%d", i), j); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf(`failed to set key concurrently: %v`, err)
+	}
+	if all, err := db.GetAll(0); len(all) != 20 || err != nil {
+		t.Errorf(`expected 20 keys, got %v, %v`, len(all), err)
+	}
+	if db.sq.Stats().MaxOpenConnections != 4 {
+		t.Errorf(`expected pool to be limited to 4 connections, got %d`, db.sq.Stats().MaxOpenConnections)
+	}
+}
+
+// BenchmarkSetParallel compares concurrent Set calls with the default connection pool and in single-writer mode.
+func BenchmarkSetParallel(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"single-writer", []Option{SingleWriter()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			db := New(bench.opts...)
+			if err := db.Open(b.TempDir()); err != nil {
+				b.Fatalf(`failed to open database: %v`, err)
+			}
+			defer db.Close()
+			var n sync.Mutex
+			next := 0
+			b.RunParallel(func(pb *testing.PB) {
+				n.Lock()
+				key := fmt.Sprintf("key%d", next)
+				next++
+				n.Unlock()
+				for i := 0; pb.Next(); i++ {
+					if err := db.Set(key, i); err != nil {
+						b.Errorf(`failed to set key: %v`, err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package kvstore
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Preload reads the values of all keys in the given categories, or of all keys if no categories are given, into
+// the read cache in one query, so that a user interface showing many preferences does not issue one query per
+// key when it is first displayed. It returns the number of values cached. Keys that expire are not cached, and
+// nothing is done if no cache has been configured with Cache. If the categories hold more values than the cache,
+// only the values read last remain cached. Values redacted by RedactSensitive are not cached either.
+func (db *KVStore) Preload(categories ...string) (int, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return 0, NotOpenErr
+	}
+	if db.cache == nil {
+		return 0, nil
+	}
+	if err := db.flushPending(); err != nil {
+		return 0, err
+	}
+	redacted, err := db.redactedKeys()
+	if err != nil {
+		return 0, err
+	}
+	gen := db.cache.gen()
+	query := `SELECT kv.key,` + db.valueColumns() + ` FROM kv ` + expiryJoins +
+		` WHERE (kv.value IS NOT NULL OR kv.original IS NOT NULL) AND ` + expiresExpr + ` IS NULL`
+	var args []any
+	if len(categories) > 0 {
+		var err error
+		query, args, err = sqlx.In(query+` AND kv.category IN (?);`, categories)
+		if err != nil {
+			return 0, err
+		}
+	}
+	rows, err := db.sqx.Queryx(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		var key string
+		var sv storedValue
+		if err := rows.Scan(append([]any{&key}, sv.dest()...)...); err != nil {
+			return n, err
+		}
+		if redacted[key] {
+			continue
+		}
+		v, ok, err2 := sv.decode()
+		if err2 != nil {
+			err = errors.Join(err, err2)
+		} else if ok {
+			db.cache.add(gen, key, v, sv.size())
+			n++
+		}
+	}
+	return n, errors.Join(err, rows.Err())
+}
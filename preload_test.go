@@ -0,0 +1,41 @@
+package kvstore
+
+import "testing"
+
+func TestPreload(t *testing.T) {
+	db := New(Cache(100, 0))
+	if err := db.Open(InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer db.Close()
+	for key, category := range map[string]string{"width": "window", "height": "window", "proxy": "network"} {
+		if err := db.SetDefault(key, key+" default", KeyInfo{Category: category}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Set("width", 800); err != nil {
+		t.Fatal(err)
+	}
+	db.cache.clear()
+	n, err := db.Preload("window")
+	if err != nil || n != 2 {
+		t.Fatalf(`expected 2 values preloaded, got %v, %v`, n, err)
+	}
+	if v, ok := db.cache.get("width"); !ok || v != 800 {
+		t.Errorf(`expected cached value, got %v, %v`, v, ok)
+	}
+	if _, ok := db.cache.get("proxy"); ok {
+		t.Errorf(`expected key of other category not to be cached`)
+	}
+	if n, err := db.Preload(); err != nil || n != 3 {
+		t.Errorf(`expected all values preloaded, got %v, %v`, n, err)
+	}
+	uncached := New()
+	if err := uncached.Open(InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer uncached.Close()
+	if n, err := uncached.Preload("window"); err != nil || n != 0 {
+		t.Errorf(`expected nothing to be done without cache, got %v, %v`, n, err)
+	}
+}
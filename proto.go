@@ -0,0 +1,117 @@
+package kvstore
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var UnknownMessageErr = errors.New(`unknown message type`)
+
+// Proto is a codec for message types registered with RegisterMessage, typically protobuf messages. Values are
+// stored in the wire format of a google.protobuf.Any message holding the type URL and the marshalled message,
+// so they can be read by other protobuf tools. Set and ForceSet use this codec for registered message types
+// automatically instead of gob, so messages are not encoded twice.
+var Proto Codec = protoCodec{}
+
+func init() {
+	RegisterCodec(Proto)
+}
+
+// messageType holds the type URL and marshalling functions of a registered message type.
+type messageType struct {
+	url       string
+	marshal   func(v any) ([]byte, error)
+	unmarshal func(b []byte) (any, error)
+}
+
+// messages holds the registered message types by Go type and by type URL.
+var messages = struct {
+	sync.RWMutex
+	byType map[reflect.Type]*messageType
+	byURL  map[string]*messageType
+}{byType: make(map[reflect.Type]*messageType), byURL: make(map[string]*messageType)}
+
+// RegisterMessage registers a message type T with its type URL and marshalling functions, which for protobuf
+// messages wrap proto.Marshal and proto.Unmarshal, for example:
+//
+//	kvstore.RegisterMessage("type.googleapis.com/app.Settings",
+//		func(m *pb.Settings) ([]byte, error) { return proto.Marshal(m) },
+//		func(b []byte) (*pb.Settings, error) { m := new(pb.Settings); return m, proto.Unmarshal(b, m) })
+func RegisterMessage[T any](typeURL string, marshal func(T) ([]byte, error), unmarshal func([]byte) (T, error)) {
+	mt := &messageType{
+		url:       typeURL,
+		marshal:   func(v any) ([]byte, error) { return marshal(v.(T)) },
+		unmarshal: func(b []byte) (any, error) { return unmarshal(b) },
+	}
+	messages.Lock()
+	defer messages.Unlock()
+	messages.byType[reflect.TypeFor[T]()] = mt
+	messages.byURL[typeURL] = mt
+}
+
+// isMessage returns true if the value is of a registered message type.
+func isMessage(v any) bool {
+	messages.RLock()
+	defer messages.RUnlock()
+	_, ok := messages.byType[reflect.TypeOf(v)]
+	return ok
+}
+
+// protoCodec implements the Proto codec.
+type protoCodec struct{}
+
+// Name returns the name stored with message values.
+func (protoCodec) Name() string {
+	return "proto"
+}
+
+// Marshal encodes a message of a registered type as google.protobuf.Any.
+func (protoCodec) Marshal(v any) ([]byte, error) {
+	messages.RLock()
+	mt, ok := messages.byType[reflect.TypeOf(v)]
+	messages.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", UnknownMessageErr, v)
+	}
+	msg, err := mt.marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// field 1 is the type URL and field 2 the message, both length-delimited (wire type 2)
+	b := binary.AppendUvarint([]byte{1<<3 | 2}, uint64(len(mt.url)))
+	b = append(b, mt.url...)
+	b = append(b, 2<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(msg)))
+	return append(b, msg...), nil
+}
+
+// Unmarshal decodes a google.protobuf.Any message into a message of the registered type for its type URL.
+func (protoCodec) Unmarshal(b []byte) (any, error) {
+	var url string
+	var msg []byte
+	for len(b) > 0 {
+		tag := b[0]
+		n, size := binary.Uvarint(b[1:])
+		if tag&7 != 2 || size <= 0 || uint64(len(b)-1-size) < n {
+			return nil, errors.New(`malformed protobuf Any message`)
+		}
+		field := b[1+size : 1+size+int(n)]
+		switch tag >> 3 {
+		case 1:
+			url = string(field)
+		case 2:
+			msg = field
+		}
+		b = b[1+size+int(n):]
+	}
+	messages.RLock()
+	mt, ok := messages.byURL[url]
+	messages.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", UnknownMessageErr, url)
+	}
+	return mt.unmarshal(msg)
+}
@@ -0,0 +1,39 @@
+package kvstore
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// testMessage stands in for a generated protobuf message.
+type testMessage struct {
+	Text string
+}
+
+func TestProto(t *testing.T) {
+	RegisterMessage("type.example.com/test.Message",
+		func(m *testMessage) ([]byte, error) { return []byte(m.Text), nil },
+		func(b []byte) (*testMessage, error) { return &testMessage{Text: string(b)}, nil })
+	db := openTestStore(t)
+	if err := db.Set("msg", &testMessage{Text: "hello"}); err != nil {
+		t.Fatalf(`failed to set message: %v`, err)
+	}
+	b, err := db.GetRaw("msg")
+	if err != nil {
+		t.Fatalf(`failed to get raw value: %v`, err)
+	}
+	expected := append([]byte{0x0a, 29}, "type.example.com/test.Message"...)
+	expected = append(expected, 0x12, 5)
+	expected = append(expected, "hello"...)
+	if !bytes.Equal(b, expected) {
+		t.Errorf(`expected google.protobuf.Any encoding, got %q`, b)
+	}
+	v, err := db.Get("msg")
+	if m, ok := v.(*testMessage); !ok || m.Text != "hello" || err != nil {
+		t.Errorf(`expected decoded message, got %v, %v`, v, err)
+	}
+	if err := db.SetWithCodec("other", 1, Proto); !errors.Is(err, UnknownMessageErr) {
+		t.Errorf(`expected UnknownMessageErr, got %v`, err)
+	}
+}
@@ -0,0 +1,115 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var QueueEmptyErr = errors.New(`queue is empty`)
+
+// Queue is a persistent first-in first-out queue stored alongside the keys of a key value store. Items must be
+// gob serializable. Several queues with different names may be used with the same store. The names of queues are
+// independent of keys, so a queue may have the same name as a key, and queues are not visible to the methods for
+// keys such as Has, Delete, and GetAll.
+type Queue struct {
+	db   *KVStore
+	name string
+}
+
+// initQueues creates the table holding queue items.
+func initQueues(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_queue(
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  queue TEXT NOT NULL,
+  value BLOB
+);
+CREATE INDEX IF NOT EXISTS kv_queue_name ON kv_queue(queue,id);
+`)
+	return err
+}
+
+// Queue returns the queue with the given name. Queues need not be created.
+func (db *KVStore) Queue(name string) *Queue {
+	return &Queue{db: db, name: name}
+}
+
+// Enqueue appends the given items to the end of the queue in one transaction.
+func (q *Queue) Enqueue(items ...any) error {
+	if atomic.LoadUint32(&q.db.state) < 256 {
+		return NotOpenErr
+	}
+	tx, err := q.db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, item := range items {
+		b, err := q.db.encode(item)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO kv_queue(queue,value) VALUES(?,?);`, q.name, b); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Dequeue removes and returns the first item of the queue in one transaction, QueueEmptyErr if the queue
+// is empty. An item is only returned to one caller, even if several processes share the queue.
+func (q *Queue) Dequeue() (any, error) {
+	if atomic.LoadUint32(&q.db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	tx, err := q.db.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	id, v, err := q.first(tx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM kv_queue WHERE id=?;`, id); err != nil {
+		return nil, err
+	}
+	return v, tx.Commit()
+}
+
+// Peek returns the first item of the queue without removing it, QueueEmptyErr if the queue is empty.
+func (q *Queue) Peek() (any, error) {
+	if atomic.LoadUint32(&q.db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	_, v, err := q.first(q.db.sqx)
+	return v, err
+}
+
+// Len returns the number of items in the queue.
+func (q *Queue) Len() (int, error) {
+	if atomic.LoadUint32(&q.db.state) < 256 {
+		return 0, NotOpenErr
+	}
+	var n int
+	err := q.db.sqx.Get(&n, `SELECT COUNT(*) FROM kv_queue WHERE queue=?;`, q.name)
+	return n, err
+}
+
+// first returns the ID and decoded value of the first item of the queue.
+func (q *Queue) first(qu sqlx.Queryer) (int64, any, error) {
+	var id int64
+	var b []byte
+	err := qu.QueryRowx(`SELECT id,value FROM kv_queue WHERE queue=? ORDER BY id LIMIT 1;`, q.name).Scan(&id, &b)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil, QueueEmptyErr
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	v, err := UnmarshalBinary(b)
+	return id, v, err
+}
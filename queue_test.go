@@ -0,0 +1,37 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQueue(t *testing.T) {
+	db := openTestStore(t)
+	q := db.Queue("uploads")
+	if _, err := q.Dequeue(); !errors.Is(err, QueueEmptyErr) {
+		t.Errorf(`expected QueueEmptyErr, got %v`, err)
+	}
+	if err := q.Enqueue("a", "b"); err != nil {
+		t.Fatalf(`failed to enqueue: %v`, err)
+	}
+	if err := db.Queue("other").Enqueue("x"); err != nil {
+		t.Fatalf(`failed to enqueue: %v`, err)
+	}
+	if err := q.Enqueue("c"); err != nil {
+		t.Fatalf(`failed to enqueue: %v`, err)
+	}
+	if n, err := q.Len(); n != 3 || err != nil {
+		t.Errorf(`expected length 3, got %v, %v`, n, err)
+	}
+	if v, err := q.Peek(); v != "a" || err != nil {
+		t.Errorf(`expected a, got %v, %v`, v, err)
+	}
+	for _, expected := range []string{"a", "b", "c"} {
+		if v, err := q.Dequeue(); v != expected || err != nil {
+			t.Errorf(`expected %v, got %v, %v`, expected, v, err)
+		}
+	}
+	if n, _ := db.Queue("other").Len(); n != 1 {
+		t.Errorf(`expected other queue to be unaffected, got length %v`, n)
+	}
+}
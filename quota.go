@@ -0,0 +1,128 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var QuotaExceededErr = errors.New(`category quota exceeded`)
+
+// categoryQuota limits the number of keys and the size of encoded values and defaults in a category.
+type categoryQuota struct {
+	maxEntries int
+	maxBytes   int64
+}
+
+// CategoryQuota limits the category to at most maxEntries keys with a value or default and at most maxBytes
+// bytes of encoded values and defaults, as counted by Bounded. A limit is ignored if it is not positive. Set,
+// SetDefault and the other methods setting values or defaults return an error wrapping QuotaExceededErr instead
+// of exceeding a limit, so that a misbehaving plugin cannot fill a shared store. Keys belong to the category
+// assigned with SetDefault; keys without a category are not limited. The option may be given once for each
+// category.
+func CategoryQuota(category string, maxEntries int, maxBytes int64) Option {
+	return func(o *options) {
+		if o.quotas == nil {
+			o.quotas = make(map[string]categoryQuota)
+		}
+		o.quotas[category] = categoryQuota{maxEntries: maxEntries, maxBytes: maxBytes}
+	}
+}
+
+// Count returns the number of keys with a value or a default.
+func (db *KVStore) Count() (int, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return 0, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return 0, err
+	}
+	var n int
+	err := db.sqx.Get(&n, `SELECT COUNT(*) FROM kv WHERE value IS NOT NULL OR original IS NOT NULL;`)
+	return n, err
+}
+
+// CountByCategory returns the number of keys with a value or a default in the given category.
+func (db *KVStore) CountByCategory(category string) (int, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return 0, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return 0, err
+	}
+	var n int
+	err := db.sqx.Get(&n, `SELECT COUNT(*) FROM kv WHERE category=? AND (value IS NOT NULL OR original IS NOT NULL);`,
+		category)
+	return n, err
+}
+
+// checkQuota returns an error wrapping QuotaExceededErr if setting the key to an encoded value of the given size
+// would exceed the quota of its category.
+func (db *KVStore) checkQuota(q sqlx.Queryer, key string, size int) error {
+	if len(db.opts.quotas) == 0 {
+		return nil
+	}
+	var category sql.NullString
+	var present bool
+	var old int64
+	err := q.QueryRowx(`SELECT category,value IS NOT NULL OR original IS NOT NULL,COALESCE(LENGTH(value),0)
+FROM kv WHERE key=?;`, key).Scan(&category, &present, &old)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !category.Valid) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entries := 0
+	if !present {
+		entries = 1
+	}
+	return db.checkCategoryQuota(q, category.String, entries, int64(size)-old)
+}
+
+// checkDefaultQuota returns an error wrapping QuotaExceededErr if setting the default of the key to an encoded
+// value of the given size and its category to the given category would exceed the quota of the category.
+func (db *KVStore) checkDefaultQuota(q sqlx.Queryer, key, category string, size int) error {
+	if _, ok := db.opts.quotas[category]; !ok {
+		return nil
+	}
+	var current sql.NullString
+	var present bool
+	var value, original int64
+	err := q.QueryRowx(`SELECT category,value IS NOT NULL OR original IS NOT NULL,COALESCE(LENGTH(value),0),
+COALESCE(LENGTH(original),0) FROM kv WHERE key=?;`, key).Scan(&current, &present, &value, &original)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if present && current.Valid && current.String == category {
+		return db.checkCategoryQuota(q, category, 0, int64(size)-original)
+	}
+	return db.checkCategoryQuota(q, category, 1, int64(size)+value)
+}
+
+// checkCategoryQuota returns an error wrapping QuotaExceededErr if adding the given number of keys and bytes to
+// the category would exceed its quota. Writes that do not add keys or bytes are always allowed, even if the
+// category already exceeds its quota.
+func (db *KVStore) checkCategoryQuota(q sqlx.Queryer, category string, entries int, size int64) error {
+	quota, ok := db.opts.quotas[category]
+	if !ok || (entries <= 0 || quota.maxEntries <= 0) && (size <= 0 || quota.maxBytes <= 0) {
+		return nil
+	}
+	var count int
+	var total int64
+	err := q.QueryRowx(`SELECT COUNT(*),COALESCE(SUM(COALESCE(LENGTH(value),0)+COALESCE(LENGTH(original),0)),0)
+FROM kv WHERE category=? AND (value IS NOT NULL OR original IS NOT NULL);`, category).Scan(&count, &total)
+	if err != nil {
+		return err
+	}
+	if entries > 0 && quota.maxEntries > 0 && count+entries > quota.maxEntries {
+		return fmt.Errorf("%w: more than %d keys in category %q", QuotaExceededErr, quota.maxEntries, category)
+	}
+	if size > 0 && quota.maxBytes > 0 && total+size > quota.maxBytes {
+		return fmt.Errorf("%w: more than %d bytes in category %q", QuotaExceededErr, quota.maxBytes, category)
+	}
+	return nil
+}
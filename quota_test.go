@@ -0,0 +1,72 @@
+package kvstore
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCategoryQuota(t *testing.T) {
+	db := New(CategoryQuota("plugin", 2, 256))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	for _, key := range []string{"plugin.a", "plugin.b"} {
+		if err := db.SetDefault(key, "", KeyInfo{Category: "plugin"}); err != nil {
+			t.Fatalf(`failed to set default: %v`, err)
+		}
+	}
+	if err := db.SetDefault("plugin.c", "", KeyInfo{Category: "plugin"}); !errors.Is(err, QuotaExceededErr) {
+		t.Errorf(`expected QuotaExceededErr for third key, got %v`, err)
+	}
+	if err := db.SetDefault("other", "", KeyInfo{Category: "other"}); err != nil {
+		t.Errorf(`expected key in other category to be unlimited, got %v`, err)
+	}
+	if err := db.Set("plugin.a", "small"); err != nil {
+		t.Errorf(`failed to set value within quota: %v`, err)
+	}
+	if err := db.Set("plugin.b", strings.Repeat("x", 300)); !errors.Is(err, QuotaExceededErr) {
+		t.Errorf(`expected QuotaExceededErr for large value, got %v`, err)
+	}
+	if err := db.Set("unrelated", strings.Repeat("x", 300)); err != nil {
+		t.Errorf(`expected key without category to be unlimited, got %v`, err)
+	}
+	if n, err := db.Count(); err != nil || n != 4 {
+		t.Errorf(`expected 4 keys, got %v, %v`, n, err)
+	}
+	if n, err := db.CountByCategory("plugin"); err != nil || n != 2 {
+		t.Errorf(`expected 2 keys in category, got %v, %v`, n, err)
+	}
+}
+
+func TestCategoryQuotaConcurrent(t *testing.T) {
+	db := New(CategoryQuota("plugin", 0, 256))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	keys := []string{"plugin.a", "plugin.b", "plugin.c", "plugin.d", "plugin.e", "plugin.f"}
+	for _, key := range keys {
+		if err := db.SetDefault(key, nil, KeyInfo{Category: "plugin"}); err != nil {
+			t.Fatalf(`failed to set default: %v`, err)
+		}
+	}
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := db.Set(key, strings.Repeat("x", 100)); err != nil && !errors.Is(err, QuotaExceededErr) {
+				t.Errorf(`failed to set key: %v`, err)
+			}
+		}()
+	}
+	wg.Wait()
+	var size int64
+	err := db.sqx.Get(&size, `SELECT SUM(COALESCE(LENGTH(value),0)+COALESCE(LENGTH(original),0)) FROM kv;`)
+	if err != nil || size > 256 {
+		t.Errorf(`expected concurrent writes to stay within quota, got %v bytes, %v`, size, err)
+	}
+}
@@ -0,0 +1,73 @@
+package kvstore
+
+import (
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SetRaw sets the value for the given key to b without encoding it, for data that is already serialized
+// such as protobuf messages or images. Get returns raw values as []byte.
+func (db *KVStore) SetRaw(key string, b []byte) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	if err := db.checkConstraints(db.sqx, key, b); err != nil {
+		return err
+	}
+	notify := db.hasListeners()
+	var old any
+	if notify {
+		old = db.current(db.sqx, key)
+	}
+	defer db.cache.remove(key)
+	err := db.inTx(func(tx *sqlx.Tx) error {
+		if err := db.checkQuota(tx, key, len(b)); err != nil {
+			return err
+		}
+		return db.putCodec(tx, key, b, codecRaw, typeName(b))
+	})
+	if err != nil {
+		return err
+	}
+	if notify {
+		if err := db.notify(change{key: key, old: old, new: b}); err != nil {
+			return err
+		}
+	}
+	return db.evict(key)
+}
+
+// GetRaw returns the stored bytes of the value for the given key without decoding them, or of the default if
+// no value is set. These are the bytes given to SetRaw for raw values and the encoding of other values.
+// NotFoundErr is returned if there is neither a value nor a default.
+func (db *KVStore) GetRaw(key string) ([]byte, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	sv, _, err := db.readValue(db.sqx, key)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case sv.value != nil:
+		// gob encoded values carry the encryption marker, values of other codecs the prefix of their codec
+		codec, encrypted := plainCodec(sv.codec.String)
+		if encrypted || (codec == codecGob && isEncrypted(sv.value)) {
+			return decrypt(sv.value)
+		}
+		return sv.value, nil
+	case sv.original != nil:
+		if isEncrypted(sv.original) {
+			return decrypt(sv.original)
+		}
+		return sv.original, nil
+	}
+	return nil, NotFoundErr
+}
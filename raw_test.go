@@ -0,0 +1,32 @@
+package kvstore
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRaw(t *testing.T) {
+	db := openTestStore(t)
+	data := []byte{0x89, 'P', 'N', 'G', 0, 1, 2}
+	if err := db.SetRaw("image", data); err != nil {
+		t.Fatalf(`failed to set raw value: %v`, err)
+	}
+	b, err := db.GetRaw("image")
+	if err != nil || !bytes.Equal(b, data) {
+		t.Errorf(`expected raw bytes, got %v, %v`, b, err)
+	}
+	v, err := db.Get("image")
+	if b, ok := v.([]byte); !ok || !bytes.Equal(b, data) || err != nil {
+		t.Errorf(`expected Get to return raw bytes, got %v, %v`, v, err)
+	}
+	var size int
+	if err := db.sqx.Get(&size, `SELECT LENGTH(value) FROM kv WHERE key='image';`); err != nil || size != len(data) {
+		t.Errorf(`expected raw value to be stored without encoding, got %v bytes, %v`, size, err)
+	}
+	if err := db.Set("image", 1); err != nil {
+		t.Fatalf(`failed to set value: %v`, err)
+	}
+	if v, err := db.Get("image"); v != 1 || err != nil {
+		t.Errorf(`expected gob value after Set, got %v, %v`, v, err)
+	}
+}
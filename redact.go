@@ -0,0 +1,75 @@
+package kvstore
+
+// Redacted replaces the values and defaults of sensitive keys in exports, see KeyInfo.Sensitive.
+const Redacted = "[REDACTED]"
+
+// RedactSensitive configures the store to replace the values of keys marked as sensitive in their key info with
+// Redacted in the results of GetAll, GetByCategory, GetByCategoryTree, GetSubtree, GetPage, and ForEach, so
+// that dumps of the store for support bundles or logs do not leak secrets, and Preload does not cache them. Get
+// and GetMany still return the actual values. Defaults of sensitive keys are always redacted in the schema,
+// regardless of this option.
+func RedactSensitive() Option {
+	return func(o *options) {
+		o.redactSensitive = true
+	}
+}
+
+// sensitiveKeys returns the keys marked as sensitive in their key info.
+func (db *KVStore) sensitiveKeys() (map[string]bool, error) {
+	rows, err := db.sqx.Queryx(`SELECT key,extra FROM kv WHERE extra IS NOT NULL;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		var extra []byte
+		if err := rows.Scan(&key, &extra); err != nil {
+			return keys, err
+		}
+		var info KeyInfo
+		if err := info.unmarshalExtra(extra); err != nil {
+			return keys, err
+		}
+		if info.Sensitive {
+			keys[key] = true
+		}
+	}
+	return keys, rows.Err()
+}
+
+// redactedKeys returns the keys whose values are replaced with Redacted, which are the sensitive keys if the
+// store has been configured with RedactSensitive and none otherwise.
+func (db *KVStore) redactedKeys() (map[string]bool, error) {
+	if !db.opts.redactSensitive {
+		return nil, nil
+	}
+	return db.sensitiveKeys()
+}
+
+// redact replaces the values of redacted keys in the map with Redacted.
+func (db *KVStore) redact(pairs map[string]any) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+	redacted, err := db.redactedKeys()
+	if err != nil {
+		return err
+	}
+	for key := range redacted {
+		if _, ok := pairs[key]; ok {
+			pairs[key] = Redacted
+		}
+	}
+	return nil
+}
+
+// readAll returns all key-value pairs of the store like GetAll, but with the actual values of sensitive keys if
+// the store is a KVStore, so that stores opened with RedactSensitive can be compared and merged.
+func readAll(s KeyValueStore) (map[string]any, error) {
+	if db, ok := s.(*KVStore); ok {
+		return db.getAll(0)
+	}
+	return s.GetAll(0)
+}
@@ -0,0 +1,154 @@
+package kvstore
+
+import (
+	"testing"
+)
+
+func TestRedactSensitive(t *testing.T) {
+	db := New(RedactSensitive())
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	if err := db.SetDefault("api.token", "default", KeyInfo{Category: "api", Sensitive: true}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.SetDefault("api.url", "https://example.com", KeyInfo{Category: "api"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.Set("api.token", "s3cr3t"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if v, err := db.Get("api.token"); err != nil || v != "s3cr3t" {
+		t.Errorf(`expected Get to return the actual value, got %v, %v`, v, err)
+	}
+	if info, ok := db.Info("api.token"); !ok || !info.Sensitive {
+		t.Errorf(`expected key info to be sensitive, got %+v`, info)
+	}
+	all, err := db.GetAll(0)
+	if err != nil || all["api.token"] != Redacted || all["api.url"] != "https://example.com" {
+		t.Errorf(`expected redacted GetAll, got %v, %v`, all, err)
+	}
+	byCategory, err := db.GetByCategory("api")
+	if err != nil || byCategory["api.token"] != Redacted {
+		t.Errorf(`expected redacted GetByCategory, got %v, %v`, byCategory, err)
+	}
+	schema, err := db.Schema()
+	if err != nil || schema.Keys[0].Default != Redacted || !schema.Keys[0].Sensitive {
+		t.Errorf(`expected redacted default in schema, got %+v, %v`, schema.Keys, err)
+	}
+}
+
+// opaqueStore hides the concrete type of a store so that its redacted GetAll is used.
+type opaqueStore struct {
+	KeyValueStore
+}
+
+func TestMergeRedacted(t *testing.T) {
+	open := func() *KVStore {
+		db := New(RedactSensitive())
+		if err := db.Open(t.TempDir()); err != nil {
+			t.Fatalf(`failed to open database: %v`, err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if err := db.SetDefault("api.token", "", KeyInfo{Sensitive: true}); err != nil {
+			t.Fatalf(`failed to set default: %v`, err)
+		}
+		return db
+	}
+	src, base, db := open(), open(), open()
+	if err := src.Set("api.token", "theirs"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Set("api.token", "ours"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	d, err := db.Diff(src)
+	if err != nil || len(d.Changed) != 1 {
+		t.Errorf(`expected the sensitive key to differ, got %+v, %v`, d, err)
+	}
+	if err := db.Merge(opaqueStore{src}, MergeTheirs); err != nil {
+		t.Fatalf(`failed to merge: %v`, err)
+	}
+	if v, _ := db.Get("api.token"); v != "ours" {
+		t.Errorf(`expected redacted value to be skipped, got %v`, v)
+	}
+	if _, err := db.Merge3(opaqueStore{base}, opaqueStore{src}, MergeTheirs); err != nil {
+		t.Fatalf(`failed to merge: %v`, err)
+	}
+	if v, _ := db.Get("api.token"); v != "ours" {
+		t.Errorf(`expected redacted value to be skipped by Merge3, got %v`, v)
+	}
+	if err := db.Merge(src, MergeTheirs); err != nil {
+		t.Fatalf(`failed to merge: %v`, err)
+	}
+	if v, _ := db.Get("api.token"); v != "theirs" {
+		t.Errorf(`expected actual value to be merged, got %v`, v)
+	}
+	if err := base.Set("api.token", "theirs"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := src.Set("api.token", "new"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if _, err := db.Merge3(base, src, MergeOurs); err != nil {
+		t.Fatalf(`failed to merge: %v`, err)
+	}
+	if v, _ := db.Get("api.token"); v != "new" {
+		t.Errorf(`expected actual value to be merged by Merge3, got %v`, v)
+	}
+}
+
+// openRedacting opens a store with RedactSensitive holding a sensitive and an ordinary key.
+func openRedacting(t *testing.T, opts ...Option) *KVStore {
+	db := New(append([]Option{RedactSensitive()}, opts...)...)
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.SetDefault("api.token", "", KeyInfo{Sensitive: true}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.SetMany(map[string]any{"api.token": "s3cr3t", "api.url": "https://example.com"}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	return db
+}
+
+func TestRedactForEach(t *testing.T) {
+	db := openRedacting(t)
+	values := make(map[string]any)
+	err := db.ForEach(func(key string, value any) error {
+		values[key] = value
+		return nil
+	})
+	if err != nil || values["api.token"] != Redacted || values["api.url"] != "https://example.com" {
+		t.Errorf(`expected redacted ForEach, got %v, %v`, values, err)
+	}
+}
+
+func TestRedactGetPage(t *testing.T) {
+	db := openRedacting(t)
+	page, err := db.GetPage(0, 0, OrderKeyAsc)
+	if err != nil || len(page) != 2 || page[0].Value != Redacted || page[1].Value != "https://example.com" {
+		t.Errorf(`expected redacted GetPage, got %v, %v`, page, err)
+	}
+}
+
+func TestRedactGetSubtree(t *testing.T) {
+	db := openRedacting(t)
+	tree, err := db.GetSubtree("")
+	if err != nil || tree["api.token"] != Redacted || tree["api.url"] != "https://example.com" {
+		t.Errorf(`expected redacted GetSubtree, got %v, %v`, tree, err)
+	}
+}
+
+func TestRedactPreload(t *testing.T) {
+	db := openRedacting(t, Cache(10, 0))
+	if n, err := db.Preload(); err != nil || n != 1 {
+		t.Errorf(`expected only the ordinary key to be preloaded, got %v, %v`, n, err)
+	}
+	if v, err := db.Get("api.token"); err != nil || v != "s3cr3t" {
+		t.Errorf(`expected Get to return the actual value, got %v, %v`, v, err)
+	}
+}
@@ -0,0 +1,107 @@
+package kvstore
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var KeyExistsErr = errors.New(`key already exists`)
+
+// Rename renames oldKey to newKey in one transaction, preserving its value, default, and key info.
+// NotFoundErr is returned if there is no oldKey. If newKey already exists, it is replaced if overwrite
+// is true and KeyExistsErr is returned otherwise.
+func (db *KVStore) Rename(oldKey, newKey string, overwrite bool) error {
+	if oldKey == newKey {
+		return nil
+	}
+	return db.move(oldKey, newKey, overwrite, false)
+}
+
+// Copy copies the value, default, and key info of src to dst in one transaction, replacing dst if it exists.
+// NotFoundErr is returned if there is no src key.
+func (db *KVStore) Copy(src, dst string) error {
+	if src == dst {
+		return nil
+	}
+	return db.move(src, dst, true, true)
+}
+
+// move renames or copies the row of the src key to dst.
+func (db *KVStore) move(src, dst string, overwrite, keep bool) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	defer db.cache.remove(src, dst)
+	var exists [2]bool
+	for i, key := range []string{src, dst} {
+		if err := tx.Get(&exists[i], `SELECT EXISTS(SELECT 1 FROM kv WHERE key=?);`, key); err != nil {
+			return err
+		}
+	}
+	if !exists[0] {
+		return NotFoundErr
+	}
+	if exists[1] && !overwrite {
+		return KeyExistsErr
+	}
+	if err := checkKind(tx, dst, "value"); err != nil {
+		return err
+	}
+	if exists[1] {
+		if err := db.beforeDelete(dst); err != nil {
+			return keyError("delete", dst, err)
+		}
+	}
+	var changes []change
+	if db.hasListeners() {
+		moved := db.current(tx, src)
+		if !keep {
+			changes = append(changes, change{key: src, old: moved})
+		}
+		changes = append(changes, change{key: dst, old: db.current(tx, dst), new: moved})
+	}
+	if _, err := tx.Exec(`DELETE FROM kv WHERE key=?;`, dst); err != nil {
+		return err
+	}
+	if keep {
+		columns, err := otherColumns(tx, "kv", "key")
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO kv(key,`+columns+`) SELECT ?,`+columns+` FROM kv WHERE key=?;`, dst, src)
+		if err != nil {
+			return err
+		}
+		now := db.now().UnixMilli()
+		if _, err := tx.Exec(`UPDATE kv SET created_at=?,updated_at=? WHERE key=?;`, now, now, dst); err != nil {
+			return err
+		}
+	} else if _, err := tx.Exec(`UPDATE kv SET key=? WHERE key=?;`, dst, src); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := db.notify(changes...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// otherColumns returns a comma-separated list of all columns of the table except the given one.
+func otherColumns(q sqlx.Queryer, table, except string) (string, error) {
+	var columns []string
+	err := sqlx.Select(q, &columns, `SELECT name FROM pragma_table_info('`+table+`') WHERE name<>? ORDER BY cid;`, except)
+	return strings.Join(columns, ","), err
+}
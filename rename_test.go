@@ -0,0 +1,48 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRenameCopy(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.SetDefault("old", "default", KeyInfo{Description: "a key", Category: "tests"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.Set("old", "value"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Set("taken", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Rename("old", "taken", false); !errors.Is(err, KeyExistsErr) {
+		t.Errorf(`expected KeyExistsErr, got %v`, err)
+	}
+	if err := db.Rename("missing", "new", false); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+	if err := db.Rename("old", "new", false); err != nil {
+		t.Fatalf(`failed to rename key: %v`, err)
+	}
+	if ok, _ := db.Has("old"); ok {
+		t.Errorf(`old key still exists after rename`)
+	}
+	if v, _ := db.Get("new"); v != "value" {
+		t.Errorf(`expected renamed value, got %v`, v)
+	}
+	if err := db.Copy("new", "taken"); err != nil {
+		t.Fatalf(`failed to copy key: %v`, err)
+	}
+	for _, key := range []string{"new", "taken"} {
+		if v, _ := db.Get(key); v != "value" {
+			t.Errorf(`expected value for %v, got %v`, key, v)
+		}
+		if v, _ := db.GetDefault(key); v != "default" {
+			t.Errorf(`expected default for %v, got %v`, key, v)
+		}
+		if info, ok := db.Info(key); !ok || info.Description != "a key" || info.Category != "tests" {
+			t.Errorf(`key info was not preserved for %v: %v`, key, info)
+		}
+	}
+}
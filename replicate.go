@@ -0,0 +1,120 @@
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+)
+
+var ReplicationErr = errors.New(`failed to replicate change`)
+
+// ReplicationPolicy specifies what happens if forwarding a change to the replica fails.
+type ReplicationPolicy int
+
+const (
+	ReplicationFail  ReplicationPolicy = iota // the mutating method returns an error wrapping ReplicationErr
+	ReplicationLog                            // the error is logged with the standard logger and the change is dropped
+	ReplicationQueue                          // the change is queued and forwarded again with the next change or by SyncReplica
+)
+
+// Replicator receives a changed key together with the value Get returns for it after the change, or nil if the
+// key has been deleted.
+type Replicator func(key string, value any) error
+
+// ReplicateTo configures the store to forward every successful change of a key to the replica, which must be
+// open while the store is used, see ReplicateFunc.
+func ReplicateTo(replica KeyValueStore, policy ReplicationPolicy) Option {
+	return ReplicateFunc(func(key string, value any) error {
+		if value == nil {
+			err := replica.Delete(key)
+			if errors.Is(err, NotFoundErr) {
+				return nil
+			}
+			return err
+		}
+		return replica.Set(key, value)
+	}, policy)
+}
+
+// ReplicateFunc configures the store to call fn for every successful change of a key, after the change has been
+// committed and the change listeners have been called, including changes by Undo and Redo. Changes of keys are
+// those reported to change listeners, see OnChange; lists, sets, hashes, queues, streams, defaults, and TTLs are
+// not replicated. As in the undo journal, a key set to nil is replicated as a deletion. Changes are forwarded in
+// the order in which they are reported, and the policy determines what happens if fn returns an error. Since the
+// change has already been committed, the store and its replica differ when an error is returned.
+func ReplicateFunc(fn Replicator, policy ReplicationPolicy) Option {
+	return func(o *options) {
+		o.replicator = fn
+		o.replicationPolicy = policy
+	}
+}
+
+// replication holds the changes that could not be forwarded to the replica with policy ReplicationQueue.
+type replication struct {
+	mutex sync.Mutex
+	queue []change
+}
+
+// replicate forwards changes to the replica according to the replication policy.
+func (db *KVStore) replicate(changes ...change) error {
+	if db.opts.replicator == nil || len(changes) == 0 {
+		return nil
+	}
+	r := &db.replication
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if db.opts.replicationPolicy == ReplicationQueue {
+		r.queue = append(r.queue, changes...)
+		db.drainReplication()
+		return nil
+	}
+	for _, c := range changes {
+		err := db.opts.replicator(c.key, c.new)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("%w: key %q: %w", ReplicationErr, c.key, err)
+		if db.opts.replicationPolicy == ReplicationFail {
+			return err
+		}
+		log.Print(err)
+	}
+	return nil
+}
+
+// drainReplication forwards queued changes until one fails, which is returned. The replication mutex must be
+// held.
+func (db *KVStore) drainReplication() error {
+	r := &db.replication
+	for len(r.queue) > 0 {
+		c := r.queue[0]
+		if err := db.opts.replicator(c.key, c.new); err != nil {
+			return fmt.Errorf("%w: key %q: %w", ReplicationErr, c.key, err)
+		}
+		r.queue[0] = change{}
+		r.queue = r.queue[1:]
+	}
+	r.queue = slices.Clip(r.queue)
+	return nil
+}
+
+// SyncReplica forwards changes queued with policy ReplicationQueue to the replica and returns an error wrapping
+// ReplicationErr if not all of them could be forwarded.
+func (db *KVStore) SyncReplica() error {
+	if db.opts.replicator == nil {
+		return nil
+	}
+	db.replication.mutex.Lock()
+	defer db.replication.mutex.Unlock()
+	return db.drainReplication()
+}
+
+// PendingReplication returns the number of changes queued with policy ReplicationQueue that have not been
+// forwarded to the replica yet.
+func (db *KVStore) PendingReplication() int {
+	db.replication.mutex.Lock()
+	defer db.replication.mutex.Unlock()
+	return len(db.replication.queue)
+}
@@ -0,0 +1,69 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReplicateTo(t *testing.T) {
+	replica := openTestStore(t)
+	db := New(ReplicateTo(replica, ReplicationFail))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	if err := db.Set("a", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.SetMany(map[string]any{"b": "x", "c": 2.5}); err != nil {
+		t.Fatalf(`failed to set keys: %v`, err)
+	}
+	if err := db.Delete("b"); err != nil {
+		t.Fatalf(`failed to delete key: %v`, err)
+	}
+	all, err := replica.GetAll(0)
+	if err != nil || len(all) != 2 || all["a"] != 1 || all["c"] != 2.5 {
+		t.Errorf(`wrong replica contents: %v, %v`, all, err)
+	}
+}
+
+func TestReplicationPolicies(t *testing.T) {
+	failing := errors.New("replica unavailable")
+	for _, policy := range []ReplicationPolicy{ReplicationFail, ReplicationLog, ReplicationQueue} {
+		var replicated []string
+		down := true
+		db := New(ReplicateFunc(func(key string, value any) error {
+			if down {
+				return failing
+			}
+			replicated = append(replicated, key)
+			return nil
+		}, policy))
+		if err := db.Open(t.TempDir()); err != nil {
+			t.Fatalf(`failed to open database: %v`, err)
+		}
+		err := db.Set("a", 1)
+		if policy == ReplicationFail {
+			if !errors.Is(err, ReplicationErr) || !errors.Is(err, failing) {
+				t.Errorf(`expected ReplicationErr, got %v`, err)
+			}
+		} else if err != nil {
+			t.Errorf(`expected no error with policy %v, got %v`, policy, err)
+		}
+		if v, err := db.Get("a"); err != nil || v != 1 {
+			t.Errorf(`expected value to be committed despite replication failure, got %v, %v`, v, err)
+		}
+		down = false
+		if err := db.Set("b", 2); err != nil {
+			t.Errorf(`failed to set key: %v`, err)
+		}
+		want := 1
+		if policy == ReplicationQueue {
+			want = 2
+		}
+		if len(replicated) != want || replicated[len(replicated)-1] != "b" || db.PendingReplication() != 0 {
+			t.Errorf(`wrong replicated keys with policy %v: %v`, policy, replicated)
+		}
+		db.Close()
+	}
+}
@@ -0,0 +1,111 @@
+package kvstore
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// RetentionAction specifies what happens to entries whose retention period has expired.
+type RetentionAction int
+
+const (
+	RetentionDelete    RetentionAction = iota // the key is removed with its value and default
+	RetentionAnonymize                        // the value is replaced, see RetentionRule
+)
+
+// RetentionRule limits how long values are kept. The rule applies to keys starting with Prefix and in the given
+// Category, an empty prefix or category matching all keys. Values that have not been modified for longer than
+// MaxAge are deleted or anonymized according to Action. For RetentionAnonymize, the value is replaced with the
+// result of Anonymize, which may return nil to delete the key. If Anonymize is nil, the key is reverted to its
+// default instead. A rule with a non-positive MaxAge is ignored.
+type RetentionRule struct {
+	Prefix    string
+	Category  string
+	MaxAge    time.Duration
+	Action    RetentionAction
+	Anonymize func(key string, value any) any
+}
+
+// Retention adds retention rules to the store, which are evaluated by ApplyRetention. The option may be given
+// several times; a value matched by several rules is subject to the first of them that has expired.
+func Retention(rules ...RetentionRule) Option {
+	return func(o *options) {
+		o.retention = append(o.retention, rules...)
+	}
+}
+
+// ApplyRetention deletes or anonymizes all values whose retention period according to the rules given with the
+// Retention option has expired, and returns the number of affected keys. It is meant to be called regularly as
+// part of the maintenance of a store holding telemetry or other personal data, so that the application can
+// comply with retention requirements.
+func (db *KVStore) ApplyRetention() (int, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return 0, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, rule := range db.opts.retention {
+		if rule.MaxAge <= 0 {
+			continue
+		}
+		m, err := db.applyRetentionRule(rule)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// applyRetentionRule applies a single retention rule and returns the number of affected keys.
+func (db *KVStore) applyRetentionRule(rule RetentionRule) (int, error) {
+	cond := `value IS NOT NULL AND coalesce(updated_at,0)<=?`
+	args := []any{db.now().Add(-rule.MaxAge).UnixMilli()}
+	if rule.Prefix != "" {
+		cond += ` AND key GLOB ?`
+		args = append(args, globPrefix(rule.Prefix))
+	}
+	if rule.Category != "" {
+		cond += ` AND category=?`
+		args = append(args, rule.Category)
+	}
+	var keys []string
+	if err := db.sqx.Select(&keys, `SELECT key FROM kv WHERE `+cond+` ORDER BY key;`, args...); err != nil {
+		return 0, err
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if rule.Action != RetentionAnonymize {
+		return len(keys), db.deleteWhere(cond, args...)
+	}
+	err := db.Update(func(tx Tx) error {
+		for _, key := range keys {
+			if rule.Anonymize == nil {
+				if err := tx.Revert(key); err != nil {
+					return err
+				}
+				continue
+			}
+			v, err := tx.Get(key)
+			if err != nil {
+				return err
+			}
+			if v = rule.Anonymize(key, v); v == nil {
+				err = tx.Delete(key)
+			} else {
+				err = tx.Set(key, v)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
@@ -0,0 +1,56 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplyRetention(t *testing.T) {
+	db := New(Retention(
+		RetentionRule{Prefix: "telemetry/", MaxAge: 30 * 24 * time.Hour},
+		RetentionRule{Prefix: "user/", MaxAge: time.Hour, Action: RetentionAnonymize,
+			Anonymize: func(key string, value any) any { return "anonymous" }},
+		RetentionRule{Category: "recent", MaxAge: time.Hour, Action: RetentionAnonymize},
+	))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	for k, v := range map[string]any{"telemetry/old": 1, "telemetry/new": 2, "user/name": "Alice", "theme": "dark"} {
+		if err := db.Set(k, v); err != nil {
+			t.Fatalf(`failed to set %q: %v`, k, err)
+		}
+	}
+	if err := db.SetDefault("recent/file", "none", KeyInfo{Category: "recent"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.Set("recent/file", "secret.txt"); err != nil {
+		t.Fatalf(`failed to set value: %v`, err)
+	}
+	age := func(key string, d time.Duration) {
+		if _, err := db.sqx.Exec(`UPDATE kv SET updated_at=? WHERE key=?;`, time.Now().Add(-d).UnixMilli(), key); err != nil {
+			t.Fatalf(`failed to age key %q: %v`, key, err)
+		}
+	}
+	age("telemetry/old", 31*24*time.Hour)
+	age("telemetry/new", 29*24*time.Hour)
+	age("user/name", 2*time.Hour)
+	age("recent/file", 2*time.Hour)
+	age("theme", 365*24*time.Hour)
+	n, err := db.ApplyRetention()
+	if err != nil || n != 3 {
+		t.Fatalf(`expected 3 affected keys, got %d, %v`, n, err)
+	}
+	if _, err := db.Get("telemetry/old"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected expired telemetry to be deleted, got %v`, err)
+	}
+	for k, want := range map[string]any{"telemetry/new": 2, "user/name": "anonymous", "recent/file": "none", "theme": "dark"} {
+		if v, err := db.Get(k); err != nil || v != want {
+			t.Errorf(`expected %v for %q, got %v, %v`, want, k, v, err)
+		}
+	}
+	if n, err := db.ApplyRetention(); err != nil || n != 0 {
+		t.Errorf(`expected no affected keys in second run, got %d, %v`, n, err)
+	}
+}
@@ -0,0 +1,203 @@
+package kvstore
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var RevisionMismatchErr = errors.New(`revision mismatch`)
+
+// Every write of a value or default assigns the key a new revision taken from a sequence shared by all keys,
+// so revisions of a key increase monotonically even if the key is deleted and recreated. Revision 0 means
+// that there is no key. Deleting and renaming keys advance the sequence as well, so its current value is the
+// revision of the whole store.
+
+// initRevisions adds the revision column and the triggers maintaining it.
+func initRevisions(tx *sqlx.Tx) error {
+	if err := addColumn(tx, "kv", "revision", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_sequence(
+  name TEXT PRIMARY KEY NOT NULL,
+  value INTEGER NOT NULL
+);
+INSERT OR IGNORE INTO kv_sequence(name,value) VALUES('revision',0);
+CREATE TRIGGER IF NOT EXISTS kv_revision_insert AFTER INSERT ON kv BEGIN
+  UPDATE kv_sequence SET value=value+1 WHERE name='revision';
+  UPDATE kv SET revision=(SELECT value FROM kv_sequence WHERE name='revision') WHERE key=new.key;
+END;
+CREATE TRIGGER IF NOT EXISTS kv_revision_update AFTER UPDATE OF value,original ON kv BEGIN
+  UPDATE kv_sequence SET value=value+1 WHERE name='revision';
+  UPDATE kv SET revision=(SELECT value FROM kv_sequence WHERE name='revision') WHERE key=new.key;
+END;
+CREATE TRIGGER IF NOT EXISTS kv_revision_delete AFTER DELETE ON kv BEGIN
+  UPDATE kv_sequence SET value=value+1 WHERE name='revision';
+END;
+CREATE TRIGGER IF NOT EXISTS kv_revision_rename AFTER UPDATE OF key ON kv WHEN old.key<>new.key BEGIN
+  UPDATE kv_sequence SET value=value+1 WHERE name='revision';
+END;
+`)
+	return err
+}
+
+// revision returns the revision of the key, 0 if there is no key.
+func revision(q sqlx.Queryer, key string) (uint64, error) {
+	var rev uint64
+	err := sqlx.Get(q, &rev, `SELECT revision FROM kv WHERE key=?;`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return rev, err
+}
+
+// GetWithRevision returns the value for the given key like Get together with its current revision.
+func (db *KVStore) GetWithRevision(key string) (any, uint64, error) {
+	var v any
+	var rev uint64
+	err := db.View(func(tx ReadTx) error {
+		var err error
+		if v, err = tx.Get(key); err != nil {
+			return err
+		}
+		rev, err = revision(tx.(*txStore).tx, key)
+		return err
+	})
+	return v, rev, err
+}
+
+// SetWithRevision sets the value for the given key like Set and returns its new revision.
+func (db *KVStore) SetWithRevision(key string, value any) (uint64, error) {
+	return db.setIfRevision(key, value, 0, false)
+}
+
+// SetIfRevision sets the value for the given key only if its current revision is expectedRev, which is 0 for
+// keys that do not exist, and returns the new revision. Otherwise an error wrapping RevisionMismatchErr is
+// returned. This allows optimistic concurrency across goroutines and processes.
+func (db *KVStore) SetIfRevision(key string, value any, expectedRev uint64) (uint64, error) {
+	return db.setIfRevision(key, value, expectedRev, true)
+}
+
+// setIfRevision sets the value for the key in one transaction, checking its revision if check is true.
+func (db *KVStore) setIfRevision(key string, value any, expectedRev uint64, check bool) (uint64, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return 0, NotOpenErr
+	}
+	value, err := db.beforeSet(key, value)
+	if err != nil {
+		return 0, err
+	}
+	var rev uint64
+	err = db.Update(func(tx Tx) error {
+		t := tx.(*txStore)
+		if check {
+			current, err := revision(t.tx, key)
+			if err != nil {
+				return err
+			}
+			if current != expectedRev {
+				return fmt.Errorf("%w: key %v has revision %v, expected %v", RevisionMismatchErr, key, current, expectedRev)
+			}
+		}
+		if err := tx.Set(key, value); err != nil {
+			return err
+		}
+		var err error
+		rev, err = revision(t.tx, key)
+		return err
+	})
+	if err == nil {
+		db.afterSet(key, value)
+	}
+	return rev, err
+}
+
+// Revision returns the revision of the store, which increases with every change of a value or default and with
+// every deletion or renaming of a key, and is 0 for an empty store that has never been changed. Lists, sets,
+// hashes, queues, and streams are not covered. Sync clients can compare revisions to quickly determine whether
+// anything has changed.
+func (db *KVStore) Revision() (uint64, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return 0, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return 0, err
+	}
+	var rev uint64
+	err := db.sqx.Get(&rev, `SELECT value FROM kv_sequence WHERE name='revision';`)
+	return rev, err
+}
+
+// storeChecksum caches the checksum of the store for the revision it was computed at.
+type storeChecksum struct {
+	mutex    sync.Mutex
+	revision uint64
+	sum      string
+}
+
+// Checksum returns a hash of all keys with their values and defaults as a hexadecimal string, suitable as an
+// ETag. Stores with the same keys, values, and defaults have the same checksum if they have been written with
+// the same options. The checksum is computed when it is first requested after a change of the revision, see
+// Revision, and cached otherwise, so polling it is cheap while nothing changes.
+func (db *KVStore) Checksum() (string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return "", NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return "", err
+	}
+	c := &db.checksum
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var sum string
+	err := db.View(func(tx ReadTx) error {
+		q := tx.(*txStore).tx
+		var rev uint64
+		if err := sqlx.Get(q, &rev, `SELECT value FROM kv_sequence WHERE name='revision';`); err != nil {
+			return err
+		}
+		if rev == c.revision && c.sum != "" {
+			sum = c.sum
+			return nil
+		}
+		h := sha256.New()
+		rows, err := q.Queryx(`SELECT key,value,original FROM kv ORDER BY key ASC;`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var key string
+			var value, original []byte
+			if err := rows.Scan(&key, &value, &original); err != nil {
+				return err
+			}
+			h.Write(binary.AppendUvarint(nil, uint64(len(key))))
+			h.Write([]byte(key))
+			for _, b := range [][]byte{value, original} {
+				// NULL is distinguished from an empty value by writing lengths incremented by one
+				n := uint64(0)
+				if b != nil {
+					n = uint64(len(b)) + 1
+				}
+				h.Write(binary.AppendUvarint(nil, n))
+				h.Write(b)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		sum = hex.EncodeToString(h.Sum(nil))
+		c.revision, c.sum = rev, sum
+		return nil
+	})
+	return sum, err
+}
@@ -0,0 +1,76 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRevisions(t *testing.T) {
+	db := openTestStore(t)
+	rev, err := db.SetIfRevision("a", 1, 0)
+	if err != nil || rev == 0 {
+		t.Fatalf(`failed to create key with SetIfRevision: %v, %v`, rev, err)
+	}
+	if _, err := db.SetIfRevision("a", 2, 0); !errors.Is(err, RevisionMismatchErr) {
+		t.Errorf(`expected RevisionMismatchErr, got %v`, err)
+	}
+	rev2, err := db.SetWithRevision("a", 2)
+	if err != nil || rev2 <= rev {
+		t.Fatalf(`expected increased revision, got %v, %v`, rev2, err)
+	}
+	if _, err := db.SetIfRevision("a", 3, rev); !errors.Is(err, RevisionMismatchErr) {
+		t.Errorf(`expected RevisionMismatchErr for stale revision, got %v`, err)
+	}
+	v, rev3, err := db.GetWithRevision("a")
+	if v != 2 || rev3 != rev2 || err != nil {
+		t.Errorf(`expected 2 at revision %v, got %v at %v, %v`, rev2, v, rev3, err)
+	}
+	if err := db.Delete("a"); err != nil {
+		t.Fatalf(`failed to delete key: %v`, err)
+	}
+	rev4, err := db.SetIfRevision("a", 4, 0)
+	if err != nil || rev4 <= rev3 {
+		t.Errorf(`expected revision to keep increasing after recreating the key, got %v, %v`, rev4, err)
+	}
+}
+
+func TestStoreRevisionAndChecksum(t *testing.T) {
+	db := openTestStore(t)
+	other := openTestStore(t)
+	rev, err := db.Revision()
+	if err != nil || rev != 0 {
+		t.Errorf(`expected revision 0 for new store, got %v, %v`, rev, err)
+	}
+	empty, err := db.Checksum()
+	if err != nil {
+		t.Fatalf(`failed to compute checksum: %v`, err)
+	}
+	for _, s := range []*KVStore{db, other} {
+		if err := s.SetMany(map[string]any{"a": 1, "b": ""}); err != nil {
+			t.Fatalf(`failed to set keys: %v`, err)
+		}
+	}
+	sum, _ := db.Checksum()
+	otherSum, _ := other.Checksum()
+	if sum == empty || sum != otherSum {
+		t.Errorf(`expected equal checksums for equal contents, got %v and %v`, sum, otherSum)
+	}
+	last, _ := db.Revision()
+	for _, change := range []func() error{
+		func() error { return db.Set("a", 2) },
+		func() error { return db.Rename("a", "c", false) },
+		func() error { return db.Delete("c") },
+	} {
+		if err := change(); err != nil {
+			t.Fatalf(`failed to change store: %v`, err)
+		}
+		rev, err := db.Revision()
+		if err != nil || rev <= last {
+			t.Errorf(`expected revision to increase from %v, got %v, %v`, last, rev, err)
+		}
+		last = rev
+		if s, _ := db.Checksum(); s == sum {
+			t.Errorf(`expected checksum to change`)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package kvstore
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var NoBackupPathErr = errors.New(`no backup path given for a positive backup interval`)
+
+// MaintenanceOptions configures the maintenance jobs run in the background by StartMaintenance. A job is only
+// run if its interval is positive.
+type MaintenanceOptions struct {
+	PurgeInterval      time.Duration // interval of PurgeExpired
+	CheckpointInterval time.Duration // interval of WALCheckpoint
+	RetentionInterval  time.Duration // interval of ApplyRetention
+	BackupInterval     time.Duration // interval of Backup to BackupPath
+	BackupPath         string        // the file written by Backup, required if BackupInterval is positive
+	// OnError is called with the name of the job and the error if a job fails. If OnError is nil, errors are
+	// logged with the standard logger. CheckpointBusyErr is not reported, as the checkpoint is tried again.
+	OnError func(job string, err error)
+}
+
+// maintenance holds the state of the background maintenance jobs.
+type maintenance struct {
+	mutex sync.Mutex
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// StartMaintenance starts running the maintenance jobs configured in opts in the background, each in its own
+// interval. Maintenance jobs that have been started before are stopped first. The jobs are stopped by
+// StopMaintenance or Close, which wait for running jobs to finish.
+func (db *KVStore) StartMaintenance(opts MaintenanceOptions) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if opts.BackupInterval > 0 && opts.BackupPath == "" {
+		return NoBackupPathErr
+	}
+	m := &db.maintenance
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.halt()
+	m.stop = make(chan struct{})
+	jobs := []struct {
+		name     string
+		interval time.Duration
+		run      func() error
+	}{
+		{"purge", opts.PurgeInterval, db.PurgeExpired},
+		{"checkpoint", opts.CheckpointInterval, db.WALCheckpoint},
+		{"retention", opts.RetentionInterval, func() error {
+			_, err := db.ApplyRetention()
+			return err
+		}},
+		{"backup", opts.BackupInterval, func() error { return db.Backup(opts.BackupPath) }},
+	}
+	for _, job := range jobs {
+		if job.interval <= 0 {
+			continue
+		}
+		m.wg.Add(1)
+		go func(stop chan struct{}) {
+			defer m.wg.Done()
+			ticker := time.NewTicker(job.interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+				}
+				err := job.run()
+				if err == nil || errors.Is(err, CheckpointBusyErr) {
+					continue
+				}
+				if opts.OnError != nil {
+					opts.OnError(job.name, err)
+				} else {
+					log.Printf("kvstore: maintenance job %s failed: %v", job.name, err)
+				}
+			}
+		}(m.stop)
+	}
+	return nil
+}
+
+// StopMaintenance stops the maintenance jobs started by StartMaintenance and waits for running jobs to finish.
+// It does nothing if no maintenance jobs have been started.
+func (db *KVStore) StopMaintenance() {
+	m := &db.maintenance
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.halt()
+}
+
+// halt stops the running maintenance jobs and waits for them to finish. The mutex must be held.
+func (m *maintenance) halt() {
+	if m.stop == nil {
+		return
+	}
+	close(m.stop)
+	m.wg.Wait()
+	m.stop = nil
+}
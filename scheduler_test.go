@@ -0,0 +1,93 @@
+package kvstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartMaintenance(t *testing.T) {
+	db := New(Retention(RetentionRule{Prefix: "log/", MaxAge: time.Hour}))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	if err := db.Set("log/1", "entry"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if _, err := db.sqx.Exec(`UPDATE kv SET updated_at=0 WHERE key='log/1';`); err != nil {
+		t.Fatalf(`failed to age key: %v`, err)
+	}
+	if err := db.StartMaintenance(MaintenanceOptions{BackupInterval: time.Millisecond}); !errors.Is(err, NoBackupPathErr) {
+		t.Errorf(`expected NoBackupPathErr, got %v`, err)
+	}
+	backup := filepath.Join(t.TempDir(), "backup.db")
+	var mutex sync.Mutex
+	var failures []string
+	err := db.StartMaintenance(MaintenanceOptions{
+		PurgeInterval:      5 * time.Millisecond,
+		CheckpointInterval: 5 * time.Millisecond,
+		RetentionInterval:  5 * time.Millisecond,
+		BackupInterval:     5 * time.Millisecond,
+		BackupPath:         backup,
+		OnError: func(job string, err error) {
+			mutex.Lock()
+			failures = append(failures, job+": "+err.Error())
+			mutex.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf(`failed to start maintenance: %v`, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, statErr := os.Stat(backup)
+		ok, _ := db.Has("log/1")
+		if statErr == nil && !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf(`maintenance jobs did not run: backup %v, retained %v`, statErr, ok)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf(`failed to close database: %v`, err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(failures) > 0 {
+		t.Errorf(`maintenance jobs failed: %v`, failures)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.Set("name", "Alice"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kvstore.sqlite")
+	if err := db.Backup(path); err != nil {
+		t.Fatalf(`backup failed: %v`, err)
+	}
+	if err := db.Set("name", "Bob"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Backup(path); err != nil {
+		t.Fatalf(`failed to replace backup: %v`, err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf(`expected temporary file to be removed, got %v`, err)
+	}
+	copy := New()
+	if err := copy.Open(dir); err != nil {
+		t.Fatalf(`failed to open backup: %v`, err)
+	}
+	defer copy.Close()
+	if v, err := copy.Get("name"); err != nil || v != "Bob" {
+		t.Errorf(`expected Bob in backup, got %v, %v`, v, err)
+	}
+}
@@ -0,0 +1,98 @@
+package kvstore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// Schema describes all keys and categories of a key value store, see ExportSchema.
+type Schema struct {
+	Categories []CategoryInfo `json:"categories,omitempty"`
+	Keys       []SchemaEntry  `json:"keys"`
+}
+
+// SchemaEntry describes a single key with its default and key info.
+type SchemaEntry struct {
+	Key         string   `json:"key"`
+	Default     any      `json:"default,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	Enum        []any    `json:"enum,omitempty"`
+	Unit        string   `json:"unit,omitempty"`
+	Locked      bool     `json:"locked,omitempty"`
+	Sensitive   bool     `json:"sensitive,omitempty"`
+	UIHints
+}
+
+// Schema returns a description of all keys in ascending order with their defaults and key info, together with
+// the infos of all categories as returned by CategoryInfos. If no value type is specified in the key info, the
+// type of the default is used as type of the key. Defaults of sensitive keys are replaced with Redacted.
+func (db *KVStore) Schema() (Schema, error) {
+	schema := Schema{Keys: make([]SchemaEntry, 0)}
+	if atomic.LoadUint32(&db.state) < 256 {
+		return schema, NotOpenErr
+	}
+	var err error
+	if schema.Categories, err = db.CategoryInfos(); err != nil {
+		return schema, err
+	}
+	rows, err := db.sqx.Queryx(`SELECT key,` + originalColumn + `,info,category,extra FROM kv ORDER BY key ASC;`)
+	if err != nil {
+		return schema, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var original, extra []byte
+		var description, category sql.NullString
+		if err := rows.Scan(&key, &original, &description, &category, &extra); err != nil {
+			return schema, err
+		}
+		info := KeyInfo{Description: description.String, Category: category.String}
+		if err := info.unmarshalExtra(extra); err != nil {
+			return schema, fmt.Errorf("invalid key info for key %q: %w", key, err)
+		}
+		entry := SchemaEntry{
+			Key:         key,
+			Category:    info.Category,
+			Description: info.Description,
+			Type:        info.ValueType,
+			Min:         info.Min,
+			Max:         info.Max,
+			Enum:        info.Enum,
+			Unit:        info.Unit,
+			Locked:      info.Locked,
+			Sensitive:   info.Sensitive,
+			UIHints:     info.UIHints,
+		}
+		if original != nil {
+			entry.Default, err = UnmarshalBinary(original)
+			if err != nil {
+				return schema, fmt.Errorf("invalid default for key %q: %w", key, err)
+			}
+			if entry.Type == "" {
+				entry.Type = fmt.Sprintf("%T", entry.Default)
+			}
+			if info.Sensitive {
+				entry.Default = Redacted
+			}
+		}
+		schema.Keys = append(schema.Keys, entry)
+	}
+	return schema, rows.Err()
+}
+
+// ExportSchema returns the schema of the key value store as indented JSON document, which is suitable for
+// generating a settings user interface. Defaults must be JSON serializable.
+func (db *KVStore) ExportSchema() ([]byte, error) {
+	schema, err := db.Schema()
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
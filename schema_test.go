@@ -0,0 +1,37 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExportSchema(t *testing.T) {
+	db := openTestStore(t)
+	maximum := 100.0
+	err := db.SetDefault("volume", 50, KeyInfo{Description: "audio volume", Category: "audio", Max: &maximum})
+	if err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.Set("counter", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	b, err := db.ExportSchema()
+	if err != nil {
+		t.Fatalf(`failed to export schema: %v`, err)
+	}
+	var schema Schema
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatalf(`exported schema is not valid JSON: %v`, err)
+	}
+	if len(schema.Keys) != 2 {
+		t.Fatalf(`expected 2 keys in schema, got %v`, len(schema.Keys))
+	}
+	volume := schema.Keys[1]
+	if volume.Key != "volume" || volume.Default != 50.0 || volume.Type != "int" || volume.Category != "audio" ||
+		volume.Description != "audio volume" || volume.Max == nil || *volume.Max != 100 {
+		t.Errorf(`wrong schema entry: %+v`, volume)
+	}
+	if schema.Keys[0].Key != "counter" || schema.Keys[0].Default != nil {
+		t.Errorf(`wrong schema entry for key without default: %+v`, schema.Keys[0])
+	}
+}
@@ -0,0 +1,124 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var NoFullTextErr = errors.New(`full-text search requires the FullTextSearch option`)
+
+// FullTextSearch enables a full-text index over string values and key descriptions, see Search.
+// The index is dropped when the store is opened without this option. The index requires sqlite's FTS5
+// extension, which the driver github.com/mattn/go-sqlite3 only includes with the build tag sqlite_fts5.
+func FullTextSearch() Option {
+	return func(o *options) {
+		o.fullText = true
+	}
+}
+
+// initSearch creates or drops the full-text index. Triggers record changed keys, which are reindexed
+// before each search, so the index also covers changes made by other processes. The triggers avoid
+// INSERT OR IGNORE because the conflict resolution of an upsert would override it.
+func (db *KVStore) initSearch(tx *sqlx.Tx) error {
+	if !db.opts.fullText {
+		_, err := tx.Exec(`
+DROP TRIGGER IF EXISTS kv_fts_insert;
+DROP TRIGGER IF EXISTS kv_fts_update;
+DROP TRIGGER IF EXISTS kv_fts_delete;
+DROP TABLE IF EXISTS kv_fts_dirty;
+DROP TABLE IF EXISTS kv_fts;
+`)
+		return err
+	}
+	var exists bool
+	err := tx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE name='kv_fts');`)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(`
+CREATE VIRTUAL TABLE kv_fts USING fts5(key UNINDEXED, value, description);
+CREATE TABLE kv_fts_dirty(key TEXT PRIMARY KEY NOT NULL);
+CREATE TRIGGER kv_fts_insert AFTER INSERT ON kv BEGIN
+  INSERT INTO kv_fts_dirty(key) SELECT new.key WHERE NOT EXISTS(SELECT 1 FROM kv_fts_dirty WHERE key=new.key);
+END;
+CREATE TRIGGER kv_fts_update AFTER UPDATE ON kv BEGIN
+  INSERT INTO kv_fts_dirty(key) SELECT old.key WHERE NOT EXISTS(SELECT 1 FROM kv_fts_dirty WHERE key=old.key);
+  INSERT INTO kv_fts_dirty(key) SELECT new.key WHERE NOT EXISTS(SELECT 1 FROM kv_fts_dirty WHERE key=new.key);
+END;
+CREATE TRIGGER kv_fts_delete AFTER DELETE ON kv BEGIN
+  INSERT INTO kv_fts_dirty(key) SELECT old.key WHERE NOT EXISTS(SELECT 1 FROM kv_fts_dirty WHERE key=old.key);
+END;
+INSERT INTO kv_fts_dirty(key) SELECT key FROM kv;
+`)
+	return err
+}
+
+// Search returns the keys whose string value or description match the full-text query, best matches first.
+// The query uses sqlite's FTS5 syntax, e.g. "proxy" or "proxy OR socks". Values that are not strings are not
+// indexed, and the default is indexed if no value is set. Search returns NoFullTextErr if the store has not
+// been opened with the FullTextSearch option.
+func (db *KVStore) Search(query string) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if !db.opts.fullText {
+		return nil, NoFullTextErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	if err := db.reindex(); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0)
+	err := db.sqx.Select(&keys, `SELECT key FROM kv_fts WHERE kv_fts MATCH ? ORDER BY rank;`, query)
+	return keys, err
+}
+
+// reindex updates the full-text index for all keys changed since the last search.
+func (db *KVStore) reindex() error {
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var dirty []string
+	if err := tx.Select(&dirty, `SELECT key FROM kv_fts_dirty;`); err != nil {
+		return err
+	}
+	if len(dirty) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(`DELETE FROM kv_fts WHERE key IN (SELECT key FROM kv_fts_dirty);`); err != nil {
+		return err
+	}
+	for _, key := range dirty {
+		var sv storedValue
+		var description sql.NullString
+		err := tx.QueryRowx(`SELECT `+db.valueColumns()+`,info FROM kv WHERE key=?;`, key).Scan(append(sv.dest(), &description)...)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var text string
+		if !sv.encrypted() {
+			v, _, _ := sv.decode()
+			text, _ = v.(string)
+		}
+		if text == "" && description.String == "" {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO kv_fts(key,value,description) VALUES(?,?,?);`, key, text, description.String); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM kv_fts_dirty;`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
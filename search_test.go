@@ -0,0 +1,51 @@
+//go:build !kvstore_mattn || sqlite_fts5
+
+package kvstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	db := New(FullTextSearch())
+	if err := db.Open(dir); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	if err := db.SetDefault("network.proxy", "", KeyInfo{Description: "HTTP proxy server for all connections"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.SetMany(map[string]any{"greeting": "hello proxy world", "count": 42, "other": "nothing"}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	keys, err := db.Search("proxy")
+	if err != nil {
+		t.Fatalf(`search failed: %v`, err)
+	}
+	if len(keys) != 2 || !strings.Contains(strings.Join(keys, ","), "network.proxy") {
+		t.Errorf(`expected two matching keys, got %v`, keys)
+	}
+	if err := db.Delete("greeting"); err != nil {
+		t.Fatalf(`failed to delete key: %v`, err)
+	}
+	if err := db.Set("other", "socks proxy"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	keys, _ = db.Search("proxy")
+	if strings.Join(keys, ",") != "network.proxy,other" && strings.Join(keys, ",") != "other,network.proxy" {
+		t.Errorf(`index was not updated after changes, got %v`, keys)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf(`failed to close database: %v`, err)
+	}
+	db = New()
+	if err := db.Open(dir); err != nil {
+		t.Fatalf(`failed to reopen database: %v`, err)
+	}
+	defer db.Close()
+	if _, err := db.Search("proxy"); !errors.Is(err, NoFullTextErr) {
+		t.Errorf(`expected NoFullTextErr without full-text index, got %v`, err)
+	}
+}
@@ -0,0 +1,111 @@
+package kvstore
+
+import (
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Sets are stored separately from ordinary values and lists, one row per member, so that adding and removing
+// members does not rewrite the whole set. Like lists, sets are invisible to the methods for ordinary keys, and
+// SAdd returns KeyExistsErr if the key already holds an ordinary value or default, a list, a hash, or a streamed
+// value. Members are compared by their gob encoding, so 1 and int64(1) are different members.
+
+// initSets creates the table holding set members.
+func initSets(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_set(
+  key TEXT NOT NULL,
+  member BLOB NOT NULL,
+  UNIQUE(key,member)
+);
+`)
+	return err
+}
+
+// SAdd adds the given members to the set stored at key in one transaction and returns the number of members
+// that were not already in the set.
+func (db *KVStore) SAdd(key string, members ...any) (int, error) {
+	return db.updateSet(key, `INSERT INTO kv_set(key,member) VALUES(?,?) ON CONFLICT DO NOTHING;`, members, true)
+}
+
+// SRemove removes the given members from the set stored at key in one transaction and returns the number of
+// members that were in the set.
+func (db *KVStore) SRemove(key string, members ...any) (int, error) {
+	return db.updateSet(key, `DELETE FROM kv_set WHERE key=? AND member=?;`, members, false)
+}
+
+// updateSet executes the query for each member in one transaction and returns the number of affected rows. If
+// add is true, the key must not hold another kind of value.
+func (db *KVStore) updateSet(key, query string, members []any, add bool) (int, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return 0, NotOpenErr
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if add {
+		if err := checkKind(tx, key, "set"); err != nil {
+			return 0, err
+		}
+	}
+	var n int64
+	for _, m := range members {
+		b, err := db.encode(m)
+		if err != nil {
+			return 0, err
+		}
+		result, err := tx.Exec(query, key, b)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		n += affected
+	}
+	return int(n), tx.Commit()
+}
+
+// SContains returns true if member is in the set stored at key.
+func (db *KVStore) SContains(key string, member any) (bool, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return false, NotOpenErr
+	}
+	b, err := MarshalBinary(member)
+	if err != nil {
+		return false, err
+	}
+	var found bool
+	err = db.sqx.Get(&found, `SELECT EXISTS(SELECT 1 FROM kv_set WHERE key=? AND member=?);`, key, b)
+	return found, err
+}
+
+// SMembers returns all members of the set stored at key in the order they were added, an empty slice if
+// there is no such set.
+func (db *KVStore) SMembers(key string) ([]any, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	rows, err := db.sqx.Queryx(`SELECT member FROM kv_set WHERE key=? ORDER BY rowid;`, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make([]any, 0)
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return result, err
+		}
+		v, err := UnmarshalBinary(b)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, v)
+	}
+	return result, rows.Err()
+}
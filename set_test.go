@@ -0,0 +1,29 @@
+package kvstore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	db := openTestStore(t)
+	if n, err := db.SAdd("dismissed", 3, 1, 3, 2); n != 3 || err != nil {
+		t.Errorf(`expected 3 added members, got %v, %v`, n, err)
+	}
+	if n, err := db.SAdd("dismissed", 1); n != 0 || err != nil {
+		t.Errorf(`expected no added members, got %v, %v`, n, err)
+	}
+	if ok, err := db.SContains("dismissed", 2); !ok || err != nil {
+		t.Errorf(`expected 2 to be a member, got %v, %v`, ok, err)
+	}
+	if n, err := db.SRemove("dismissed", 2, 4); n != 1 || err != nil {
+		t.Errorf(`expected 1 removed member, got %v, %v`, n, err)
+	}
+	if ok, _ := db.SContains("dismissed", 2); ok {
+		t.Errorf(`expected 2 to be removed`)
+	}
+	members, err := db.SMembers("dismissed")
+	if err != nil || !reflect.DeepEqual(members, []any{3, 1}) {
+		t.Errorf(`expected [3 1], got %v, %v`, members, err)
+	}
+}
@@ -0,0 +1,67 @@
+package kvstore
+
+import (
+	"sync/atomic"
+)
+
+// SetIfAbsent sets the value for the given key only if the key has neither a value nor a default, and returns
+// true if the value was set. The check and the write are performed atomically, so concurrent initialization
+// code in several goroutines or processes sets the value only once. Expired keys count as absent.
+func (db *KVStore) SetIfAbsent(key string, value any) (bool, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return false, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return false, err
+	}
+	value, err := db.beforeSet(key, value)
+	if err != nil {
+		return false, err
+	}
+	if err := db.checkConstraints(db.sqx, key, value); err != nil {
+		return false, err
+	}
+	b, err := db.encode(value)
+	if err != nil {
+		return false, err
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	defer db.cache.remove(key)
+	_, err = tx.Exec(`DELETE FROM kv WHERE key=? AND key IN (SELECT kv.key FROM kv `+expiryJoins+` WHERE `+
+		expiresExpr+`<=?);`, key, db.now().UnixMilli())
+	if err != nil {
+		return false, err
+	}
+	var present bool
+	err = tx.Get(&present, `SELECT EXISTS(SELECT 1 FROM kv WHERE key=? AND (value IS NOT NULL OR original IS NOT NULL));`,
+		key)
+	if err != nil || present {
+		return false, err
+	}
+	if err := db.checkQuota(tx, key, len(b)); err != nil {
+		return false, err
+	}
+	notify := db.hasListeners()
+	var old any
+	if notify {
+		old = db.current(tx, key)
+	}
+	if err := db.put(tx, key, b, typeName(value)); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	db.removeOrphans()
+	if notify {
+		if err := db.notify(change{key: key, old: old, new: value}); err != nil {
+			return false, err
+		}
+	}
+	db.afterSet(key, value)
+	return true, db.evict(key)
+}
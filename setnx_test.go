@@ -0,0 +1,64 @@
+package kvstore
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSetIfAbsent(t *testing.T) {
+	db := openTestStore(t)
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var set []int
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := db.SetIfAbsent("init", i)
+			if err != nil {
+				t.Errorf(`SetIfAbsent failed: %v`, err)
+			}
+			if ok {
+				mutex.Lock()
+				set = append(set, i)
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(set) != 1 {
+		t.Fatalf(`expected exactly one successful SetIfAbsent, got %v`, set)
+	}
+	if v, _ := db.Get("init"); v != set[0] {
+		t.Errorf(`expected %v, got %v`, set[0], v)
+	}
+	if err := db.SetDefault("defaulted", 1, KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if ok, err := db.SetIfAbsent("defaulted", 2); ok || err != nil {
+		t.Errorf(`expected no write for key with default, got %v, %v`, ok, err)
+	}
+}
+
+func TestSetIfAbsentDeduplicated(t *testing.T) {
+	db := New(Deduplicate(16))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	large := strings.Repeat("x", 1024)
+	if err := db.Set("a", large); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if ok, err := db.SetIfAbsent("b", large); !ok || err != nil {
+		t.Fatalf(`failed to set absent key: %v, %v`, ok, err)
+	}
+	var n int
+	if err := db.sqx.Get(&n, `SELECT COUNT(*) FROM kv_content;`); err != nil || n != 1 {
+		t.Errorf(`expected value set with SetIfAbsent to be deduplicated, got %v contents, %v`, n, err)
+	}
+	if v, err := db.Get("b"); v != large || err != nil {
+		t.Errorf(`failed to get deduplicated value: %v`, err)
+	}
+}
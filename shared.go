@@ -0,0 +1,66 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var UnsupportedErr = errors.New(`option is not supported by this kind of store`)
+
+// NewWithDB creates a key value store configured with the given options and opens it in an existing
+// database with OpenWithDB.
+func NewWithDB(sqlDB *sql.DB, opts ...Option) (*KVStore, error) {
+	db := New(opts...)
+	if err := db.OpenWithDB(sqlDB); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// OpenWithDB opens the store in an existing sqlite database managed by the application instead of a
+// database file of its own, creating the kv table and its auxiliary tables if necessary. Use UpdateTx
+// to change keys within transactions of the application. Close does not close the database. Values
+// cannot be kept in external files, and the TableName and SingleWriter options cannot be used, since
+// the store does not open connections itself. The Cache option cannot be used either, since the store
+// cannot tell when the application commits a transaction passed to UpdateTx and could keep serving values
+// it replaced. UnsupportedErr is returned if any of these options is configured.
+// Pragmas such as the busy timeout and the journal mode are left to the application.
+func (db *KVStore) OpenWithDB(sqlDB *sql.DB) error {
+	if atomic.LoadUint32(&db.state) > 255 {
+		return AlreadyOpenErr
+	}
+	switch {
+	case db.opts.externalThreshold > 0:
+		return fmt.Errorf("%w: ExternalValues", UnsupportedErr)
+	case db.opts.singleWriter:
+		return fmt.Errorf("%w: SingleWriter", UnsupportedErr)
+	case db.opts.tableName() != defaultTable:
+		return fmt.Errorf("%w: TableName", UnsupportedErr)
+	case db.cache != nil:
+		return fmt.Errorf("%w: Cache", UnsupportedErr)
+	}
+	db.path = ""
+	db.shared = true
+	db.sq = sqlDB
+	db.sqx = sqlx.NewDb(sqlDB, "sqlite3")
+	return db.init()
+}
+
+// UpdateTx runs fn within the given transaction of the application, so that changes to keys are committed
+// or rolled back together with the application's own changes. The caller is responsible for committing the
+// transaction. Since the store cannot tell whether the transaction is committed, change listeners are not
+// called and the changes are not recorded in the journal. The transaction must belong to the database the
+// store has been opened in.
+func (db *KVStore) UpdateTx(tx *sql.Tx, fn func(tx Tx) error) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	return fn(&txStore{db: db, tx: &sqlx.Tx{Tx: tx, Mapper: db.sqx.Mapper}})
+}
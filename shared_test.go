@@ -0,0 +1,72 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenWithDB(t *testing.T) {
+	sqlDB := sql.OpenDB(&dsnConnector{driver: sqliteDriver, dsn: "file:" + filepath.Join(t.TempDir(), "app.sqlite")})
+	defer sqlDB.Close()
+	if _, err := sqlDB.Exec(`CREATE TABLE app(name TEXT);`); err != nil {
+		t.Fatalf(`failed to create application table: %v`, err)
+	}
+	if _, err := NewWithDB(sqlDB, ExternalValues(10)); !errors.Is(err, UnsupportedErr) {
+		t.Errorf(`expected UnsupportedErr, got %v`, err)
+	}
+	if _, err := NewWithDB(sqlDB, Cache(10, 0)); !errors.Is(err, UnsupportedErr) {
+		t.Errorf(`expected UnsupportedErr for Cache, got %v`, err)
+	}
+	db, err := NewWithDB(sqlDB, Deduplicate(1))
+	if err != nil {
+		t.Fatalf(`failed to open store: %v`, err)
+	}
+	if err := db.Set("a", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if v, err := db.Get("a"); v != 1 || err != nil {
+		t.Errorf(`expected 1, got %v, %v`, v, err)
+	}
+	for _, commit := range []bool{false, true} {
+		tx, err := sqlDB.Begin()
+		if err != nil {
+			t.Fatalf(`failed to begin transaction: %v`, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO app(name) VALUES('b');`); err != nil {
+			t.Fatalf(`failed to insert into application table: %v`, err)
+		}
+		err = db.UpdateTx(tx, func(tx Tx) error {
+			return tx.Set("b", "shared")
+		})
+		if err != nil {
+			t.Fatalf(`failed to update in application transaction: %v`, err)
+		}
+		if commit {
+			err = tx.Commit()
+		} else {
+			err = tx.Rollback()
+		}
+		if err != nil {
+			t.Fatalf(`failed to end transaction: %v`, err)
+		}
+		var n int
+		if err := sqlDB.QueryRow(`SELECT count(*) FROM app;`).Scan(&n); err != nil {
+			t.Fatalf(`failed to count rows: %v`, err)
+		}
+		v, err := db.Get("b")
+		switch {
+		case !commit && (n != 0 || !errors.Is(err, NotFoundErr)):
+			t.Errorf(`expected rollback to discard both changes, got %d rows and %v, %v`, n, v, err)
+		case commit && (n != 1 || v != "shared"):
+			t.Errorf(`expected commit to keep both changes, got %d rows and %v, %v`, n, v, err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf(`failed to close store: %v`, err)
+	}
+	if err := sqlDB.Ping(); err != nil {
+		t.Errorf(`expected application database to remain open, got %v`, err)
+	}
+}
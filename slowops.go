@@ -0,0 +1,48 @@
+package kvstore
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// SlowOp describes an operation reported by the function given to SlowOperations.
+type SlowOp struct {
+	Op       string        // the operation, such as "set" or "delete many"
+	Key      string        // the key, empty for operations on several keys
+	Rows     int           // the number of keys written or read
+	Duration time.Duration // the time the operation took
+}
+
+// SlowOperations configures the store to report operations taking longer than threshold to report, or to the
+// standard logger if report is nil, which helps to diagnose why saving settings stalls on certain machines. If
+// threshold is not positive, every operation is reported to report, which may then serve as profiling hook. The
+// operations reported are Get, GetMany, GetAll, Set, SetMany, SetDefault, SetDefaults, Revert, Delete,
+// DeleteMany, and Update. The function is called synchronously after the operation and must not block.
+func SlowOperations(threshold time.Duration, report func(SlowOp)) Option {
+	return func(o *options) {
+		o.slowThreshold = threshold
+		o.slowReport = report
+	}
+}
+
+// observe counts an operation of the given kind started at start and reports it if it was slow, see
+// SlowOperations. Errors other than NotFoundErr are counted as failures.
+func (db *KVStore) observe(op opKind, key string, rows int, start time.Time, err *error) {
+	db.counters.ops[op].Add(1)
+	if *err != nil && !errors.Is(*err, NotFoundErr) {
+		db.counters.errors[op].Add(1)
+	}
+	if db.opts.slowThreshold <= 0 && db.opts.slowReport == nil {
+		return
+	}
+	d := time.Since(start)
+	if d < db.opts.slowThreshold {
+		return
+	}
+	if db.opts.slowReport == nil {
+		log.Printf("kvstore: slow %s of key %q, %d rows, took %v", opNames[op], key, rows, d)
+		return
+	}
+	db.opts.slowReport(SlowOp{Op: opNames[op], Key: key, Rows: rows, Duration: d})
+}
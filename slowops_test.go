@@ -0,0 +1,55 @@
+package kvstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlowOperations(t *testing.T) {
+	var reported []SlowOp
+	db := New(SlowOperations(20*time.Millisecond, func(op SlowOp) { reported = append(reported, op) }))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	db.BeforeSet(func(key string, value any) (any, error) {
+		if key == "slow" {
+			time.Sleep(30 * time.Millisecond)
+		}
+		return value, nil
+	})
+	if err := db.Set("fast", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Set("slow", 2); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if len(reported) != 1 || reported[0].Op != "set" || reported[0].Key != "slow" || reported[0].Rows != 1 ||
+		reported[0].Duration < 30*time.Millisecond {
+		t.Errorf(`expected the slow set to be reported, got %v`, reported)
+	}
+}
+
+func TestSlowOperationsProfiling(t *testing.T) {
+	var ops []string
+	db := New(SlowOperations(0, func(op SlowOp) { ops = append(ops, op.Op) }))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	db.SetMany(map[string]any{"a": 1, "b": 2})
+	db.Get("a")
+	db.GetAll(0)
+	db.Update(func(tx Tx) error { return tx.Set("c", 3) })
+	db.DeleteMany([]string{"a", "b"})
+	want := []string{"set many", "get", "get all", "update", "delete many"}
+	if len(ops) != len(want) {
+		t.Fatalf(`expected operations %v, got %v`, want, ops)
+	}
+	for i := range want {
+		if ops[i] != want[i] {
+			t.Errorf(`expected operations %v, got %v`, want, ops)
+			break
+		}
+	}
+}
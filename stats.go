@@ -0,0 +1,62 @@
+package kvstore
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// Stats provides information about the size and contents of a key value store.
+type Stats struct {
+	Keys       int            // number of keys, including keys that only have a default
+	ValueBytes int64          // total number of bytes used by encoded values and defaults
+	Defaults   int            // number of keys with a default
+	FileSize   int64          // size of the database file and write-ahead log on disk, or of the database in memory
+	Categories map[string]int // number of keys per category, keys without category are not counted
+}
+
+// Stats returns key counts and size information for the key value store.
+func (db *KVStore) Stats() (Stats, error) {
+	stats := Stats{Categories: make(map[string]int)}
+	if atomic.LoadUint32(&db.state) < 256 {
+		return stats, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return stats, err
+	}
+	row := db.sqx.QueryRowx(`SELECT COUNT(*), COALESCE(SUM(LENGTH(value)),0)+COALESCE(SUM(LENGTH(original)),0)+
+(SELECT COALESCE(SUM(LENGTH(data)),0) FROM kv_content), COUNT(original) FROM kv;`)
+	if err := row.Scan(&stats.Keys, &stats.ValueBytes, &stats.Defaults); err != nil {
+		return stats, err
+	}
+	rows, err := db.sqx.Queryx(`SELECT category, COUNT(*) FROM kv WHERE category IS NOT NULL AND category<>'' GROUP BY category;`)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var category string
+		var n int
+		if err := rows.Scan(&category, &n); err != nil {
+			return stats, err
+		}
+		stats.Categories[category] = n
+	}
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+	if db.memory != "" || db.opts.vfs != "" {
+		err := db.sqx.Get(&stats.FileSize, `SELECT page_count*page_size FROM pragma_page_count(),pragma_page_size();`)
+		return stats, err
+	}
+	for _, file := range []string{db.path, db.path + "-wal"} {
+		info, err := os.Stat(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return stats, err
+		}
+		stats.FileSize += info.Size()
+	}
+	return stats, nil
+}
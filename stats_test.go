@@ -0,0 +1,29 @@
+package kvstore
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.Set("a", "hello"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.SetDefault("b", 42, KeyInfo{Description: "b", Category: "numbers"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.SetDefault("c", 43, KeyInfo{Description: "c", Category: "numbers"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf(`failed to get stats: %v`, err)
+	}
+	if stats.Keys != 3 || stats.Defaults != 2 {
+		t.Errorf(`expected 3 keys and 2 defaults, got %v and %v`, stats.Keys, stats.Defaults)
+	}
+	if stats.ValueBytes <= 0 || stats.FileSize <= 0 {
+		t.Errorf(`expected positive sizes, got %v value bytes and file size %v`, stats.ValueBytes, stats.FileSize)
+	}
+	if stats.Categories["numbers"] != 2 || len(stats.Categories) != 1 {
+		t.Errorf(`wrong category counts: %v`, stats.Categories)
+	}
+}
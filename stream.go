@@ -0,0 +1,132 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// streamChunkSize is the size of the chunks streamed values are stored in.
+const streamChunkSize = 64 << 10
+
+// Streamed values are raw bytes stored separately from ordinary values in chunks, so that large values can be
+// written and read without holding them in memory as a whole. They are only accessible through SetReader,
+// GetReader, and DeleteStream, and SetReader returns KeyExistsErr if the key holds an ordinary value or default,
+// a list, a set, or a hash.
+
+// initStreams creates the table holding chunks of streamed values.
+func initStreams(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_stream(
+  key TEXT NOT NULL,
+  chunk INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  PRIMARY KEY(key,chunk)
+) WITHOUT ROWID;
+`)
+	return err
+}
+
+// SetReader stores all bytes read from r until io.EOF as the streamed value of key in one transaction,
+// replacing any previous streamed value of the key.
+func (db *KVStore) SetReader(key string, r io.Reader) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := checkKind(tx, key, "stream"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM kv_stream WHERE key=?;`, key); err != nil {
+		return err
+	}
+	buf := make([]byte, streamChunkSize)
+	for chunk := 0; ; chunk++ {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 || chunk == 0 {
+			if _, err := tx.Exec(`INSERT INTO kv_stream(key,chunk,data) VALUES(?,?,?);`, key, chunk, buf[:n]); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetReader returns a reader for the streamed value of key, NotFoundErr if there is none. The reader sees the
+// value as it was when GetReader was called and must be closed after use.
+func (db *KVStore) GetReader(key string) (io.ReadCloser, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	tx, err := db.sqx.BeginTxx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	var found bool
+	if err := tx.Get(&found, `SELECT EXISTS(SELECT 1 FROM kv_stream WHERE key=?);`, key); err != nil || !found {
+		tx.Rollback()
+		if err == nil {
+			err = NotFoundErr
+		}
+		return nil, err
+	}
+	return &streamReader{tx: tx, key: key}, nil
+}
+
+// DeleteStream removes the streamed value of key.
+func (db *KVStore) DeleteStream(key string) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	_, err := db.exec(`DELETE FROM kv_stream WHERE key=?;`, key)
+	return err
+}
+
+// streamReader reads a streamed value chunk by chunk within a read-only transaction.
+type streamReader struct {
+	tx    *sqlx.Tx
+	key   string
+	chunk int
+	buf   []byte
+	eof   bool
+}
+
+// Read reads the next bytes of the streamed value, loading the next chunk if necessary.
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.buf) == 0 {
+		if s.eof {
+			return 0, io.EOF
+		}
+		err := s.tx.Get(&s.buf, `SELECT data FROM kv_stream WHERE key=? AND chunk=?;`, s.key, s.chunk)
+		if errors.Is(err, sql.ErrNoRows) {
+			s.eof = true
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		s.chunk++
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+// Close ends the transaction of the reader.
+func (s *streamReader) Close() error {
+	return s.tx.Rollback()
+}
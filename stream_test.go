@@ -0,0 +1,50 @@
+package kvstore
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	db := openTestStore(t)
+	data := make([]byte, 3*streamChunkSize+17)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	if err := db.SetReader("blob", bytes.NewReader(data)); err != nil {
+		t.Fatalf(`failed to set reader: %v`, err)
+	}
+	r, err := db.GetReader("blob")
+	if err != nil {
+		t.Fatalf(`failed to get reader: %v`, err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf(`failed to read stream: %v`, err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf(`failed to close reader: %v`, err)
+	}
+	if !bytes.Equal(b, data) {
+		t.Errorf(`streamed value differs, got %v bytes, expected %v`, len(b), len(data))
+	}
+	if err := db.SetReader("empty", bytes.NewReader(nil)); err != nil {
+		t.Fatalf(`failed to set empty reader: %v`, err)
+	}
+	r, err = db.GetReader("empty")
+	if err != nil {
+		t.Fatalf(`failed to get empty reader: %v`, err)
+	}
+	if b, err := io.ReadAll(r); len(b) != 0 || err != nil {
+		t.Errorf(`expected empty value, got %v bytes, %v`, len(b), err)
+	}
+	r.Close()
+	if err := db.DeleteStream("blob"); err != nil {
+		t.Fatalf(`failed to delete stream: %v`, err)
+	}
+	if _, err := db.GetReader("blob"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+}
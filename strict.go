@@ -0,0 +1,42 @@
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var EncodingErr = errors.New(`value cannot be decoded after encoding`)
+
+// StrictEncoding configures the store to decode every value written with Set and related methods right after
+// encoding it, and to reject the value with an error wrapping EncodingErr if it cannot be decoded or decodes to a
+// value of a different type. This detects values of types that have not been registered with gob, or whose
+// encoding is not symmetric, when they are written rather than when they are read much later. Since every value
+// is decoded once more, writes become slower; the option is intended for development and testing.
+func StrictEncoding() Option {
+	return func(o *options) {
+		o.strictEncoding = true
+	}
+}
+
+// encode encodes a value with MarshalBinary and validates that it can be decoded in strict mode.
+func (db *KVStore) encode(v any) ([]byte, error) {
+	b, err := MarshalBinary(v)
+	if err != nil {
+		if db.opts.strictEncoding {
+			return nil, fmt.Errorf("%w: %T: %v", EncodingErr, v, err)
+		}
+		return nil, err
+	}
+	if !db.opts.strictEncoding {
+		return b, nil
+	}
+	decoded, err := UnmarshalBinary(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %T: %v", EncodingErr, v, err)
+	}
+	if reflect.TypeOf(decoded) != reflect.TypeOf(v) {
+		return nil, fmt.Errorf("%w: %T decodes to %T", EncodingErr, v, decoded)
+	}
+	return b, nil
+}
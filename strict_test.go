@@ -0,0 +1,34 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+// strictType is not registered with gob.
+type strictType struct {
+	Name string
+}
+
+func TestStrictEncoding(t *testing.T) {
+	db := New(StrictEncoding())
+	if err := db.Open(InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer db.Close()
+	if err := db.Set("ok", []string{"a", "b"}); err != nil {
+		t.Errorf(`set: %v`, err)
+	}
+	if err := db.Set("bad", strictType{Name: "x"}); !errors.Is(err, EncodingErr) {
+		t.Errorf(`expected EncodingErr, got %v`, err)
+	}
+	if err := db.SetMany(map[string]any{"bad": strictType{}}); !errors.Is(err, EncodingErr) {
+		t.Errorf(`expected EncodingErr for SetMany, got %v`, err)
+	}
+	if err := db.SetDefault("bad", strictType{}, KeyInfo{}); !errors.Is(err, EncodingErr) {
+		t.Errorf(`expected EncodingErr for SetDefault, got %v`, err)
+	}
+	if _, err := db.Get("bad"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected rejected value not to be stored, got %v`, err)
+	}
+}
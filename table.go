@@ -0,0 +1,112 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+)
+
+var InvalidTableNameErr = errors.New(`invalid table name`)
+
+// defaultTable is the name of the table holding keys and values, which is also the prefix of the names of
+// all auxiliary tables, indexes, and triggers.
+const defaultTable = "kv"
+
+// validTableName matches the table names accepted by TableName.
+var validTableName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+// tableIdentifier matches the names of the kv table and of all schema objects derived from it.
+var tableIdentifier = regexp.MustCompile(`\bkv(_[A-Za-z0-9_]+)?\b`)
+
+// TableName configures the store to keep its keys and values in the table with the given name, so that several
+// independent stores can share one database file, for example one per plugin of an application. All auxiliary
+// tables, indexes, and triggers are prefixed with the name instead of "kv", and values kept in external files,
+// see ExternalValues, are stored in a separate directory. Names must start with a letter and consist of
+// letters and digits only; Open returns InvalidTableNameErr otherwise. The default name is "kv".
+func TableName(name string) Option {
+	return func(o *options) {
+		o.table = name
+	}
+}
+
+// tableName returns the configured table name.
+func (o *options) tableName() string {
+	if o.table == "" {
+		return defaultTable
+	}
+	return o.table
+}
+
+// renameTable returns a function rewriting statements to use the given table instead of the default table.
+// The name of the kv_external function is kept.
+func renameTable(table string) func(string) string {
+	return func(query string) string {
+		return tableIdentifier.ReplaceAllStringFunc(query, func(s string) string {
+			if s == "kv_external" {
+				return s
+			}
+			return table + s[len(defaultTable):]
+		})
+	}
+}
+
+// tableConn is a connection that rewrites all statements before passing them to the sqlite driver.
+type tableConn struct {
+	driver.Conn
+	rename func(string) string
+}
+
+// Prepare prepares the rewritten statement.
+func (c *tableConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(c.rename(query))
+}
+
+// PrepareContext prepares the rewritten statement.
+func (c *tableConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return pc.PrepareContext(ctx, c.rename(query))
+	}
+	return c.Conn.Prepare(c.rename(query))
+}
+
+// ExecContext executes the rewritten statements if there are no arguments, which allows executing several
+// statements at once.
+func (c *tableConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if ec, ok := c.Conn.(driver.ExecerContext); ok {
+		return ec.ExecContext(ctx, c.rename(query), args)
+	}
+	return nil, driver.ErrSkip
+}
+
+// BeginTx starts a transaction with the options of the driver.
+func (c *tableConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return bc.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// CheckNamedValue passes all arguments to the driver unchanged.
+func (c *tableConn) CheckNamedValue(arg *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(arg)
+	}
+	return driver.ErrSkip
+}
+
+// ResetSession resets the connection if the driver supports it.
+func (c *tableConn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.Conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid reports whether the connection can be reused.
+func (c *tableConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
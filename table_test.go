@@ -0,0 +1,52 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTableName(t *testing.T) {
+	dir := t.TempDir()
+	optsA := []Option{TableName("pluginA"), Deduplicate(1)}
+	if externalFunction {
+		// the alternative drivers support neither external values nor, by default, full-text search
+		optsA = append(optsA, FullTextSearch(), ExternalValues(1))
+	}
+	stores := []*KVStore{New(), New(optsA...), New(TableName("pluginB"), SingleWriter())}
+	for i, db := range stores {
+		if err := db.Open(dir); err != nil {
+			t.Fatalf(`failed to open database: %v`, err)
+		}
+		defer db.Close()
+		if err := db.SetDefault("shared", i, KeyInfo{Description: "shared key"}); err != nil {
+			t.Fatalf(`failed to set default: %v`, err)
+		}
+		if err := db.Set("shared", i*10); err != nil {
+			t.Fatalf(`failed to set key: %v`, err)
+		}
+	}
+	for i, db := range stores {
+		if v, err := db.Get("shared"); v != i*10 || err != nil {
+			t.Errorf(`expected isolated value %d, got %v, %v`, i*10, v, err)
+		}
+		if err := db.Revert("shared"); err != nil {
+			t.Fatalf(`failed to revert key: %v`, err)
+		}
+		if v, err := db.Get("shared"); v != i || err != nil {
+			t.Errorf(`expected isolated default %d, got %v, %v`, i, v, err)
+		}
+	}
+	if err := stores[1].Delete("shared"); err != nil {
+		t.Fatalf(`failed to delete key: %v`, err)
+	}
+	if ok, _ := stores[2].Has("shared"); !ok {
+		t.Errorf(`expected key of other store to be unaffected by Delete`)
+	}
+	var exists bool
+	if err := stores[0].sqx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE name='pluginA_content');`); err != nil || !exists {
+		t.Errorf(`expected auxiliary tables to be prefixed with table name, got %v, %v`, exists, err)
+	}
+	if err := New(TableName("no-way")).Open(t.TempDir()); !errors.Is(err, InvalidTableNameErr) {
+		t.Errorf(`expected InvalidTableNameErr, got %v`, err)
+	}
+}
@@ -0,0 +1,134 @@
+package kvstore
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Tags group keys independently of their category, and a key may have any number of tags, e.g. "advanced" and
+// "sync-excluded". Tags are deleted together with their key.
+
+// TagMatch specifies whether KeysByTags returns keys with all or any of the given tags.
+type TagMatch int
+
+const (
+	MatchAll TagMatch = iota // keys having all of the tags
+	MatchAny                 // keys having at least one of the tags
+)
+
+// initTags creates the table holding the tags of keys.
+func initTags(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_tags(
+  key TEXT NOT NULL,
+  tag TEXT NOT NULL,
+  PRIMARY KEY(key,tag)
+) WITHOUT ROWID;
+CREATE INDEX IF NOT EXISTS kv_tags_tag ON kv_tags(tag,key);
+CREATE TRIGGER IF NOT EXISTS kv_tags_delete AFTER DELETE ON kv BEGIN
+  DELETE FROM kv_tags WHERE key=old.key;
+END;
+CREATE TRIGGER IF NOT EXISTS kv_tags_rename AFTER UPDATE OF key ON kv WHEN old.key<>new.key BEGIN
+  UPDATE kv_tags SET key=new.key WHERE key=old.key;
+END;
+`)
+	return err
+}
+
+// AddTag adds the given tags to a key, NotFoundErr if there is no key. Adding a tag the key already has does
+// nothing.
+func (db *KVStore) AddTag(key string, tags ...string) error {
+	return keyError("add tag", key, db.addTag(key, tags))
+}
+
+// addTag implements AddTag.
+func (db *KVStore) addTag(key string, tags []string) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	return db.inTx(func(tx *sqlx.Tx) error {
+		var exists bool
+		if err := tx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM kv WHERE key=?);`, key); err != nil {
+			return err
+		}
+		if !exists {
+			return NotFoundErr
+		}
+		for _, tag := range tags {
+			if _, err := tx.Exec(`INSERT INTO kv_tags(key,tag) VALUES(?,?) ON CONFLICT DO NOTHING;`, key, tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveTag removes the given tags from a key. Removing a tag the key does not have does nothing.
+func (db *KVStore) RemoveTag(key string, tags ...string) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return keyError("remove tag", key, NotOpenErr)
+	}
+	return keyError("remove tag", key, db.inTx(func(tx *sqlx.Tx) error {
+		for _, tag := range tags {
+			if _, err := tx.Exec(`DELETE FROM kv_tags WHERE key=? AND tag=?;`, key, tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// Tags returns the tags of a key in ascending order, NotFoundErr if there is no key.
+func (db *KVStore) Tags(key string) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	var exists bool
+	err := db.sqx.Get(&exists, `SELECT EXISTS(SELECT 1 FROM kv WHERE key=?);`, key)
+	if err == nil && !exists {
+		err = NotFoundErr
+	}
+	if err != nil {
+		return nil, keyError("get tags", key, err)
+	}
+	tags := make([]string, 0)
+	err = db.sqx.Select(&tags, `SELECT tag FROM kv_tags WHERE key=? ORDER BY tag ASC;`, key)
+	return tags, err
+}
+
+// KeysByTag returns the keys with the given tag in ascending order.
+func (db *KVStore) KeysByTag(tag string) ([]string, error) {
+	return db.KeysByTags(MatchAll, tag)
+}
+
+// KeysByTags returns the keys with all or any of the given tags in ascending order, depending on match. No keys
+// are returned if no tags are given.
+func (db *KVStore) KeysByTags(match TagMatch, tags ...string) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	keys := make([]string, 0)
+	if len(tags) == 0 {
+		return keys, nil
+	}
+	args := make([]any, 0, len(tags))
+	for _, tag := range slices.Compact(slices.Sorted(slices.Values(tags))) {
+		args = append(args, tag)
+	}
+	query := `SELECT DISTINCT key FROM kv_tags WHERE tag IN (?` + strings.Repeat(`,?`, len(args)-1) + `)`
+	if match == MatchAll {
+		query += ` GROUP BY key HAVING COUNT(*)=` + strconv.Itoa(len(args))
+	}
+	err := db.sqx.Select(&keys, query+` ORDER BY key ASC;`, args...)
+	return keys, err
+}
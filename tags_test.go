@@ -0,0 +1,54 @@
+package kvstore
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.AddTag("missing", "advanced"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr for missing key, got %v`, err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := db.Set(key, key); err != nil {
+			t.Fatalf(`failed to set key: %v`, err)
+		}
+	}
+	if err := db.AddTag("a", "advanced", "experimental"); err != nil {
+		t.Fatalf(`failed to add tags: %v`, err)
+	}
+	if err := db.AddTag("b", "advanced", "advanced"); err != nil {
+		t.Fatalf(`failed to add tags: %v`, err)
+	}
+	if err := db.AddTag("c", "sync-excluded"); err != nil {
+		t.Fatalf(`failed to add tags: %v`, err)
+	}
+	if tags, err := db.Tags("a"); err != nil || !slices.Equal(tags, []string{"advanced", "experimental"}) {
+		t.Errorf(`wrong tags: %v, %v`, tags, err)
+	}
+	if keys, err := db.KeysByTag("advanced"); err != nil || !slices.Equal(keys, []string{"a", "b"}) {
+		t.Errorf(`wrong keys by tag: %v, %v`, keys, err)
+	}
+	keys, err := db.KeysByTags(MatchAll, "advanced", "experimental", "advanced")
+	if err != nil || !slices.Equal(keys, []string{"a"}) {
+		t.Errorf(`wrong keys with all tags: %v, %v`, keys, err)
+	}
+	keys, err = db.KeysByTags(MatchAny, "experimental", "sync-excluded")
+	if err != nil || !slices.Equal(keys, []string{"a", "c"}) {
+		t.Errorf(`wrong keys with any tag: %v, %v`, keys, err)
+	}
+	if err := db.RemoveTag("a", "experimental"); err != nil {
+		t.Fatalf(`failed to remove tag: %v`, err)
+	}
+	if keys, err := db.KeysByTag("experimental"); err != nil || len(keys) != 0 {
+		t.Errorf(`expected no keys after removing tag, got %v, %v`, keys, err)
+	}
+	if err := db.Delete("b"); err != nil {
+		t.Fatalf(`failed to delete key: %v`, err)
+	}
+	if keys, err := db.KeysByTag("advanced"); err != nil || !slices.Equal(keys, []string{"a"}) {
+		t.Errorf(`expected tags to be deleted with key, got %v, %v`, keys, err)
+	}
+}
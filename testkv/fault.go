@@ -0,0 +1,211 @@
+package testkv
+
+import (
+	"errors"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rasteric/kvstore"
+)
+
+var BusyErr = errors.New(`injected fault: database is locked`)
+var PartialBatchErr = errors.New(`injected fault: batch only partially applied`)
+
+// Faults configures the failures injected by a FaultyStore. Rates are probabilities between 0 and 1 evaluated
+// for each call.
+type Faults struct {
+	BusyRate    float64       // the call fails with BusyErr without reaching the store
+	PartialRate float64       // SetMany or DeleteMany applies only part of the batch and fails with PartialBatchErr
+	SlowRate    float64       // the call is delayed by Delay before reaching the store
+	Delay       time.Duration // the delay of slow calls
+	Methods     []string      // the names of the methods subject to faults, all methods if empty
+	Seed        uint64        // the seed of the random choices, so that a failing run can be reproduced
+}
+
+// FaultyStore wraps a key value store and injects failures into the calls passed to it, for testing how an
+// application copes with a busy database, batches that are not applied atomically, and slow operations.
+// Open and Close are never failed, so that the wrapped store is always opened and closed properly, and Info is
+// only slowed down. FaultyStore is safe for concurrent use if the wrapped store is.
+type FaultyStore struct {
+	store kvstore.KeyValueStore
+
+	mutex    sync.Mutex
+	faults   Faults
+	rng      *rand.Rand
+	injected int
+}
+
+var _ kvstore.KeyValueStore = (*FaultyStore)(nil)
+
+// Faulty returns a store injecting the given faults into the calls to store.
+func Faulty(store kvstore.KeyValueStore, faults Faults) *FaultyStore {
+	f := &FaultyStore{store: store}
+	f.SetFaults(faults)
+	return f
+}
+
+// SetFaults replaces the injected faults, for example with Faults{} to let an application recover.
+func (f *FaultyStore) SetFaults(faults Faults) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.faults = faults
+	f.rng = rand.New(rand.NewPCG(faults.Seed, faults.Seed))
+}
+
+// Injected returns the number of faults injected so far, counting failed and slowed down calls.
+func (f *FaultyStore) Injected() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.injected
+}
+
+// fault is the fault chosen for a call.
+type fault int
+
+const (
+	noFault fault = iota
+	busyFault
+	partialFault
+)
+
+// inject sleeps if the call of the method is chosen to be slow and returns the fault chosen for it. Partial
+// faults are only chosen if partial is true.
+func (f *FaultyStore) inject(method string, partial bool) fault {
+	f.mutex.Lock()
+	faults := f.faults
+	if len(faults.Methods) > 0 && !slices.Contains(faults.Methods, method) {
+		f.mutex.Unlock()
+		return noFault
+	}
+	slow := faults.SlowRate > 0 && f.rng.Float64() < faults.SlowRate
+	result := noFault
+	switch {
+	case faults.BusyRate > 0 && f.rng.Float64() < faults.BusyRate:
+		result = busyFault
+	case partial && faults.PartialRate > 0 && f.rng.Float64() < faults.PartialRate:
+		result = partialFault
+	}
+	if slow {
+		f.injected++
+	}
+	if result != noFault {
+		f.injected++
+	}
+	f.mutex.Unlock()
+	if slow {
+		time.Sleep(faults.Delay)
+	}
+	return result
+}
+
+// part returns the number of elements of a batch of n elements applied by a partial fault, which is less than n.
+func (f *FaultyStore) part(n int) int {
+	if n == 0 {
+		return 0
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.rng.IntN(n)
+}
+
+// Open opens the wrapped store.
+func (f *FaultyStore) Open(path string) error {
+	return f.store.Open(path)
+}
+
+// Close closes the wrapped store.
+func (f *FaultyStore) Close() error {
+	return f.store.Close()
+}
+
+// Set sets the value for the given key in the wrapped store unless a fault is injected.
+func (f *FaultyStore) Set(key string, value any) error {
+	if f.inject("Set", false) == busyFault {
+		return BusyErr
+	}
+	return f.store.Set(key, value)
+}
+
+// Get gets the value for the given key from the wrapped store unless a fault is injected.
+func (f *FaultyStore) Get(key string) (any, error) {
+	if f.inject("Get", false) == busyFault {
+		return nil, BusyErr
+	}
+	return f.store.Get(key)
+}
+
+// SetMany sets the key-value pairs in the wrapped store unless a fault is injected. A partial fault sets the
+// pairs of some of the keys in ascending order.
+func (f *FaultyStore) SetMany(values map[string]any) error {
+	switch f.inject("SetMany", true) {
+	case busyFault:
+		return BusyErr
+	case partialFault:
+		keys := slices.Sorted(maps.Keys(values))
+		part := make(map[string]any)
+		for _, k := range keys[:f.part(len(keys))] {
+			part[k] = values[k]
+		}
+		if err := f.store.SetMany(part); err != nil {
+			return err
+		}
+		return PartialBatchErr
+	}
+	return f.store.SetMany(values)
+}
+
+// GetAll gets key-value pairs from the wrapped store unless a fault is injected.
+func (f *FaultyStore) GetAll(limit int) (map[string]any, error) {
+	if f.inject("GetAll", false) == busyFault {
+		return nil, BusyErr
+	}
+	return f.store.GetAll(limit)
+}
+
+// Revert reverts the key in the wrapped store unless a fault is injected.
+func (f *FaultyStore) Revert(key string) error {
+	if f.inject("Revert", false) == busyFault {
+		return BusyErr
+	}
+	return f.store.Revert(key)
+}
+
+// Info returns the key info from the wrapped store, possibly slowed down.
+func (f *FaultyStore) Info(key string) (kvstore.KeyInfo, bool) {
+	f.inject("Info", false)
+	return f.store.Info(key)
+}
+
+// Delete removes the key from the wrapped store unless a fault is injected.
+func (f *FaultyStore) Delete(key string) error {
+	if f.inject("Delete", false) == busyFault {
+		return BusyErr
+	}
+	return f.store.Delete(key)
+}
+
+// DeleteMany removes the keys from the wrapped store unless a fault is injected. A partial fault removes some
+// of the keys from the start of the slice.
+func (f *FaultyStore) DeleteMany(keys []string) error {
+	switch f.inject("DeleteMany", true) {
+	case busyFault:
+		return BusyErr
+	case partialFault:
+		if err := f.store.DeleteMany(keys[:f.part(len(keys))]); err != nil {
+			return err
+		}
+		return PartialBatchErr
+	}
+	return f.store.DeleteMany(keys)
+}
+
+// SetDefault sets the default and key info in the wrapped store unless a fault is injected.
+func (f *FaultyStore) SetDefault(key string, value any, info kvstore.KeyInfo) error {
+	if f.inject("SetDefault", false) == busyFault {
+		return BusyErr
+	}
+	return f.store.SetDefault(key, value, info)
+}
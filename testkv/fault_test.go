@@ -0,0 +1,56 @@
+package testkv
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rasteric/kvstore"
+)
+
+func TestFaulty(t *testing.T) {
+	db := kvstore.New()
+	if err := db.Open(kvstore.InMemory); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer db.Close()
+	f := Faulty(db, Faults{BusyRate: 0.5, Seed: 1})
+	busy := 0
+	for range 100 {
+		if err := f.Set("key", 1); errors.Is(err, BusyErr) {
+			busy++
+		} else if err != nil {
+			t.Fatalf(`unexpected error: %v`, err)
+		}
+	}
+	if busy < 20 || busy > 80 || f.Injected() != busy {
+		t.Errorf(`expected about half of the calls to fail, got %d failures and %d faults`, busy, f.Injected())
+	}
+	f.SetFaults(Faults{PartialRate: 1, Methods: []string{"SetMany"}})
+	if _, err := f.Get("key"); err != nil {
+		t.Errorf(`expected Get not to fail, got %v`, err)
+	}
+	values := map[string]any{"a": 1, "b": 2, "c": 3, "d": 4}
+	if err := f.SetMany(values); !errors.Is(err, PartialBatchErr) {
+		t.Fatalf(`expected PartialBatchErr, got %v`, err)
+	}
+	all, err := db.GetAll(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(all) - 1; n >= len(values) {
+		t.Errorf(`expected only part of the batch to be applied, got %v`, all)
+	}
+	f.SetFaults(Faults{SlowRate: 1, Delay: 20 * time.Millisecond})
+	start := time.Now()
+	if err := f.Delete("key"); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 20*time.Millisecond {
+		t.Errorf(`expected call to be delayed`)
+	}
+	f.SetFaults(Faults{})
+	if err := f.SetMany(values); err != nil {
+		t.Errorf(`expected no faults after reset, got %v`, err)
+	}
+}
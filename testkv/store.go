@@ -0,0 +1,337 @@
+// Package testkv provides a fake key value store for unit tests of code using the kvstore package. The fake
+// implements kvstore.KeyValueStore with maps, records all operations, can be scripted to fail selected calls,
+// and offers assertion helpers:
+//
+//	store := testkv.New()
+//	store.Fail("Set", 3, errors.New("disk full")) // the third call of Set fails
+//	err := saveSettings(store)
+//	...
+//	store.AssertCalled(t, "Set", "theme")
+//	store.AssertValue(t, "theme", "dark")
+//
+// FaultyStore wraps a real store instead and injects busy errors, partially applied batches, and slow
+// operations, for resilience testing of applications embedding kvstore.
+package testkv
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/rasteric/kvstore"
+)
+
+// Op is an operation recorded by the fake store. Key is empty for operations on several keys or on the store as
+// a whole, and Value is the value passed to Set or SetDefault, the map passed to SetMany, or the keys passed to
+// DeleteMany.
+type Op struct {
+	Method string
+	Key    string
+	Value  any
+	Err    error // the error returned by the operation
+}
+
+// failure is a scripted error for the nth call of a method, for every call if nth is not positive.
+type failure struct {
+	method string
+	nth    int
+	err    error
+}
+
+// Store is a fake key value store holding values in memory. Values are stored as they are, without being
+// encoded, so that they need not be serializable. A new store is open and may be opened again by the code under
+// test; operations after Close fail with kvstore.NotOpenErr like those of kvstore.KVStore. Store is safe for
+// concurrent use.
+type Store struct {
+	mutex    sync.Mutex
+	closed   bool
+	values   map[string]any
+	defaults map[string]any
+	infos    map[string]kvstore.KeyInfo
+	ops      []Op
+	calls    map[string]int
+	failures []failure
+}
+
+var _ kvstore.KeyValueStore = (*Store)(nil)
+
+// New returns a new, empty fake store.
+func New() *Store {
+	return &Store{
+		values:   make(map[string]any),
+		defaults: make(map[string]any),
+		infos:    make(map[string]kvstore.KeyInfo),
+		calls:    make(map[string]int),
+	}
+}
+
+// Fail scripts the nth call of the named method, counting from 1 and including the calls made before, to
+// return err without having any effect. If nth is not positive, every call of the method fails. Methods are
+// named as in kvstore.KeyValueStore, for example "Set" or "DeleteMany".
+func (s *Store) Fail(method string, nth int, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures = append(s.failures, failure{method: method, nth: nth, err: err})
+}
+
+// Ops returns the operations recorded so far in the order they were called.
+func (s *Store) Ops() []Op {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return slices.Clone(s.ops)
+}
+
+// Reset clears the recorded operations, the call counts, and the scripted errors, but keeps the values.
+func (s *Store) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ops = nil
+	s.calls = make(map[string]int)
+	s.failures = nil
+}
+
+// begin counts a call of the method and returns the scripted error for it, or kvstore.NotOpenErr if the store
+// is closed. The mutex must be held.
+func (s *Store) begin(method string) error {
+	s.calls[method]++
+	n := s.calls[method]
+	for _, f := range s.failures {
+		if f.method == method && (f.nth <= 0 || f.nth == n) {
+			return f.err
+		}
+	}
+	if s.closed {
+		return kvstore.NotOpenErr
+	}
+	return nil
+}
+
+// record records an operation and returns its error. The mutex must be held.
+func (s *Store) record(method, key string, value any, err error) error {
+	s.ops = append(s.ops, Op{Method: method, Key: key, Value: value, Err: err})
+	return err
+}
+
+// Open opens the store again after it has been closed. The path is ignored.
+func (s *Store) Open(path string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := s.begin("Open")
+	if err == kvstore.NotOpenErr {
+		err = nil
+	}
+	if err == nil {
+		s.closed = false
+	}
+	return s.record("Open", "", nil, err)
+}
+
+// Close closes the store. The values are kept, so that they can be inspected after the code under test has
+// closed the store.
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := s.begin("Close")
+	if err == kvstore.NotOpenErr {
+		err = nil
+	}
+	if err == nil {
+		s.closed = true
+	}
+	return s.record("Close", "", nil, err)
+}
+
+// Set sets the value for the given key.
+func (s *Store) Set(key string, value any) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := s.begin("Set")
+	if err == nil {
+		s.values[key] = value
+	}
+	return s.record("Set", key, value, err)
+}
+
+// Get returns the value for the key, the default if no value is set, and kvstore.NotFoundErr if neither of
+// them is present.
+func (s *Store) Get(key string) (any, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := s.begin("Get")
+	var v any
+	if err == nil {
+		var ok bool
+		if v, ok = s.get(key); !ok {
+			err = kvstore.NotFoundErr
+		}
+	}
+	return v, s.record("Get", key, nil, err)
+}
+
+// get returns the value or default of the key. The mutex must be held.
+func (s *Store) get(key string) (any, bool) {
+	if v, ok := s.values[key]; ok {
+		return v, true
+	}
+	v, ok := s.defaults[key]
+	return v, ok
+}
+
+// SetMany sets all key-value pairs in the map. If the call fails, none of them is set.
+func (s *Store) SetMany(values map[string]any) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := s.begin("SetMany")
+	if err == nil {
+		maps.Copy(s.values, values)
+	}
+	return s.record("SetMany", "", maps.Clone(values), err)
+}
+
+// GetAll returns up to limit keys with their values or defaults, all of them if limit is not positive.
+func (s *Store) GetAll(limit int) (map[string]any, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := s.begin("GetAll")
+	result := make(map[string]any)
+	if err == nil {
+		keys := maps.Clone(s.defaults)
+		maps.Copy(keys, s.values)
+		for _, k := range slices.Sorted(maps.Keys(keys)) {
+			if limit > 0 && len(result) >= limit {
+				break
+			}
+			result[k], _ = s.get(k)
+		}
+	}
+	return result, s.record("GetAll", "", nil, err)
+}
+
+// Revert removes the value of the key, so that Get returns its default.
+func (s *Store) Revert(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := s.begin("Revert")
+	if err == nil {
+		delete(s.values, key)
+	}
+	return s.record("Revert", key, nil, err)
+}
+
+// Info returns the key info set with SetDefault, and false if there is none. Info is not recorded and cannot
+// be scripted to fail.
+func (s *Store) Info(key string) (kvstore.KeyInfo, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return kvstore.KeyInfo{}, false
+	}
+	info, ok := s.infos[key]
+	return info, ok
+}
+
+// Delete removes the key with its value, default, and key info.
+func (s *Store) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := s.begin("Delete")
+	if err == nil {
+		s.delete(key)
+	}
+	return s.record("Delete", key, nil, err)
+}
+
+// delete removes a key. The mutex must be held.
+func (s *Store) delete(key string) {
+	delete(s.values, key)
+	delete(s.defaults, key)
+	delete(s.infos, key)
+}
+
+// DeleteMany removes all the given keys. If the call fails, none of them is removed.
+func (s *Store) DeleteMany(keys []string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := s.begin("DeleteMany")
+	if err == nil {
+		for _, k := range keys {
+			s.delete(k)
+		}
+	}
+	return s.record("DeleteMany", "", slices.Clone(keys), err)
+}
+
+// SetDefault sets the default and key info for the key.
+func (s *Store) SetDefault(key string, value any, info kvstore.KeyInfo) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err := s.begin("SetDefault")
+	if err == nil {
+		s.defaults[key] = value
+		s.infos[key] = info
+	}
+	return s.record("SetDefault", key, value, err)
+}
+
+// called returns true if the method has been called successfully for the key, or at all if key is empty.
+func (s *Store) called(method, key string) bool {
+	return slices.ContainsFunc(s.Ops(), func(op Op) bool {
+		return op.Method == method && op.Err == nil && (key == "" || op.Key == key)
+	})
+}
+
+// AssertCalled reports an error if the method has not been called successfully for the key. If key is empty,
+// any successful call of the method is accepted.
+func (s *Store) AssertCalled(t testing.TB, method, key string) {
+	t.Helper()
+	if !s.called(method, key) {
+		t.Errorf(`expected %s to be called for key %q`, method, key)
+	}
+}
+
+// AssertNotCalled reports an error if the method has been called successfully for the key. If key is empty,
+// no successful call of the method is accepted.
+func (s *Store) AssertNotCalled(t testing.TB, method, key string) {
+	t.Helper()
+	if s.called(method, key) {
+		t.Errorf(`expected %s not to be called for key %q`, method, key)
+	}
+}
+
+// AssertCalls reports an error if the method has not been called n times, including failed calls.
+func (s *Store) AssertCalls(t testing.TB, method string, n int) {
+	t.Helper()
+	s.mutex.Lock()
+	calls := s.calls[method]
+	s.mutex.Unlock()
+	if calls != n {
+		t.Errorf(`expected %d calls of %s, got %d`, n, method, calls)
+	}
+}
+
+// AssertValue reports an error if Get would not return a value deeply equal to want for the key.
+func (s *Store) AssertValue(t testing.TB, key string, want any) {
+	t.Helper()
+	s.mutex.Lock()
+	v, ok := s.get(key)
+	s.mutex.Unlock()
+	switch {
+	case !ok:
+		t.Errorf(`expected %v for key %q, but the key is not present`, want, key)
+	case !reflect.DeepEqual(v, want):
+		t.Errorf(`expected %v for key %q, got %v`, want, key, v)
+	}
+}
+
+// AssertMissing reports an error if the key has a value or default.
+func (s *Store) AssertMissing(t testing.TB, key string) {
+	t.Helper()
+	s.mutex.Lock()
+	v, ok := s.get(key)
+	s.mutex.Unlock()
+	if ok {
+		t.Errorf(`expected key %q not to be present, got %v`, key, v)
+	}
+}
@@ -0,0 +1,86 @@
+package testkv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rasteric/kvstore"
+)
+
+func TestStore(t *testing.T) {
+	s := New()
+	if err := s.SetDefault("theme", "light", kvstore.KeyInfo{Category: "ui"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("theme", "dark"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Get("theme"); err != nil || v != "dark" {
+		t.Errorf(`expected dark, got %v, %v`, v, err)
+	}
+	if err := s.Revert("theme"); err != nil {
+		t.Fatal(err)
+	}
+	s.AssertValue(t, "theme", "light")
+	if info, ok := s.Info("theme"); !ok || info.Category != "ui" {
+		t.Errorf(`expected key info, got %v, %v`, info, ok)
+	}
+	if _, err := s.Get("missing"); !errors.Is(err, kvstore.NotFoundErr) {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+	if err := s.SetMany(map[string]any{"a": 1, "b": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if all, err := s.GetAll(2); err != nil || len(all) != 2 || all["a"] != 1 || all["b"] != 2 {
+		t.Errorf(`expected first two keys, got %v, %v`, all, err)
+	}
+	if err := s.DeleteMany([]string{"a", "theme"}); err != nil {
+		t.Fatal(err)
+	}
+	s.AssertMissing(t, "a")
+	s.AssertMissing(t, "theme")
+	s.AssertCalled(t, "Set", "theme")
+	s.AssertCalled(t, "DeleteMany", "")
+	s.AssertNotCalled(t, "Delete", "")
+	s.AssertCalls(t, "Get", 2)
+	if ops := s.Ops(); len(ops) != 8 || ops[0].Method != "SetDefault" || ops[0].Value != "light" {
+		t.Errorf(`unexpected operations: %v`, ops)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("b", 3); !errors.Is(err, kvstore.NotOpenErr) {
+		t.Errorf(`expected NotOpenErr after Close, got %v`, err)
+	}
+	if err := s.Open("ignored"); err != nil {
+		t.Fatal(err)
+	}
+	s.AssertValue(t, "b", 2)
+}
+
+func TestFail(t *testing.T) {
+	s := New()
+	full := errors.New("disk full")
+	s.Fail("Set", 3, full)
+	for i, want := range []error{nil, nil, full, nil} {
+		if err := s.Set("key", i); err != want {
+			t.Errorf(`call %d: expected %v, got %v`, i+1, want, err)
+		}
+	}
+	s.AssertValue(t, "key", 3)
+	s.Fail("Delete", 0, full)
+	for range 2 {
+		if err := s.Delete("key"); err != full {
+			t.Errorf(`expected %v, got %v`, full, err)
+		}
+	}
+	s.AssertNotCalled(t, "Delete", "key")
+	if ops := s.Ops(); ops[2].Err != full || ops[2].Value != 2 {
+		t.Errorf(`expected failed operation to be recorded, got %v`, ops[2])
+	}
+	s.Reset()
+	if err := s.Delete("key"); err != nil {
+		t.Errorf(`expected scripted errors to be cleared, got %v`, err)
+	}
+	s.AssertCalls(t, "Delete", 1)
+}
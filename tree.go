@@ -0,0 +1,71 @@
+package kvstore
+
+import (
+	"sync/atomic"
+)
+
+// GetSubtree treats keys as paths separated by KeySeparator and returns all key-value pairs in the subtree
+// rooted at path, i.e., the key path itself and all keys starting with path followed by a separator. An
+// empty path denotes the whole store. As with Get, defaults are returned for keys without value.
+func (db *KVStore) GetSubtree(path string) (map[string]any, error) {
+	cond, args := subtreeCond(path)
+	return db.getByCondition(cond, args...)
+}
+
+// DeleteSubtree removes all keys in the subtree rooted at path in one transaction, see GetSubtree.
+func (db *KVStore) DeleteSubtree(path string) error {
+	cond, args := subtreeCond(path)
+	return db.deleteWhere(cond, args...)
+}
+
+// ListChildren returns the paths of the immediate children of path in ascending order, i.e., path followed by
+// a separator and one more path segment, regardless of whether there is a key for the child itself or only
+// for its descendants. Separators escaped by JoinKey are not treated as separators.
+func (db *KVStore) ListChildren(path string) ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return nil, err
+	}
+	prefix := ""
+	if path != "" {
+		prefix = path + string(KeySeparator)
+	}
+	keys, err := db.liveKeys(`kv.key GLOB ?`, `ORDER BY kv.key ASC`, globPrefix(prefix))
+	if err != nil {
+		return nil, err
+	}
+	children := make([]string, 0)
+	for _, key := range keys {
+		child := prefix + firstSegment(key[len(prefix):])
+		if len(children) == 0 || children[len(children)-1] != child {
+			children = append(children, child)
+		}
+	}
+	return children, nil
+}
+
+// subtreeCond returns the SQL condition and arguments matching all keys in the subtree rooted at path.
+func subtreeCond(path string) (string, []any) {
+	if path == "" {
+		return `1`, nil
+	}
+	return `(kv.key=? OR kv.key GLOB ?)`, []any{path, globPrefix(path + string(KeySeparator))}
+}
+
+// firstSegment returns the part of the path before the first unescaped separator.
+func firstSegment(path string) string {
+	escaped := false
+	for i, r := range path {
+		switch {
+		case escaped:
+			escaped = false
+		case r == keyEscape:
+			escaped = true
+		case r == KeySeparator:
+			return path[:i]
+		}
+	}
+	return path
+}
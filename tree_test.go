@@ -0,0 +1,41 @@
+package kvstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyTrees(t *testing.T) {
+	db := openTestStore(t)
+	keys := []string{"app", "app/window/width", "app/window/height", "app/theme", `app/a\/b/c`, "application", "other/x"}
+	for i, key := range keys {
+		if err := db.Set(key, i); err != nil {
+			t.Fatalf(`failed to set key: %v`, err)
+		}
+	}
+	subtree, err := db.GetSubtree("app/window")
+	if err != nil {
+		t.Fatalf(`failed to get subtree: %v`, err)
+	}
+	if len(subtree) != 2 || subtree["app/window/width"] != 1 {
+		t.Errorf(`wrong subtree: %v`, subtree)
+	}
+	children, err := db.ListChildren("app")
+	if err != nil {
+		t.Fatalf(`failed to list children: %v`, err)
+	}
+	if strings.Join(children, ",") != `app/a\/b,app/theme,app/window` {
+		t.Errorf(`wrong children: %v`, children)
+	}
+	children, _ = db.ListChildren("")
+	if strings.Join(children, ",") != "app,application,other" {
+		t.Errorf(`wrong children of root: %v`, children)
+	}
+	if err := db.DeleteSubtree("app"); err != nil {
+		t.Fatalf(`failed to delete subtree: %v`, err)
+	}
+	all, _ := db.GetAll(0)
+	if len(all) != 2 || all["application"] != 5 || all["other/x"] != 6 {
+		t.Errorf(`wrong keys after deleting subtree: %v`, all)
+	}
+}
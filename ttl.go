@@ -0,0 +1,232 @@
+package kvstore
+
+import (
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Keys may expire either at a fixed time after they were set (absolute TTL) or after they have not been read for
+// a given duration (sliding TTL), in which case every read or write extends their lifetime. TTLs are set per key with
+// SetWithTTL and SetWithSlidingTTL, or per category with SetCategoryTTL. A TTL set for a key takes precedence over
+// the TTL of its category. Expired keys are not returned by Get, GetWithSource, Has, and the methods reading or
+// listing several keys such as GetMany, GetAll, KeysMatching, and QueryIndex, and are deleted by PurgeExpired.
+
+// expiryJoins joins kv with the tables needed to compute when keys expire.
+const expiryJoins = `LEFT JOIN kv_expiry e ON e.key=kv.key LEFT JOIN kv_category_ttl c ON c.category=kv.category
+LEFT JOIN kv_access a ON a.key=kv.key`
+
+// expiresExpr is the SQL expression for the expiration time of a key in milliseconds since the Unix epoch,
+// NULL if the key does not expire.
+const expiresExpr = `(CASE
+  WHEN e.key IS NOT NULL THEN (CASE WHEN e.sliding THEN max(e.since,coalesce(kv.updated_at,0),coalesce(a.last_accessed,0)) ELSE e.since END)+e.ttl
+  WHEN c.category IS NOT NULL THEN (CASE WHEN c.sliding THEN max(coalesce(kv.updated_at,0),coalesce(a.last_accessed,0))
+    ELSE coalesce(kv.updated_at,0) END)+c.ttl
+END)`
+
+// slidingExpr is the SQL expression that is true if reading a key extends its lifetime.
+const slidingExpr = `coalesce(e.sliding,CASE WHEN e.key IS NULL THEN c.sliding END,0)`
+
+// queryLive queries the key, the value columns, and whether reading extends the lifetime, see scanLive, of the
+// keys matching the SQL condition that have not expired, followed by suffix, e.g. an ORDER BY clause. Columns
+// of kv must be qualified in cond and suffix, since kv is joined with expiryJoins.
+func (db *KVStore) queryLive(q sqlx.Queryer, cond, suffix string, args ...any) (*sqlx.Rows, error) {
+	return q.Queryx(`SELECT kv.key,`+db.valueColumns()+`,`+slidingExpr+` FROM kv `+expiryJoins+` WHERE (`+
+		expiresExpr+` IS NULL OR `+expiresExpr+`>?) AND (`+cond+`) `+suffix+`;`,
+		append([]any{db.now().UnixMilli()}, args...)...)
+}
+
+// liveKeys returns the keys matching the SQL condition that have not expired, ordered by suffix. As with
+// queryLive, columns of kv must be qualified in cond and suffix.
+func (db *KVStore) liveKeys(cond, suffix string, args ...any) ([]string, error) {
+	keys := make([]string, 0)
+	err := db.sqx.Select(&keys, `SELECT kv.key FROM kv `+expiryJoins+` WHERE (`+expiresExpr+` IS NULL OR `+
+		expiresExpr+`>?) AND (`+cond+`) `+suffix+`;`, append([]any{db.now().UnixMilli()}, args...)...)
+	return keys, err
+}
+
+// scanLive scans a row returned by queryLive.
+func scanLive(rows *sqlx.Rows) (string, storedValue, bool, error) {
+	var key string
+	var sv storedValue
+	var sliding bool
+	err := rows.Scan(append(append([]any{&key}, sv.dest()...), &sliding)...)
+	return key, sv, sliding, err
+}
+
+// initExpiry creates the tables holding TTLs of keys and categories.
+func initExpiry(tx *sqlx.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS kv_expiry(
+  key TEXT PRIMARY KEY NOT NULL,
+  since INTEGER NOT NULL,
+  ttl INTEGER NOT NULL,
+  sliding INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS kv_category_ttl(
+  category TEXT PRIMARY KEY NOT NULL,
+  ttl INTEGER NOT NULL,
+  sliding INTEGER NOT NULL
+);
+CREATE TRIGGER IF NOT EXISTS kv_expiry_delete AFTER DELETE ON kv BEGIN
+  DELETE FROM kv_expiry WHERE key=old.key;
+END;
+CREATE TRIGGER IF NOT EXISTS kv_expiry_rename AFTER UPDATE OF key ON kv WHEN old.key<>new.key BEGIN
+  UPDATE kv_expiry SET key=new.key WHERE key=old.key;
+END;
+`)
+	return err
+}
+
+// expiry holds the expiration columns of a key read together with its value.
+type expiry struct {
+	expires sql.NullInt64
+	sliding bool
+}
+
+// dest returns the scan destinations for expiryColumns.
+func (x *expiry) dest() []any {
+	return []any{&x.expires, &x.sliding}
+}
+
+// expired returns true if the key has expired at the given time.
+func (x expiry) expired(now time.Time) bool {
+	return x.expires.Valid && x.expires.Int64 <= now.UnixMilli()
+}
+
+// expiryColumns are the columns scanned by expiry.dest, which must be selected from kv joined with expiryJoins.
+const expiryColumns = expiresExpr + `,` + slidingExpr
+
+// readValue reads the stored value and expiration of a key, NotFoundErr if there is no key or it has expired.
+func (db *KVStore) readValue(q sqlx.Queryer, key string) (storedValue, expiry, error) {
+	var sv storedValue
+	var x expiry
+	err := q.QueryRowx(`SELECT `+db.valueColumns()+`,`+expiryColumns+` FROM kv `+expiryJoins+` WHERE kv.key=? LIMIT 1;`,
+		key).Scan(append(sv.dest(), x.dest()...)...)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && x.expired(db.now())) {
+		return sv, x, NotFoundErr
+	}
+	return sv, x, err
+}
+
+// SetWithTTL sets the value for the given key, which expires after the given duration.
+func (db *KVStore) SetWithTTL(key string, value any, ttl time.Duration) error {
+	return db.setWithTTL(key, value, ttl, false)
+}
+
+// SetWithSlidingTTL sets the value for the given key, which expires when it has not been read for the given duration.
+func (db *KVStore) SetWithSlidingTTL(key string, value any, ttl time.Duration) error {
+	return db.setWithTTL(key, value, ttl, true)
+}
+
+// setWithTTL sets the value and TTL of a key in one transaction.
+func (db *KVStore) setWithTTL(key string, value any, ttl time.Duration, sliding bool) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	value, err := db.beforeSet(key, value)
+	if err != nil {
+		return err
+	}
+	if err := db.checkConstraints(db.sqx, key, value); err != nil {
+		return err
+	}
+	b, err := db.encode(value)
+	if err != nil {
+		return err
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	defer db.cache.remove(key)
+	if err := db.checkQuota(tx, key, len(b)); err != nil {
+		return err
+	}
+	notify := db.hasListeners()
+	var old any
+	if notify {
+		old = db.current(tx, key)
+	}
+	if err := db.put(tx, key, b, typeName(value)); err != nil {
+		return err
+	}
+	if err := db.setTTL(tx, key, ttl, sliding); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if notify {
+		if err := db.notify(change{key: key, old: old, new: value}); err != nil {
+			return err
+		}
+	}
+	db.afterSet(key, value)
+	return db.evict(key)
+}
+
+// setTTL sets the TTL of a key, starting now.
+func (db *KVStore) setTTL(ex sqlx.Execer, key string, ttl time.Duration, sliding bool) error {
+	_, err := ex.Exec(`INSERT INTO kv_expiry(key,since,ttl,sliding) VALUES(?,?,?,?)
+ON CONFLICT(key) DO UPDATE SET since=excluded.since,ttl=excluded.ttl,sliding=excluded.sliding;`,
+		key, db.now().UnixMilli(), ttl.Milliseconds(), sliding)
+	return err
+}
+
+// Persist removes the TTL set for the given key with SetWithTTL or SetWithSlidingTTL. The key still expires if
+// its category has a TTL.
+func (db *KVStore) Persist(key string) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	_, err := db.exec(`DELETE FROM kv_expiry WHERE key=?;`, key)
+	return err
+}
+
+// SetCategoryTTL makes all keys of the given category that have no TTL of their own expire after the given
+// duration, counted from the last time they were modified or, if sliding is true, the last time they were
+// modified or read. A duration that is not positive removes the TTL of the category.
+func (db *KVStore) SetCategoryTTL(category string, ttl time.Duration, sliding bool) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	db.cache.clear()
+	if ttl <= 0 {
+		_, err := db.exec(`DELETE FROM kv_category_ttl WHERE category=?;`, category)
+		return err
+	}
+	_, err := db.exec(`INSERT INTO kv_category_ttl(category,ttl,sliding) VALUES(?,?,?)
+ON CONFLICT(category) DO UPDATE SET ttl=excluded.ttl,sliding=excluded.sliding;`, category, ttl.Milliseconds(), sliding)
+	return err
+}
+
+// Expiration returns the time the given key expires, or the zero time if it does not expire.
+// NotFoundErr is returned if there is no key or it has already expired.
+func (db *KVStore) Expiration(key string) (time.Time, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return time.Time{}, NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return time.Time{}, err
+	}
+	var x expiry
+	err := db.sqx.QueryRowx(`SELECT `+expiryColumns+` FROM kv `+expiryJoins+` WHERE kv.key=?;`, key).Scan(x.dest()...)
+	if errors.Is(err, sql.ErrNoRows) || x.expired(db.now()) {
+		return time.Time{}, NotFoundErr
+	}
+	return millisTime(x.expires), err
+}
+
+// PurgeExpired deletes all expired keys in one transaction.
+func (db *KVStore) PurgeExpired() error {
+	return db.deleteWhere(`key IN (SELECT kv.key FROM kv `+expiryJoins+` WHERE `+expiresExpr+`<=?)`,
+		db.now().UnixMilli())
+}
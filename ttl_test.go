@@ -0,0 +1,129 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.SetWithTTL("absolute", 1, 150*time.Millisecond); err != nil {
+		t.Fatalf(`failed to set key with TTL: %v`, err)
+	}
+	if err := db.SetWithSlidingTTL("sliding", 2, 150*time.Millisecond); err != nil {
+		t.Fatalf(`failed to set key with sliding TTL: %v`, err)
+	}
+	if err := db.SetDefault("session", 0, KeyInfo{Category: "sessions"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.SetCategoryTTL("sessions", 150*time.Millisecond, false); err != nil {
+		t.Fatalf(`failed to set category TTL: %v`, err)
+	}
+	if exp, err := db.Expiration("absolute"); err != nil || exp.IsZero() {
+		t.Errorf(`expected expiration time, got %v, %v`, exp, err)
+	}
+	for range 3 {
+		time.Sleep(75 * time.Millisecond)
+		if v, err := db.Get("sliding"); v != 2 || err != nil {
+			t.Fatalf(`expected sliding key to be extended, got %v, %v`, v, err)
+		}
+	}
+	for _, key := range []string{"absolute", "session"} {
+		if _, err := db.Get(key); !errors.Is(err, NotFoundErr) {
+			t.Errorf(`expected %v to have expired, got %v`, key, err)
+		}
+		if ok, _ := db.Has(key); ok {
+			t.Errorf(`expired key %v is still present`, key)
+		}
+	}
+	time.Sleep(200 * time.Millisecond)
+	if _, err := db.Get("sliding"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected sliding key to have expired, got %v`, err)
+	}
+	if err := db.PurgeExpired(); err != nil {
+		t.Fatalf(`failed to purge expired keys: %v`, err)
+	}
+	all, err := db.GetAll(0)
+	if err != nil {
+		t.Fatalf(`failed to get all keys: %v`, err)
+	}
+	if len(all) != 0 {
+		t.Errorf(`expected expired keys to be purged, got %v`, all)
+	}
+}
+
+func TestExpiredBulkReads(t *testing.T) {
+	db := openTestStore(t)
+	clock := NewManualClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	db.SetClock(clock)
+	if err := db.SetDefault("token", "", KeyInfo{Category: "auth"}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.RegisterIndex("value", func(key string, value any) (any, bool) { return value, true }); err != nil {
+		t.Fatalf(`failed to register index: %v`, err)
+	}
+	if err := db.SetWithTTL("token", "tok", time.Minute); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.SetWithSlidingTTL("session", "s", time.Minute); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Set("name", "Alice"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	for range 2 {
+		clock.Advance(50 * time.Second)
+		if all, err := db.GetAll(0); err != nil || all["session"] != "s" {
+			t.Fatalf(`expected sliding key to be extended by GetAll, got %v, %v`, all, err)
+		}
+	}
+	if m, err := db.GetMany([]string{"token", "session", "name"}); err != nil || len(m) != 2 || m["token"] != nil {
+		t.Errorf(`expected expired key to be missing from GetMany, got %v, %v`, m, err)
+	}
+	if all, err := db.GetAll(0); err != nil || len(all) != 2 || all["token"] != nil {
+		t.Errorf(`expected expired key to be missing from GetAll, got %v, %v`, all, err)
+	}
+	var s string
+	if err := db.GetJSON("token", &s); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr from GetJSON, got %q, %v`, s, err)
+	}
+	if err := db.ForEach(func(key string, value any) error {
+		if key == "token" {
+			t.Errorf(`expected expired key to be skipped by ForEach`)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf(`ForEach failed: %v`, err)
+	}
+	if page, err := db.GetPage(0, 0, OrderKeyAsc); err != nil || len(page) != 2 {
+		t.Errorf(`expected expired key to be missing from GetPage, got %v, %v`, page, err)
+	}
+	if m, err := db.GetByCategoryTree(""); err != nil || len(m) != 2 {
+		t.Errorf(`expected expired key to be missing from GetByCategoryTree, got %v, %v`, m, err)
+	}
+	if m, err := db.GetSubtree(""); err != nil || len(m) != 2 {
+		t.Errorf(`expected expired key to be missing from GetSubtree, got %v, %v`, m, err)
+	}
+	if children, err := db.ListChildren(""); err != nil || len(children) != 2 {
+		t.Errorf(`expected expired key to be missing from ListChildren, got %v, %v`, children, err)
+	}
+	if keys, err := db.KeysByCategory("auth"); err != nil || len(keys) != 0 {
+		t.Errorf(`expected expired key to be missing from KeysByCategory, got %v, %v`, keys, err)
+	}
+	if keys, err := db.KeysMatching("*", MatchGlob); err != nil || len(keys) != 2 {
+		t.Errorf(`expected expired key to be missing from KeysMatching, got %v, %v`, keys, err)
+	}
+	if keys, err := db.QueryIndex("value", "tok"); err != nil || len(keys) != 0 {
+		t.Errorf(`expected expired key to be missing from QueryIndex, got %v, %v`, keys, err)
+	}
+	if err := db.Update(func(tx Tx) error {
+		all, err := tx.GetAll(0)
+		if err == nil && len(all) != 2 {
+			t.Errorf(`expected expired key to be missing in transaction, got %v`, all)
+		}
+		return err
+	}); err != nil {
+		t.Fatalf(`update failed: %v`, err)
+	}
+}
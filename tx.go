@@ -0,0 +1,228 @@
+package kvstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ReadTx is a read-only transaction passed to the function given to View. All reads see a consistent
+// snapshot of the database.
+type ReadTx interface {
+	Get(key string) (any, error)              // get the value for key, NotFoundErr if there is no key
+	Has(key string) (bool, error)             // true if there is a value or default for key
+	GetAll(limit int) (map[string]any, error) // get all key-value pairs as a map
+}
+
+// Tx is a read-write transaction passed to the function given to Update. Reads see the writes made
+// earlier in the same transaction.
+type Tx interface {
+	ReadTx
+	Set(key string, value any) error   // set the key to the given value
+	Delete(key string) error           // remove the key and value for the key
+	Revert(key string) error           // revert key to its default
+	Update(fn func(tx Tx) error) error // run fn in a nested transaction rolled back on error
+}
+
+// txStore implements Tx on top of an sql transaction.
+type txStore struct {
+	db      *KVStore
+	tx      *sqlx.Tx
+	keys    []string // keys written, which are removed from the cache after the transaction
+	changes []change // change notifications sent after commit
+	notify  bool
+	depth   int // the number of nested transactions
+}
+
+// Update runs fn within a read-write transaction, which is committed if fn returns nil and rolled back
+// otherwise. The error returned by fn is returned by Update. Change listeners are called after commit,
+// and all changes form a single mutation in the journal. The transaction must not be used after fn returns.
+func (db *KVStore) Update(fn func(tx Tx) error) (err error) {
+	rows := 0
+	defer func(start time.Time) { db.observe(opUpdate, "", rows, start, &err) }(time.Now())
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	t := &txStore{db: db, tx: tx, notify: db.hasListeners()}
+	defer func() { db.cache.remove(t.keys...) }()
+	defer func() { rows = len(t.keys) }()
+	if err := fn(t); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := db.notify(t.changes...); err != nil {
+		return err
+	}
+	return db.evict(t.keys...)
+}
+
+// View runs fn within a read-only transaction that sees a consistent snapshot of the database. The error
+// returned by fn is returned by View. The transaction must not be used after fn returns.
+func (db *KVStore) View(fn func(tx ReadTx) error) error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return err
+	}
+	tx, err := db.sqx.BeginTxx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(&txStore{db: db, tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Get gets the value for the given key, the default if no value is stored, and NotFoundErr if neither
+// of them is present.
+func (t *txStore) Get(key string) (any, error) {
+	sv, _, err := t.db.readValue(t.tx, key)
+	if err != nil {
+		return nil, err
+	}
+	v, ok, err := sv.decode()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, NotFoundErr
+	}
+	return v, nil
+}
+
+// Has returns true if there is a value or default for the key.
+func (t *txStore) Has(key string) (bool, error) {
+	sv, _, err := t.db.readValue(t.tx, key)
+	if errors.Is(err, NotFoundErr) {
+		return false, nil
+	}
+	return err == nil && (sv.value != nil || sv.original != nil), err
+}
+
+// GetAll returns up to limit key-value pairs, all of them if limit is not positive.
+func (t *txStore) GetAll(limit int) (map[string]any, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := t.db.queryLive(t.tx, `1`, `LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make(map[string]any)
+	for rows.Next() {
+		key, sv, _, err := scanLive(rows)
+		if err != nil {
+			return result, err
+		}
+		v, ok, err := sv.decode()
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			result[key] = v
+		}
+	}
+	return result, rows.Err()
+}
+
+// Set sets the value for the given key, checking constraints and locks like KVStore.Set.
+func (t *txStore) Set(key string, value any) error {
+	if err := t.db.checkConstraints(t.tx, key, value); err != nil {
+		return err
+	}
+	b, err := t.db.encode(value)
+	if err != nil {
+		return err
+	}
+	if err := t.db.checkQuota(t.tx, key, len(b)); err != nil {
+		return err
+	}
+	var old any
+	if t.notify {
+		old = t.db.current(t.tx, key)
+	}
+	if err := t.db.put(t.tx, key, b, typeName(value)); err != nil {
+		return err
+	}
+	t.changed(key, old, value)
+	return nil
+}
+
+// Delete removes the key with its value and default.
+func (t *txStore) Delete(key string) error {
+	var old any
+	if t.notify {
+		old = t.db.current(t.tx, key)
+	}
+	if _, err := t.tx.Exec(`DELETE FROM kv WHERE key=?;`, key); err != nil {
+		return err
+	}
+	t.changed(key, old, nil)
+	return nil
+}
+
+// Revert reverts the key to its default.
+func (t *txStore) Revert(key string) error {
+	var old any
+	if t.notify {
+		old = t.db.current(t.tx, key)
+	}
+	if _, err := t.tx.Exec(`UPDATE kv SET `+revertColumns+` WHERE key=?;`, t.db.now().UnixMilli(), key); err != nil {
+		return err
+	}
+	var new any
+	if t.notify {
+		new = t.db.current(t.tx, key)
+	}
+	t.changed(key, old, new)
+	return nil
+}
+
+// Update runs fn in a nested transaction using an sqlite savepoint. If fn returns an error, only the changes made
+// by fn are rolled back and the error is returned, so the enclosing transaction may continue or fail itself.
+// This allows transactional helpers taking a Tx to be composed within a larger transaction.
+func (t *txStore) Update(fn func(tx Tx) error) error {
+	t.depth++
+	defer func() { t.depth-- }()
+	name := "kv_savepoint_" + strconv.Itoa(t.depth)
+	if _, err := t.tx.Exec(`SAVEPOINT ` + name + `;`); err != nil {
+		return err
+	}
+	changes := len(t.changes)
+	if err := fn(t); err != nil {
+		t.changes = t.changes[:changes]
+		if _, err2 := t.tx.Exec(`ROLLBACK TO ` + name + `; RELEASE ` + name + `;`); err2 != nil {
+			return errors.Join(err, err2)
+		}
+		return err
+	}
+	_, err := t.tx.Exec(`RELEASE ` + name + `;`)
+	return err
+}
+
+// changed records a write to a key.
+func (t *txStore) changed(key string, old, new any) {
+	t.keys = append(t.keys, key)
+	if t.notify {
+		t.changes = append(t.changes, change{key: key, old: old, new: new})
+	}
+}
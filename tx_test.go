@@ -0,0 +1,96 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdateView(t *testing.T) {
+	db := openTestStore(t)
+	if err := db.Set("counter", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	var changes int
+	db.OnChange(func(key string, old, new any) { changes++ })
+	err := db.Update(func(tx Tx) error {
+		v, err := tx.Get("counter")
+		if err != nil {
+			return err
+		}
+		if err := tx.Set("counter", v.(int)+1); err != nil {
+			return err
+		}
+		if v, err := tx.Get("counter"); v != 2 || err != nil {
+			t.Errorf(`expected the transaction to see its own write, got %v, %v`, v, err)
+		}
+		return tx.Set("other", "x")
+	})
+	if err != nil {
+		t.Fatalf(`update failed: %v`, err)
+	}
+	if changes != 2 {
+		t.Errorf(`expected 2 change notifications, got %v`, changes)
+	}
+	failed := errors.New("failed")
+	err = db.Update(func(tx Tx) error {
+		if err := tx.Delete("counter"); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf(`expected the error of the closure, got %v`, err)
+	}
+	err = db.View(func(tx ReadTx) error {
+		if v, err := tx.Get("counter"); v != 2 || err != nil {
+			t.Errorf(`expected rolled back delete, got %v, %v`, v, err)
+		}
+		all, err := tx.GetAll(0)
+		if len(all) != 2 {
+			t.Errorf(`expected 2 keys, got %v`, all)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf(`view failed: %v`, err)
+	}
+	if changes != 2 {
+		t.Errorf(`expected no notifications for a rolled back transaction, got %v`, changes)
+	}
+}
+
+func TestNestedUpdate(t *testing.T) {
+	db := openTestStore(t)
+	failed := errors.New("failed")
+	setBoth := func(tx Tx) error {
+		if err := tx.Set("a", 1); err != nil {
+			return err
+		}
+		return tx.Set("b", 2)
+	}
+	err := db.Update(func(tx Tx) error {
+		if err := tx.Update(setBoth); err != nil {
+			return err
+		}
+		err := tx.Update(func(tx Tx) error {
+			if err := tx.Set("c", 3); err != nil {
+				return err
+			}
+			return failed
+		})
+		if !errors.Is(err, failed) {
+			t.Errorf(`expected the error of the nested closure, got %v`, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf(`update failed: %v`, err)
+	}
+	all, err := db.GetAll(0)
+	if err != nil {
+		t.Fatalf(`failed to get all keys: %v`, err)
+	}
+	if len(all) != 2 || all["a"] != 1 || all["b"] != 2 {
+		t.Errorf(`expected only the successful nested transaction to be committed, got %v`, all)
+	}
+}
@@ -0,0 +1,258 @@
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+var TypeMismatchErr = errors.New(`value cannot be converted to the requested type`)
+var NotPointerErr = errors.New(`expected a non-nil pointer`)
+
+// GetInto stores the value for the key, or the default if no value is set, in the variable pointed to by dest,
+// for example:
+//
+//	var settings Settings
+//	err := db.GetInto("settings", &settings)
+//
+// Values of type T and *T can both be read into a *T, and numeric values are converted to the type of the variable
+// if they fit into it without overflow or loss of a fractional part. A nil value sets the variable to its zero
+// value. Use GetJSON for values set with SetJSON.
+func (db *KVStore) GetInto(key string, dest any) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return NotPointerErr
+	}
+	v, err := db.Get(key)
+	if err != nil {
+		return err
+	}
+	target := rv.Elem()
+	if v == nil {
+		target.SetZero()
+		return nil
+	}
+	value := reflect.ValueOf(v)
+	switch {
+	case value.Type().AssignableTo(target.Type()):
+		target.Set(value)
+	case value.Kind() == reflect.Pointer && value.Type().Elem().AssignableTo(target.Type()):
+		if value.IsNil() {
+			target.SetZero()
+		} else {
+			target.Set(value.Elem())
+		}
+	case target.Kind() == reflect.Pointer && value.Type().AssignableTo(target.Type().Elem()):
+		p := reflect.New(value.Type())
+		p.Elem().Set(value)
+		target.Set(p)
+	case isNumeric(value.Kind()) && isNumeric(target.Kind()):
+		converted, ok := convertNumber(value, target.Type())
+		if !ok {
+			return mismatch(key, v, target.Type().String())
+		}
+		target.Set(converted)
+	default:
+		return mismatch(key, v, target.Type().String())
+	}
+	return nil
+}
+
+// GetString returns the value for the key as string. Byte slices are converted to strings.
+func (db *KVStore) GetString(key string) (string, error) {
+	v, err := db.Get(key)
+	if err != nil {
+		return "", err
+	}
+	switch s := v.(type) {
+	case string:
+		return s, nil
+	case []byte:
+		return string(s), nil
+	}
+	return "", mismatch(key, v, "string")
+}
+
+// GetInt returns the value for the key as int. Integers of all sizes are converted if they fit into an int,
+// and floating point numbers are converted if they have no fractional part.
+func (db *KVStore) GetInt(key string) (int, error) {
+	n, err := db.GetInt64(key)
+	if err != nil {
+		return 0, err
+	}
+	if n < math.MinInt || n > math.MaxInt {
+		return 0, fmt.Errorf("%w: value %v for key %q overflows int", TypeMismatchErr, n, key)
+	}
+	return int(n), nil
+}
+
+// GetInt64 returns the value for the key as int64. Integers of all sizes are converted if they fit into
+// an int64, and floating point numbers are converted if they have no fractional part.
+func (db *KVStore) GetInt64(key string) (int64, error) {
+	v, err := db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(key, v)
+}
+
+// toInt64 converts the value for the key to int64 as described for GetInt64.
+func toInt64(key string, v any) (int64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("%w: value %v for key %q overflows int64", TypeMismatchErr, v, key)
+		}
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("%w: value %v for key %q is not an integer", TypeMismatchErr, v, key)
+		}
+		return int64(f), nil
+	}
+	return 0, mismatch(key, v, "int64")
+}
+
+// convertNumber converts the numeric value to the numeric type t. Unlike reflect.Value.Convert, it returns false
+// instead of wrapping around if the value does not fit into t, or truncating if t is an integer type and the value
+// has a fractional part.
+func convertNumber(value reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	zero := reflect.Zero(t)
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt64("", value.Interface())
+		if err != nil || zero.OverflowInt(n) {
+			return reflect.Value{}, false
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if value.Int() < 0 {
+				return reflect.Value{}, false
+			}
+			n = uint64(value.Int())
+		case reflect.Float32, reflect.Float64:
+			f := value.Float()
+			if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+				return reflect.Value{}, false
+			}
+			n = uint64(f)
+		default:
+			n = value.Uint()
+		}
+		if zero.OverflowUint(n) {
+			return reflect.Value{}, false
+		}
+	case reflect.Float32:
+		if value.CanFloat() && zero.OverflowFloat(value.Float()) {
+			return reflect.Value{}, false
+		}
+	}
+	return value.Convert(t), true
+}
+
+// GetFloat64 returns the value for the key as float64. All numeric values are converted.
+func (db *KVStore) GetFloat64(key string) (float64, error) {
+	v, err := db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	if f, ok := toFloat64(v); ok {
+		return f, nil
+	}
+	return 0, mismatch(key, v, "float64")
+}
+
+// GetBool returns the value for the key as bool. Only boolean values are accepted.
+func (db *KVStore) GetBool(key string) (bool, error) {
+	v, err := db.Get(key)
+	if err != nil {
+		return false, err
+	}
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	return false, mismatch(key, v, "bool")
+}
+
+// GetBytes returns the value for the key as byte slice. Strings are converted to byte slices.
+func (db *KVStore) GetBytes(key string) ([]byte, error) {
+	v, err := db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case string:
+		return []byte(b), nil
+	}
+	return nil, mismatch(key, v, "[]byte")
+}
+
+// GetTime returns the value for the key as time.Time. Strings are parsed in RFC3339 format.
+func (db *KVStore) GetTime(key string) (time.Time, error) {
+	v, err := db.Get(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return time.Time{}, keyError("get", key, fmt.Errorf("%w: %w", TypeMismatchErr, err))
+		}
+		return parsed, nil
+	}
+	return time.Time{}, mismatch(key, v, "time.Time")
+}
+
+// SetTime stores the time for the key as a string in RFC3339 format with nanoseconds, so that it does not
+// depend on the encoding of time.Time. Get returns the string, use GetTime to read it as time.Time.
+func (db *KVStore) SetTime(key string, t time.Time) error {
+	return db.Set(key, t.Format(time.RFC3339Nano))
+}
+
+// SetDuration stores the duration for the key as number of nanoseconds. Get returns an int64, use GetDuration
+// to read it as time.Duration.
+func (db *KVStore) SetDuration(key string, d time.Duration) error {
+	return db.Set(key, int64(d))
+}
+
+// GetDuration returns the value for the key as time.Duration. Integers are interpreted as nanoseconds, and
+// strings are parsed with time.ParseDuration.
+func (db *KVStore) GetDuration(key string) (time.Duration, error) {
+	v, err := db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	switch d := v.(type) {
+	case time.Duration:
+		return d, nil
+	case string:
+		parsed, err := time.ParseDuration(d)
+		if err != nil {
+			return 0, keyError("get", key, fmt.Errorf("%w: %w", TypeMismatchErr, err))
+		}
+		return parsed, nil
+	}
+	n, err := toInt64(key, v)
+	if errors.Is(err, TypeMismatchErr) && !isNumeric(reflect.ValueOf(v).Kind()) {
+		return 0, mismatch(key, v, "time.Duration")
+	}
+	return time.Duration(n), err
+}
+
+// mismatch returns a KeyError wrapping TypeMismatchErr.
+func mismatch(key string, value any, expected string) error {
+	return keyError("get", key, fmt.Errorf("%w: value has type %T, expected %v", TypeMismatchErr, value, expected))
+}
@@ -0,0 +1,171 @@
+package kvstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTypedGetters(t *testing.T) {
+	db := openTestStore(t)
+	now := time.Now().Round(0)
+	pairs := map[string]any{
+		"string": "hello",
+		"bytes":  []byte("world"),
+		"int":    42,
+		"uint8":  uint8(7),
+		"float":  2.0,
+		"half":   2.5,
+		"bool":   true,
+		"time":   now,
+		"rfc":    "2024-01-02T15:04:05Z",
+	}
+	if err := db.SetMany(pairs); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	if s, err := db.GetString("bytes"); s != "world" || err != nil {
+		t.Errorf(`GetString: got %v, %v`, s, err)
+	}
+	if n, err := db.GetInt("uint8"); n != 7 || err != nil {
+		t.Errorf(`GetInt: got %v, %v`, n, err)
+	}
+	if n, err := db.GetInt64("float"); n != 2 || err != nil {
+		t.Errorf(`GetInt64: got %v, %v`, n, err)
+	}
+	if _, err := db.GetInt("half"); !errors.Is(err, TypeMismatchErr) {
+		t.Errorf(`expected TypeMismatchErr for fractional number, got %v`, err)
+	}
+	if f, err := db.GetFloat64("int"); f != 42 || err != nil {
+		t.Errorf(`GetFloat64: got %v, %v`, f, err)
+	}
+	if b, err := db.GetBool("bool"); !b || err != nil {
+		t.Errorf(`GetBool: got %v, %v`, b, err)
+	}
+	if _, err := db.GetBool("string"); !errors.Is(err, TypeMismatchErr) {
+		t.Errorf(`expected TypeMismatchErr for string as bool, got %v`, err)
+	}
+	if b, err := db.GetBytes("string"); string(b) != "hello" || err != nil {
+		t.Errorf(`GetBytes: got %v, %v`, b, err)
+	}
+	if tm, err := db.GetTime("time"); !tm.Equal(now) || err != nil {
+		t.Errorf(`GetTime: got %v, %v`, tm, err)
+	}
+	if tm, err := db.GetTime("rfc"); tm.Year() != 2024 || err != nil {
+		t.Errorf(`GetTime with RFC3339 string: got %v, %v`, tm, err)
+	}
+	if _, err := db.GetString("missing"); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+}
+
+func TestGetInto(t *testing.T) {
+	db := openTestStore(t)
+	defer db.Close()
+	if err := db.RegisterTypes(intoStruct{}, &intoPointer{}); err != nil {
+		t.Fatalf(`failed to register types: %v`, err)
+	}
+	if err := db.SetMany(map[string]any{"struct": intoStruct{Name: "a"}, "pointer": &intoPointer{Name: "b"},
+		"int": 42, "string": "text"}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	var s intoStruct
+	if err := db.GetInto("struct", &s); err != nil || s.Name != "a" {
+		t.Errorf(`expected struct, got %v, %v`, s, err)
+	}
+	var sp *intoStruct
+	if err := db.GetInto("struct", &sp); err != nil || sp == nil || sp.Name != "a" {
+		t.Errorf(`expected pointer to struct, got %v, %v`, sp, err)
+	}
+	var p intoPointer
+	if err := db.GetInto("pointer", &p); err != nil || p.Name != "b" {
+		t.Errorf(`expected dereferenced pointer, got %v, %v`, p, err)
+	}
+	var f float64
+	if err := db.GetInto("int", &f); err != nil || f != 42 {
+		t.Errorf(`expected converted number, got %v, %v`, f, err)
+	}
+	if err := db.GetInto("string", &f); !errors.Is(err, TypeMismatchErr) {
+		t.Errorf(`expected TypeMismatchErr, got %v`, err)
+	}
+	if err := db.GetInto("int", f); err != NotPointerErr {
+		t.Errorf(`expected NotPointerErr, got %v`, err)
+	}
+	if err := db.GetInto("missing", &f); !errors.Is(err, NotFoundErr) {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+}
+
+func TestGetIntoLossyConversion(t *testing.T) {
+	db := openTestStore(t)
+	defer db.Close()
+	if err := db.SetMany(map[string]any{"big": 300, "fraction": 1.7, "negative": -1, "whole": 2.0}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	var u8 uint8
+	if err := db.GetInto("big", &u8); !errors.Is(err, TypeMismatchErr) {
+		t.Errorf(`expected TypeMismatchErr for overflow, got %v, %v`, u8, err)
+	}
+	var n int
+	if err := db.GetInto("fraction", &n); !errors.Is(err, TypeMismatchErr) {
+		t.Errorf(`expected TypeMismatchErr for fraction, got %v, %v`, n, err)
+	}
+	var u uint
+	if err := db.GetInto("negative", &u); !errors.Is(err, TypeMismatchErr) {
+		t.Errorf(`expected TypeMismatchErr for negative value, got %v, %v`, u, err)
+	}
+	if err := db.GetInto("whole", &u8); err != nil || u8 != 2 {
+		t.Errorf(`expected whole number to be converted, got %v, %v`, u8, err)
+	}
+	var f32 float32
+	if err := db.GetInto("fraction", &f32); err != nil || f32 != 1.7 {
+		t.Errorf(`expected float to be converted, got %v, %v`, f32, err)
+	}
+}
+
+type intoStruct struct {
+	Name string
+}
+
+type intoPointer struct {
+	Name string
+}
+
+func TestTimeAndDuration(t *testing.T) {
+	db := openTestStore(t)
+	defer db.Close()
+	now := time.Now()
+	if err := db.SetTime("time", now); err != nil {
+		t.Fatalf(`failed to set time: %v`, err)
+	}
+	if v, _ := db.Get("time"); v != now.Format(time.RFC3339Nano) {
+		t.Errorf(`expected time to be stored as RFC3339 string, got %v`, v)
+	}
+	if tm, err := db.GetTime("time"); !tm.Equal(now) || err != nil {
+		t.Errorf(`GetTime: got %v, %v`, tm, err)
+	}
+	if err := db.SetDuration("duration", 90*time.Second); err != nil {
+		t.Fatalf(`failed to set duration: %v`, err)
+	}
+	if v, _ := db.Get("duration"); v != int64(90*time.Second) {
+		t.Errorf(`expected duration to be stored as nanoseconds, got %v`, v)
+	}
+	if d, err := db.GetDuration("duration"); d != 90*time.Second || err != nil {
+		t.Errorf(`GetDuration: got %v, %v`, d, err)
+	}
+	if err := db.SetMany(map[string]any{"text": "1m30s", "bool": true, "invalid": "soon"}); err != nil {
+		t.Fatalf(`failed to set many: %v`, err)
+	}
+	if d, err := db.GetDuration("text"); d != 90*time.Second || err != nil {
+		t.Errorf(`GetDuration with string: got %v, %v`, d, err)
+	}
+	if _, err := db.GetDuration("bool"); !errors.Is(err, TypeMismatchErr) {
+		t.Errorf(`expected TypeMismatchErr, got %v`, err)
+	}
+	var ke *KeyError
+	if _, err := db.GetDuration("invalid"); !errors.As(err, &ke) || ke.Key != "invalid" || ke.Code != CodeTypeMismatch {
+		t.Errorf(`expected KeyError with TypeMismatchErr for unparsable duration, got %v`, err)
+	}
+	if _, err := db.GetTime("invalid"); !errors.As(err, &ke) || ke.Key != "invalid" || ke.Code != CodeTypeMismatch {
+		t.Errorf(`expected KeyError with TypeMismatchErr for unparsable time, got %v`, err)
+	}
+}
@@ -0,0 +1,176 @@
+package kvstore
+
+import (
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// knownTypes holds all types registered with RegisterTypes in this process by type name.
+var knownTypes = struct {
+	sync.RWMutex
+	m map[string]any
+}{m: make(map[string]any)}
+
+// initTypes adds the columns holding the type names of values and defaults, creates the table recording the
+// names of registered types, and registers the recorded types.
+func (db *KVStore) initTypes(tx *sqlx.Tx) error {
+	if err := addColumn(tx, "kv", "value_type", "TEXT"); err != nil {
+		return err
+	}
+	if err := addColumn(tx, "kv", "original_type", "TEXT"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS kv_types(name TEXT PRIMARY KEY NOT NULL);`); err != nil {
+		return err
+	}
+	return db.registerTypes(tx)
+}
+
+// RegisterTypes registers the concrete types of the given values with gob, so that values of these types can
+// be stored as interface values, and records their names in the store. When a store is opened, all types
+// recorded in it that have been registered in this process by any store are registered with gob again, and
+// UnregisteredTypes reports those that are missing. RegisterTypes may be called before Open, in which case the
+// types are recorded when the store is opened.
+func (db *KVStore) RegisterTypes(values ...any) error {
+	names := make([]string, 0, len(values))
+	knownTypes.Lock()
+	for _, v := range values {
+		if err := registerGob(v); err != nil {
+			knownTypes.Unlock()
+			return err
+		}
+		name := reflect.TypeOf(v).String()
+		knownTypes.m[name] = v
+		names = append(names, name)
+	}
+	knownTypes.Unlock()
+	if atomic.LoadUint32(&db.state) < 256 {
+		db.types = append(db.types, names...)
+		return nil
+	}
+	return db.inTx(func(tx *sqlx.Tx) error {
+		return recordTypes(tx, names)
+	})
+}
+
+// registerGob registers the type of v with gob and returns an error instead of panicking if gob rejects it,
+// for example because the type has already been registered under a different name.
+func registerGob(v any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	gob.Register(v)
+	return nil
+}
+
+// recordTypes records the given type names in the store.
+func recordTypes(tx *sqlx.Tx, names []string) error {
+	for _, name := range names {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO kv_types(name) VALUES(?);`, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerTypes records the types registered before the store was opened and registers all recorded types
+// known in this process with gob.
+func (db *KVStore) registerTypes(tx *sqlx.Tx) error {
+	if err := recordTypes(tx, db.types); err != nil {
+		return err
+	}
+	db.types = nil
+	var names []string
+	if err := tx.Select(&names, `SELECT name FROM kv_types;`); err != nil {
+		return err
+	}
+	knownTypes.RLock()
+	defer knownTypes.RUnlock()
+	for _, name := range names {
+		if v, ok := knownTypes.m[name]; ok {
+			gob.Register(v)
+		}
+	}
+	return nil
+}
+
+// UnregisteredTypes returns the names of types recorded in the store by RegisterTypes that have not been
+// registered in this process. Values of these types cannot be decoded.
+func (db *KVStore) UnregisteredTypes() ([]string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return nil, NotOpenErr
+	}
+	var names []string
+	if err := db.sqx.Select(&names, `SELECT name FROM kv_types ORDER BY name;`); err != nil {
+		return nil, err
+	}
+	knownTypes.RLock()
+	defer knownTypes.RUnlock()
+	missing := make([]string, 0)
+	for _, name := range names {
+		if _, ok := knownTypes.m[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// typeName returns the name of the concrete type of v as recorded in the store, the empty string if v is nil.
+func typeName(v any) string {
+	if v == nil {
+		return ""
+	}
+	return reflect.TypeOf(v).String()
+}
+
+// nullString returns s, or nil if s is empty so that it is stored as NULL.
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// TypeOf returns the name of the concrete Go type of the value for the given key, or of the default if no
+// value is set, as it was when the value was stored, such as "int" or "*mypkg.Settings". This works even if
+// the type is not known in this process and the value cannot be decoded. For values stored by versions that
+// did not record types, the type is determined by decoding the value. The empty string is returned for nil
+// values.
+func (db *KVStore) TypeOf(key string) (string, error) {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return "", NotOpenErr
+	}
+	if err := db.flushPending(); err != nil {
+		return "", err
+	}
+	var typ sql.NullString
+	var sv storedValue
+	err := db.sqx.QueryRowx(`SELECT CASE WHEN kv.value IS NOT NULL THEN kv.value_type ELSE kv.original_type END,`+
+		db.valueColumns()+` FROM kv WHERE key=?;`, key).Scan(append([]any{&typ}, sv.dest()...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", NotFoundErr
+	}
+	if err != nil {
+		return "", err
+	}
+	if typ.Valid {
+		return typ.String, nil
+	}
+	v, ok, err := sv.decode()
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", NotFoundErr
+	}
+	return typeName(v), nil
+}
@@ -0,0 +1,97 @@
+package kvstore
+
+import "testing"
+
+type registeredType struct {
+	Name string
+}
+
+type unregisteredType struct {
+	Name string
+}
+
+type pointerType struct {
+	Name string
+}
+
+func TestRegisterTypes(t *testing.T) {
+	dir := t.TempDir()
+	db := New()
+	if err := db.RegisterTypes(registeredType{}); err != nil {
+		t.Fatalf(`failed to register types before Open: %v`, err)
+	}
+	if err := db.Open(dir); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	if err := db.Set("a", registeredType{Name: "a"}); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.RegisterTypes(&unregisteredType{}); err != nil {
+		t.Fatalf(`failed to register types: %v`, err)
+	}
+	db.Close()
+	// simulate a process that has not registered the second type
+	knownTypes.Lock()
+	delete(knownTypes.m, "*kvstore.unregisteredType")
+	knownTypes.Unlock()
+	db = New()
+	if err := db.Open(dir); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	if v, err := db.Get("a"); err != nil || v != (registeredType{Name: "a"}) {
+		t.Errorf(`expected registered type, got %v, %v`, v, err)
+	}
+	missing, err := db.UnregisteredTypes()
+	if err != nil {
+		t.Fatalf(`failed to get unregistered types: %v`, err)
+	}
+	if len(missing) != 1 || missing[0] != "*kvstore.unregisteredType" {
+		t.Errorf(`expected unregistered pointer type, got %v`, missing)
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	db := openTestStore(t)
+	defer db.Close()
+	if err := db.RegisterTypes(&pointerType{}); err != nil {
+		t.Fatalf(`failed to register types: %v`, err)
+	}
+	if err := db.RegisterTypes(pointerType{}); err == nil {
+		t.Errorf(`expected error when registering type under a different name`)
+	}
+	if err := db.SetDefault("a", 1, KeyInfo{}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if typ, err := db.TypeOf("a"); err != nil || typ != "int" {
+		t.Errorf(`expected type of default, got %q, %v`, typ, err)
+	}
+	if err := db.Set("a", &pointerType{Name: "a"}); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if typ, err := db.TypeOf("a"); err != nil || typ != "*kvstore.pointerType" {
+		t.Errorf(`expected type of value, got %q, %v`, typ, err)
+	}
+	if err := db.Revert("a"); err != nil {
+		t.Fatalf(`failed to revert key: %v`, err)
+	}
+	if typ, err := db.TypeOf("a"); err != nil || typ != "int" {
+		t.Errorf(`expected type of default after Revert, got %q, %v`, typ, err)
+	}
+	if err := db.SetRaw("b", []byte("raw")); err != nil {
+		t.Fatalf(`failed to set raw value: %v`, err)
+	}
+	if typ, err := db.TypeOf("b"); err != nil || typ != "[]uint8" {
+		t.Errorf(`expected type of raw value, got %q, %v`, typ, err)
+	}
+	// values stored without type are decoded
+	if _, err := db.sqx.Exec(`UPDATE kv SET value_type=NULL WHERE key='b';`); err != nil {
+		t.Fatalf(`failed to update table: %v`, err)
+	}
+	if typ, err := db.TypeOf("b"); err != nil || typ != "[]uint8" {
+		t.Errorf(`expected type of decoded value, got %q, %v`, typ, err)
+	}
+	if _, err := db.TypeOf("missing"); err != NotFoundErr {
+		t.Errorf(`expected NotFoundErr, got %v`, err)
+	}
+}
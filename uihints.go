@@ -0,0 +1,32 @@
+package kvstore
+
+// Widget names the kind of input element a settings user interface should use for a key. Applications may use
+// their own widget names in addition to the predefined ones.
+type Widget string
+
+const (
+	WidgetAuto     Widget = ""         // chosen by the user interface, e.g. based on the type of the default
+	WidgetCheckbox Widget = "checkbox" // a checkbox or switch for boolean values
+	WidgetText     Widget = "text"     // a single line text entry
+	WidgetTextArea Widget = "textarea" // a multi-line text entry
+	WidgetPassword Widget = "password" // a text entry hiding its contents
+	WidgetNumber   Widget = "number"   // a numeric entry, e.g. a spin box
+	WidgetSlider   Widget = "slider"   // a slider between Min and Max of the key info
+	WidgetSelect   Widget = "select"   // a drop-down list of the values in Enum of the key info
+	WidgetRadio    Widget = "radio"    // radio buttons for the values in Enum of the key info
+	WidgetColor    Widget = "color"    // a color picker
+	WidgetFile     Widget = "file"     // a file chooser
+	WidgetDir      Widget = "dir"      // a directory chooser
+)
+
+// UIHints are hints for presenting a key in a settings user interface, so that preference dialogs can be
+// generated from the store without a separate hard-coded description of the keys. They are stored with the key
+// info by SetDefault and included in the schema. The store itself does not interpret them.
+type UIHints struct {
+	Widget          Widget `json:"widget,omitempty"`
+	Label           string `json:"label,omitempty"`           // the display name of the key
+	Order           int    `json:"order,omitempty"`           // keys are shown by ascending order within their category
+	Hidden          bool   `json:"hidden,omitempty"`          // the key is not shown, e.g. because it is internal
+	Advanced        bool   `json:"advanced,omitempty"`        // the key is only shown in an advanced view
+	RequiresRestart bool   `json:"requiresRestart,omitempty"` // changes take effect after restarting the application
+}
@@ -0,0 +1,39 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUIHints(t *testing.T) {
+	db := openTestStore(t)
+	hints := UIHints{Widget: WidgetSlider, Label: "Volume", Order: 2, Advanced: true, RequiresRestart: true}
+	if err := db.SetDefault("volume", 50, KeyInfo{Category: "audio", UIHints: hints}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	if err := db.SetDefault("device", "", KeyInfo{UIHints: UIHints{Hidden: true}}); err != nil {
+		t.Fatalf(`failed to set default: %v`, err)
+	}
+	info, ok := db.Info("volume")
+	if !ok || info.UIHints != hints {
+		t.Errorf(`expected hints %+v, got %+v`, hints, info.UIHints)
+	}
+	if info, ok := db.Info("device"); !ok || !info.Hidden {
+		t.Errorf(`expected key to be hidden, got %+v`, info.UIHints)
+	}
+	b, err := db.ExportSchema()
+	if err != nil {
+		t.Fatalf(`failed to export schema: %v`, err)
+	}
+	var schema struct {
+		Keys []map[string]any `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatalf(`exported schema is not valid JSON: %v`, err)
+	}
+	volume := schema.Keys[1]
+	if volume["widget"] != "slider" || volume["label"] != "Volume" || volume["order"] != 2.0 ||
+		volume["requiresRestart"] != true {
+		t.Errorf(`expected hints in schema, got %v`, volume)
+	}
+}
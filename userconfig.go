@@ -0,0 +1,42 @@
+package kvstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var InvalidAppNameErr = errors.New(`invalid application name`)
+
+// OpenUserConfig creates a key value store configured with the given options and opens it in the directory named
+// after the application in the user's configuration directory, i.e. $XDG_CONFIG_HOME or ~/.config on Unix
+// systems, %AppData% on Windows, and ~/Library/Application Support on macOS, see os.UserConfigDir. The directory
+// is created with permissions 0700 if it does not exist, since preferences may contain private data. The
+// application name must not be empty or contain path separators; InvalidAppNameErr is returned otherwise.
+func OpenUserConfig(appName string, opts ...Option) (*KVStore, error) {
+	dir, err := userConfigPath(appName)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	db := New(opts...)
+	if err := db.Open(dir); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// userConfigPath returns the directory of the application's store in the user's configuration directory.
+func userConfigPath(appName string) (string, error) {
+	if appName == "" || appName == "." || appName == ".." || strings.ContainsAny(appName, `/\`) {
+		return "", InvalidAppNameErr
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, appName), nil
+}
@@ -0,0 +1,41 @@
+package kvstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestOpenUserConfig(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip(`configuration directory is set with XDG_CONFIG_HOME on Linux only`)
+	}
+	base := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", base)
+	db, err := OpenUserConfig("myapp")
+	if err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer db.Close()
+	if err := db.Set("theme", "dark"); err != nil {
+		t.Fatalf(`set: %v`, err)
+	}
+	dir := filepath.Join(base, "myapp")
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf(`stat: %v`, err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf(`expected permissions 0700, got %v`, info.Mode().Perm())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "kvstore.sqlite")); err != nil {
+		t.Errorf(`expected database file in %v: %v`, dir, err)
+	}
+	for _, name := range []string{"", "..", "a/b", `a\b`} {
+		if _, err := OpenUserConfig(name); !errors.Is(err, InvalidAppNameErr) {
+			t.Errorf(`expected InvalidAppNameErr for %q, got %v`, name, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var ValidationErr = errors.New(`value rejected by validator`)
+
+// Validator checks a value before it is set for a key and returns an error if it must not be set.
+type Validator func(key string, value any) error
+
+// validators holds the validators registered in this process.
+type validators struct {
+	mutex    sync.RWMutex
+	keys     map[string][]Validator
+	prefixes map[string][]Validator
+}
+
+// RegisterValidator registers a validator for the given key. Set and all other methods setting values call the
+// validators registered for a key before writing a value, and return an error wrapping both ValidationErr and
+// the validator's error if a validator rejects the value. Raw values are passed to validators as []byte.
+// Validators are kept when the store is closed and opened again, and a key may have several validators.
+func (db *KVStore) RegisterValidator(key string, v Validator) {
+	db.validators.mutex.Lock()
+	defer db.validators.mutex.Unlock()
+	if db.validators.keys == nil {
+		db.validators.keys = make(map[string][]Validator)
+	}
+	db.validators.keys[key] = append(db.validators.keys[key], v)
+}
+
+// RegisterPrefixValidator registers a validator for all keys starting with the given prefix, see
+// RegisterValidator.
+func (db *KVStore) RegisterPrefixValidator(prefix string, v Validator) {
+	db.validators.mutex.Lock()
+	defer db.validators.mutex.Unlock()
+	if db.validators.prefixes == nil {
+		db.validators.prefixes = make(map[string][]Validator)
+	}
+	db.validators.prefixes[prefix] = append(db.validators.prefixes[prefix], v)
+}
+
+// validate runs the validators registered for the key and its prefixes.
+func (db *KVStore) validate(key string, value any) error {
+	db.validators.mutex.RLock()
+	defer db.validators.mutex.RUnlock()
+	for _, v := range db.validators.keys[key] {
+		if err := v(key, value); err != nil {
+			return fmt.Errorf("%w: %w", ValidationErr, err)
+		}
+	}
+	for prefix, vs := range db.validators.prefixes {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		for _, v := range vs {
+			if err := v(key, value); err != nil {
+				return fmt.Errorf("%w: %w", ValidationErr, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateKeyInfo returns a validator rejecting values that violate the constraints of the key info, regardless
+// of whether they are enforced. Errors wrap ConstraintErr.
+func ValidateKeyInfo(info KeyInfo) Validator {
+	return func(key string, value any) error {
+		return info.Check(value)
+	}
+}
+
+// ValidateType returns a validator rejecting values whose type as printed by fmt's %T verb is not the given
+// type, e.g. "int".
+func ValidateType(typeName string) Validator {
+	return ValidateKeyInfo(KeyInfo{ValueType: typeName})
+}
+
+// ValidateRange returns a validator rejecting values that are not numeric or not between min and max,
+// inclusively.
+func ValidateRange(min, max float64) Validator {
+	return ValidateKeyInfo(KeyInfo{Min: &min, Max: &max})
+}
+
+// ValidateEnum returns a validator rejecting values that are not one of the given values.
+func ValidateEnum(values ...any) Validator {
+	return ValidateKeyInfo(KeyInfo{Enum: values})
+}
@@ -0,0 +1,45 @@
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidators(t *testing.T) {
+	db := openTestStore(t)
+	db.RegisterValidator("volume", ValidateRange(0, 100))
+	db.RegisterValidator("theme", ValidateEnum("light", "dark"))
+	db.RegisterPrefixValidator("net.", func(key string, value any) error {
+		if s, ok := value.(string); !ok || strings.ContainsAny(s, " \t") {
+			return fmt.Errorf("malformed host %v", value)
+		}
+		return nil
+	})
+	if err := db.Set("volume", 50); err != nil {
+		t.Errorf(`failed to set valid value: %v`, err)
+	}
+	err := db.Set("volume", 150)
+	if !errors.Is(err, ValidationErr) || !errors.Is(err, ConstraintErr) {
+		t.Errorf(`expected ValidationErr and ConstraintErr, got %v`, err)
+	}
+	if v, _ := db.Get("volume"); v != 50 {
+		t.Errorf(`expected rejected value not to be set, got %v`, v)
+	}
+	if err := db.SetMany(map[string]any{"theme": "blue"}); !errors.Is(err, ValidationErr) {
+		t.Errorf(`expected ValidationErr from SetMany, got %v`, err)
+	}
+	if err := db.Set("net.proxy", "example.com"); err != nil {
+		t.Errorf(`failed to set valid value: %v`, err)
+	}
+	if err := db.Set("net.proxy", "not a host"); !errors.Is(err, ValidationErr) {
+		t.Errorf(`expected ValidationErr from prefix validator, got %v`, err)
+	}
+	if err := db.Set("other", 150); err != nil {
+		t.Errorf(`expected key without validator to accept value, got %v`, err)
+	}
+	if err := ValidateType("int")("count", "1"); !errors.Is(err, ConstraintErr) {
+		t.Errorf(`expected ConstraintErr from type validator, got %v`, err)
+	}
+}
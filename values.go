@@ -0,0 +1,104 @@
+package kvstore
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// Codecs stored in the codec column of the kv table. Values without codec are gob encoded.
+const (
+	codecGob  = ""
+	codecJSON = "json"
+	codecRaw  = "raw"
+)
+
+// valueColumns are the columns of the kv table scanned into a storedValue. Values kept in external files
+// are read with the kv_external function, see ExternalValues, and deduplicated values and defaults from
+// the content table, see Deduplicate.
+var valueColumns = `CASE WHEN kv.external IS NOT NULL THEN kv_external(kv.external) ELSE ` + contentColumn("value") + ` END,` +
+	originalColumn + `,kv.codec`
+
+// plainValueColumns are the columns scanned into a storedValue for stores opened with OpenWithDB or with a
+// driver other than the default one, whose connections do not provide the kv_external function and which
+// cannot keep values in external files.
+var plainValueColumns = contentColumn("value") + `,` + originalColumn + `,kv.codec`
+
+// valueColumns returns the columns of the kv table scanned into a storedValue.
+func (db *KVStore) valueColumns() string {
+	if db.shared || !externalFunction {
+		return plainValueColumns
+	}
+	return valueColumns
+}
+
+// storedValue holds the columns of a row of the kv table needed to decode its value and default.
+type storedValue struct {
+	value    []byte
+	original []byte
+	codec    sql.NullString
+}
+
+// dest returns the scan destinations for valueColumns.
+func (sv *storedValue) dest() []any {
+	return []any{&sv.value, &sv.original, &sv.codec}
+}
+
+// decode decodes the value if there is one and otherwise the default, and applies registered migrations to
+// it. It returns false if neither of them is present.
+func (sv *storedValue) decode() (any, bool, error) {
+	var v any
+	var err error
+	switch {
+	case sv.value != nil:
+		v, err = sv.decodeValue()
+	case sv.original != nil:
+		v, err = UnmarshalBinary(sv.original)
+	default:
+		return nil, false, nil
+	}
+	if err == nil {
+		v, _, err = migrate(v)
+	}
+	return v, err == nil, err
+}
+
+// decodeValue decodes the value according to its codec. Defaults are always gob encoded.
+func (sv *storedValue) decodeValue() (any, error) {
+	value := sv.value
+	codec, encrypted := plainCodec(sv.codec.String)
+	if encrypted {
+		var err error
+		if value, err = decrypt(value); err != nil {
+			return nil, err
+		}
+	}
+	switch codec {
+	case codecGob:
+		return UnmarshalBinary(value)
+	case codecJSON:
+		var v any
+		err := json.Unmarshal(value, &v)
+		return v, err
+	case codecRaw:
+		return value, nil
+	}
+	c, err := lookupCodec(codec)
+	if err != nil {
+		return nil, err
+	}
+	return c.Unmarshal(value)
+}
+
+// encrypted returns true if the value or default is encrypted.
+func (sv *storedValue) encrypted() bool {
+	_, encrypted := plainCodec(sv.codec.String)
+	return encrypted || isEncrypted(sv.value) || isEncrypted(sv.original)
+}
+
+// size returns the encoded size of the value if there is one, and of the default otherwise.
+func (sv *storedValue) size() int64 {
+	if sv.value != nil {
+		return int64(len(sv.value))
+	}
+	return int64(len(sv.original))
+}
@@ -0,0 +1,36 @@
+package kvstore
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// VFS configures the store to open its database with the sqlite VFS registered under the given name instead of
+// the file system of the operating system, for example a VFS registered with the vfs package of the sqlite driver
+// that keeps files in the origin private file system (OPFS) of a browser when running under GOOS=js. The path
+// given to Open is then a slash-separated directory within the VFS, and no directories are created on disk.
+// Values cannot be kept in external files, see ExternalValues. If the VFS does not support locking, limit the
+// connection pool to one connection with ConnectionPool(1, 1).
+func VFS(name string) Option {
+	return func(o *options) {
+		o.vfs = name
+	}
+}
+
+// vfsDataSourceName returns the sqlite URI for the database file in the directory dir of the configured VFS.
+func (db *KVStore) vfsDataSourceName(dir string) (string, error) {
+	if db.opts.externalThreshold > 0 {
+		return "", fmt.Errorf("%w: ExternalValues", UnsupportedErr)
+	}
+	db.path = path.Join("/", strings.ReplaceAll(dir, "\\", "/"), "kvstore.sqlite")
+	p := (&url.URL{Path: db.path}).EscapedPath()
+	return dataSourceName(p, db.opts.vfs, db.opts.retry.busyTimeout()), nil
+}
+
+// unlocked returns true if the database file is opened without file locking, which requires a single
+// connection.
+func (db *KVStore) unlocked() bool {
+	return !fileLocking && db.memory == "" && db.opts.vfs == "" && !db.shared
+}
@@ -0,0 +1,43 @@
+//go:build !kvstore_modernc && !kvstore_mattn
+
+package kvstore
+
+import (
+	"errors"
+	"testing"
+
+	_ "github.com/ncruces/go-sqlite3/vfs/memdb"
+)
+
+func TestVFS(t *testing.T) {
+	db := New(VFS("memdb"))
+	if err := db.Open("prefs/app"); err != nil {
+		t.Fatalf(`open: %v`, err)
+	}
+	defer db.Close()
+	if db.path != "/prefs/app/kvstore.sqlite" {
+		t.Errorf(`expected path in VFS, got %q`, db.path)
+	}
+	if err := db.Set("theme", "dark"); err != nil {
+		t.Fatalf(`set: %v`, err)
+	}
+	v, err := db.Get("theme")
+	if err != nil || v != "dark" {
+		t.Errorf(`expected "dark", got %v, %v`, v, err)
+	}
+	stats, err := db.Stats()
+	if err != nil || stats.FileSize <= 0 {
+		t.Errorf(`expected size of database in VFS, got %v, %v`, stats.FileSize, err)
+	}
+	if err := New(VFS("memdb"), ExternalValues(10)).Open("prefs/other"); !errors.Is(err, UnsupportedErr) {
+		t.Errorf(`expected UnsupportedErr, got %v`, err)
+	}
+}
+
+func TestVFSUnknown(t *testing.T) {
+	db := New(VFS("nonexistent"))
+	if err := db.Open("prefs"); err == nil {
+		db.Close()
+		t.Errorf(`expected error for unknown VFS`)
+	}
+}
@@ -0,0 +1,165 @@
+package kvstore
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WriteBehind configures the store to coalesce Set calls into batched transactions that are written every
+// interval, or as soon as maxBatch distinct keys are pending if maxBatch is positive. Get returns pending
+// values, and all other operations write pending values first. Use Flush to write pending values
+// immediately; Close also writes all pending values. If writing pending values in the background fails, they
+// remain pending and the error is returned by the next operation that writes them. Values are lost if the
+// process terminates before they have been written.
+func WriteBehind(interval time.Duration, maxBatch int) Option {
+	return func(o *options) {
+		o.flushInterval = interval
+		o.maxBatch = maxBatch
+	}
+}
+
+// writeBehind holds pending writes in write-behind mode.
+type writeBehind struct {
+	mutex   sync.Mutex
+	flushMu sync.Mutex // serializes flushes so that pending values are written in order
+	pending map[string]pendingWrite
+	kick    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// pendingWrite is an encoded value pending in write-behind mode together with the name of its type.
+type pendingWrite struct {
+	b   []byte
+	typ string
+}
+
+// startWriteBehind starts the background flusher if write-behind mode is configured.
+func (db *KVStore) startWriteBehind() {
+	if db.opts.flushInterval <= 0 {
+		return
+	}
+	wb := &db.writeBehind
+	wb.pending = make(map[string]pendingWrite)
+	wb.kick = make(chan struct{}, 1)
+	wb.stop = make(chan struct{})
+	wb.stopped = make(chan struct{})
+	go func() {
+		defer close(wb.stopped)
+		ticker := time.NewTicker(db.opts.flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-wb.stop:
+				return
+			case <-ticker.C:
+			case <-wb.kick:
+			}
+			// failed writes remain pending, and the error is returned by the next read or write, by Flush, or by
+			// Close, all of which write pending values first
+			db.flushPending()
+		}
+	}()
+}
+
+// stopWriteBehind stops the background flusher and writes all pending values.
+func (db *KVStore) stopWriteBehind() error {
+	if db.opts.flushInterval <= 0 {
+		return nil
+	}
+	close(db.writeBehind.stop)
+	<-db.writeBehind.stopped
+	return db.flushPending()
+}
+
+// Flush waits for all writes queued with SetAsync before the call and then writes all values pending in
+// write-behind mode in one transaction.
+func (db *KVStore) Flush() error {
+	if atomic.LoadUint32(&db.state) < 256 {
+		return NotOpenErr
+	}
+	db.waitAsync()
+	return db.flushPending()
+}
+
+// flushPending writes all pending values. If this fails, values that have not been set again in the meantime
+// remain pending.
+func (db *KVStore) flushPending() error {
+	if db.opts.flushInterval <= 0 {
+		return nil
+	}
+	wb := &db.writeBehind
+	wb.flushMu.Lock()
+	defer wb.flushMu.Unlock()
+	wb.mutex.Lock()
+	batch := wb.pending
+	wb.pending = make(map[string]pendingWrite)
+	wb.mutex.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+	err := db.writeBatch(batch)
+	if err != nil {
+		wb.mutex.Lock()
+		for k, w := range batch {
+			if _, ok := wb.pending[k]; !ok {
+				wb.pending[k] = w
+			}
+		}
+		wb.mutex.Unlock()
+	}
+	return err
+}
+
+// writeBatch writes encoded values in one transaction.
+func (db *KVStore) writeBatch(batch map[string]pendingWrite) error {
+	tx, err := db.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for k, w := range batch {
+		if err := db.put(tx, k, w.b, w.typ); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return db.evict(slices.Collect(maps.Keys(batch))...)
+}
+
+// setBehind adds an encoded value of the named type to the pending values and returns true, or returns false if
+// the store is not in write-behind mode.
+func (db *KVStore) setBehind(key string, b []byte, typ string) bool {
+	if db.opts.flushInterval <= 0 {
+		return false
+	}
+	wb := &db.writeBehind
+	wb.mutex.Lock()
+	wb.pending[key] = pendingWrite{b: b, typ: typ}
+	full := db.opts.maxBatch > 0 && len(wb.pending) >= db.opts.maxBatch
+	wb.mutex.Unlock()
+	if full {
+		select {
+		case wb.kick <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// pendingValue returns the value pending for the key in write-behind mode as a gob encoded stored value, so that
+// it is decoded like values read from the database, false if there is none.
+func (db *KVStore) pendingValue(key string) (storedValue, bool) {
+	if db.opts.flushInterval <= 0 {
+		return storedValue{}, false
+	}
+	db.writeBehind.mutex.Lock()
+	defer db.writeBehind.mutex.Unlock()
+	w, ok := db.writeBehind.pending[key]
+	return storedValue{value: w.b}, ok
+}
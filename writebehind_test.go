@@ -0,0 +1,109 @@
+package kvstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWriteBehind(t *testing.T) {
+	dir := t.TempDir()
+	db := New(WriteBehind(time.Hour, 0))
+	if err := db.Open(dir); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	stored := func(key string) bool {
+		var n int
+		if err := db.sqx.Get(&n, `SELECT COUNT(*) FROM kv WHERE key=?;`, key); err != nil {
+			t.Fatalf(`failed to query table: %v`, err)
+		}
+		return n > 0
+	}
+	for i := 0; i < 10; i++ {
+		if err := db.Set("slider", i); err != nil {
+			t.Fatalf(`failed to set key: %v`, err)
+		}
+	}
+	if stored("slider") {
+		t.Errorf(`value was written before flush`)
+	}
+	if v, err := db.Get("slider"); v != 9 || err != nil {
+		t.Errorf(`expected pending value 9, got %v, %v`, v, err)
+	}
+	if err := db.Flush(); err != nil {
+		t.Fatalf(`failed to flush: %v`, err)
+	}
+	if !stored("slider") {
+		t.Errorf(`value was not written by flush`)
+	}
+	if err := db.Set("pending", "value"); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf(`failed to close database: %v`, err)
+	}
+	db = New()
+	if err := db.Open(dir); err != nil {
+		t.Fatalf(`failed to reopen database: %v`, err)
+	}
+	defer db.Close()
+	if v, err := db.Get("pending"); v != "value" || err != nil {
+		t.Errorf(`pending value was not written on close, got %v, %v`, v, err)
+	}
+}
+
+func TestWriteBehindMaxBatch(t *testing.T) {
+	db := New(WriteBehind(time.Hour, 2))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	if err := db.Set("a", 1); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	if err := db.Set("b", 2); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var n int
+		if err := db.sqx.Get(&n, `SELECT COUNT(*) FROM kv;`); err != nil {
+			t.Fatalf(`failed to query table: %v`, err)
+		}
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf(`full batch was not written`)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWriteBehindMigration(t *testing.T) {
+	db := New(WriteBehind(time.Hour, 0))
+	if err := db.Open(t.TempDir()); err != nil {
+		t.Fatalf(`failed to open database: %v`, err)
+	}
+	defer db.Close()
+	if err := db.RegisterTypes(settingsV1{}, settingsV2{}); err != nil {
+		t.Fatalf(`failed to register types: %v`, err)
+	}
+	if err := db.Set("settings", settingsV1{Theme: "dark"}); err != nil {
+		t.Fatalf(`failed to set key: %v`, err)
+	}
+	RegisterMigration(func(old settingsV1) (settingsV2, error) {
+		return settingsV2{Theme: old.Theme, FontSize: 12}, nil
+	})
+	defer func() {
+		migrations.Lock()
+		clear(migrations.byType)
+		migrations.Unlock()
+	}()
+	want := settingsV2{Theme: "dark", FontSize: 12}
+	if v, err := db.Get("settings"); err != nil || v != want {
+		t.Errorf(`expected migrated pending value %v, got %v, %v`, want, v, err)
+	}
+	if v, source, err := db.GetWithSource("settings"); err != nil || v != want || source != SourceValue {
+		t.Errorf(`expected migrated pending value %v, got %v, %v, %v`, want, v, source, err)
+	}
+}